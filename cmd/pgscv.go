@@ -3,12 +3,21 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/lesovsky/pgscv/internal/collector"
+	"github.com/lesovsky/pgscv/internal/dashboard"
 	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/lesovsky/pgscv/internal/pgscv"
+	"github.com/lesovsky/pgscv/internal/rules"
+	"github.com/lesovsky/pgscv/internal/service"
 	"gopkg.in/alecthomas/kingpin.v2"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 )
 
 var (
@@ -19,10 +28,37 @@ func main() {
 	var (
 		showVersion = kingpin.Flag("version", "show version and exit").Default().Bool()
 		logLevel    = kingpin.Flag("log-level", "set log level: debug, info, warn, error").Default("info").Envar("LOG_LEVEL").String()
+		logFormat   = kingpin.Flag("log-format", "set log format: json, text").Default("json").Envar("LOG_FORMAT").String()
 		configFile  = kingpin.Flag("config-file", "path to config file").Default("").Envar("PGSCV_CONFIG_FILE").String()
+		describe    = kingpin.Flag("describe", "print all metrics which could be collected and exit: text, json").Default("").String()
+
+		_           = kingpin.Command("run", "run metrics collector (default)").Default()
+		checkConfig = kingpin.Command("check-config", "validate configuration and exit")
+		collectors  = kingpin.Command("collectors", "list collectors and their applicability to configured services")
+		scrape      = kingpin.Command("scrape", "collect metrics once, print them to stdout and exit")
+		scrapeSvc   = scrape.Flag("service", "collect metrics only from specified service").Default("").String()
+		scrapeColl  = scrape.Flag("collector", "collect metrics only using specified collector").Default("").String()
+		dashboards  = kingpin.Command("dashboards", "manage Grafana dashboards")
+		dashExport  = dashboards.Command("export", "print Grafana dashboard for metrics of enabled collectors")
+		dashTitle   = dashExport.Flag("title", "title of the dashboard").Default("pgSCV").String()
+		rulesCmd    = kingpin.Command("rules", "manage Prometheus alerting rules")
+		rulesExport = rulesCmd.Command("export", "print Prometheus alerting rules for metrics of enabled collectors")
+		checkCmd    = kingpin.Command("check", "evaluate thresholds and exit with Nagios-compatible status")
+		checkName   = checkCmd.Arg("name", "check name: "+strings.Join(pgscv.CheckNames(), ", ")).Required().Enum(pgscv.CheckNames()...)
+		checkSvc    = checkCmd.Flag("service", "evaluate check only for specified service").Default("").String()
+		checkWarn   = checkCmd.Flag("warning", "warning threshold, check default is used if not specified").Default("").String()
+		checkCrit   = checkCmd.Flag("critical", "critical threshold, check default is used if not specified").Default("").String()
 	)
-	kingpin.Parse()
+	command := kingpin.Parse()
 	log.SetLevel(*logLevel)
+	if command != "run" {
+		// Keep stdout clean for output of subcommands.
+		log.SetOutput(os.Stderr)
+	}
+	if err := log.SetFormat(*logFormat); err != nil {
+		log.Errorln("set log format failed: ", err)
+		os.Exit(1)
+	}
 	log.SetApplication(appName)
 
 	if *showVersion {
@@ -30,19 +66,50 @@ func main() {
 		os.Exit(0)
 	}
 
-	config, err := pgscv.NewConfig(*configFile)
-	if err != nil {
-		log.Errorln("create config failed: ", err)
-		os.Exit(1)
+	if *describe != "" {
+		if err := collector.WriteCatalog(os.Stdout, *describe); err != nil {
+			log.Errorln("describe metrics failed: ", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
-	if err := config.Validate(); err != nil {
-		log.Errorln("validate config failed: ", err)
+	if command == checkConfig.FullCommand() {
+		os.Exit(runCheckConfig(*configFile))
+	}
+
+	if command == collectors.FullCommand() {
+		os.Exit(runCollectors(*configFile))
+	}
+
+	if command == scrape.FullCommand() {
+		os.Exit(runScrape(*configFile, *scrapeSvc, *scrapeColl))
+	}
+
+	if command == dashExport.FullCommand() {
+		os.Exit(runDashboardsExport(*configFile, *dashTitle))
+	}
+
+	if command == rulesExport.FullCommand() {
+		os.Exit(runRulesExport(*configFile))
+	}
+
+	if command == checkCmd.FullCommand() {
+		os.Exit(runCheck(*configFile, *checkSvc, *checkName, *checkWarn, *checkCrit))
+	}
+
+	config, err := loadConfig(*configFile)
+	if err != nil {
+		log.Errorln(err)
 		os.Exit(1)
 	}
 
+	config.BinaryVersion = fmt.Sprintf("%s %s-%s", gitTag, gitCommit, gitBranch)
+
 	ctx, cancel := context.WithCancel(context.Background())
 
+	go listenLogLevelSignals(*logLevel)
+
 	var doExit = make(chan error, 2)
 	go func() {
 		doExit <- listenSignals()
@@ -57,6 +124,174 @@ func main() {
 	log.Warnf("received shutdown signal: '%s'", <-doExit)
 }
 
+// loadConfig reads configuration from file and environment and validates it.
+func loadConfig(configFile string) (*pgscv.Config, error) {
+	config, err := pgscv.NewConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("read config failed: %s", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validate config failed: %s", err)
+	}
+
+	return config, nil
+}
+
+// runCheckConfig reads and validates configuration, prints found problems and returns exit code.
+func runCheckConfig(configFile string) int {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	errs := config.Check()
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "check config failed: %s\n", err)
+		}
+		return 1
+	}
+
+	fmt.Println("config is valid")
+	return 0
+}
+
+// runCollectors prints collectors state for each configured service and returns exit code.
+func runCollectors(configFile string) int {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	services := map[string]service.ConnSetting{"system:0": {ServiceType: model.ServiceTypeSystem}}
+	for id, cs := range config.ServicesConnsSettings {
+		services[id] = cs
+	}
+
+	ids := make([]string, 0, len(services))
+	for id := range services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tCOLLECTOR\tENABLED\tINTERVAL\tSTATUS")
+
+	var failed bool
+	for _, id := range ids {
+		cs := services[id]
+		states, err := collector.States(cs.ServiceType, cs.Conninfo, config.DisableCollectors, config.CollectorsSettings)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\tservice unavailable: %s\n", id, err)
+			failed = true
+			continue
+		}
+
+		for _, s := range states {
+			status := "ok"
+			if s.Reason != "" {
+				status = "not applicable: " + s.Reason
+			}
+			interval := s.Interval
+			if interval == "" {
+				interval = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\n", id, s.Name, s.Enabled, interval, status)
+		}
+	}
+
+	_ = w.Flush()
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// runScrape collects metrics once, prints them to stdout and returns exit code.
+func runScrape(configFile string, serviceID string, collectorName string) int {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := pgscv.Scrape(os.Stdout, config, serviceID, collectorName); err != nil {
+		fmt.Fprintf(os.Stderr, "scrape failed: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// runDashboardsExport prints Grafana dashboard for metrics of enabled collectors and returns exit code.
+func runDashboardsExport(configFile string, title string) int {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	catalog, err := collector.Catalog(collector.Config{Labels: config.Labels, Settings: config.CollectorsSettings})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create metrics catalog failed: %s\n", err)
+		return 1
+	}
+
+	d := dashboard.New(title, collector.FilterCatalog(catalog, config.DisableCollectors))
+	if err := dashboard.Write(os.Stdout, d); err != nil {
+		fmt.Fprintf(os.Stderr, "write dashboard failed: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// runRulesExport prints Prometheus alerting rules for metrics of enabled collectors and returns exit code.
+func runRulesExport(configFile string) int {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := rules.Write(os.Stdout, rules.New(config.Alerts, config.DisableCollectors)); err != nil {
+		fmt.Fprintf(os.Stderr, "write rules failed: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// runCheck evaluates named check, prints its result and returns Nagios-compatible exit code.
+func runCheck(configFile string, serviceID string, name string, warning, critical string) int {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Printf("UNKNOWN - %s\n", err)
+		return pgscv.CheckUnknown
+	}
+
+	var thresholds [2]*float64
+	for i, s := range []string{warning, critical} {
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			fmt.Printf("UNKNOWN - invalid threshold '%s': %s\n", s, err)
+			return pgscv.CheckUnknown
+		}
+		thresholds[i] = &v
+	}
+
+	status, output := pgscv.Check(config, serviceID, name, thresholds[0], thresholds[1])
+	fmt.Println(output)
+	return status
+}
+
 func listenSignals() error {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
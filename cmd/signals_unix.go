@@ -0,0 +1,26 @@
+//go:build !windows
+
+package main
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// listenLogLevelSignals changes logging level at runtime: SIGUSR1 enables debug level, SIGUSR2 restores initial level.
+func listenLogLevelSignals(initial string) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	for sig := range c {
+		level := initial
+		if sig == syscall.SIGUSR1 {
+			level = "debug"
+		}
+
+		log.SetLevel(level)
+		log.Infof("received signal '%s', log level changed to %s", sig, log.Level())
+	}
+}
@@ -0,0 +1,5 @@
+package main
+
+// listenLogLevelSignals does nothing on Windows, because SIGUSR1 and SIGUSR2 signals are not supported. Use the
+// '/-/loglevel' endpoint for changing logging level at runtime.
+func listenLogLevelSignals(_ string) {}
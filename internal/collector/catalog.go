@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// MetricDescription describes a single metric which could be produced by collector.
+type MetricDescription struct {
+	Collector string   `json:"collector"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Labels    []string `json:"labels"`
+	Help      string   `json:"help"`
+}
+
+// Catalog returns descriptions of all metrics which could be produced by builtin collectors and user-defined metrics
+// specified in config. Collectors are created but not started, hence no connections are made.
+func Catalog(config Config) ([]MetricDescription, error) {
+	factories := newAllFactories()
+
+	constLabels := labels{"service_id": ""}
+	for k, v := range config.Labels {
+		constLabels[k] = v
+	}
+
+	// Constant labels follow variable labels, service_id goes first.
+	constNames := make([]string, 0, len(constLabels))
+	for name := range constLabels {
+		if name != "service_id" {
+			constNames = append(constNames, name)
+		}
+	}
+	sort.Strings(constNames)
+	constNames = append([]string{"service_id"}, constNames...)
+
+	var catalog []MetricDescription
+
+	for name, factory := range factories {
+		c, err := factory(constLabels, config.Settings[name])
+		if err != nil {
+			return nil, fmt.Errorf("create %s collector failed: %s", name, err)
+		}
+
+		var descs []typedDesc
+		for _, d := range c.descriptors() {
+			descs = append(descs, d)
+			if d.isnull != nil {
+				descs = append(descs, *d.isnull)
+			}
+		}
+
+		seen := map[string]bool{}
+		for _, d := range descs {
+			if d.fqName == "" || seen[d.fqName] {
+				continue
+			}
+			seen[d.fqName] = true
+
+			catalog = append(catalog, MetricDescription{
+				Collector: name,
+				Name:      d.fqName,
+				Type:      valueTypeString(d.valueType),
+				Labels:    append(append([]string{}, d.labelNames...), constNames...),
+				Help:      d.help,
+			})
+		}
+	}
+
+	sort.Slice(catalog, func(i, j int) bool {
+		if catalog[i].Collector != catalog[j].Collector {
+			return catalog[i].Collector < catalog[j].Collector
+		}
+		return catalog[i].Name < catalog[j].Name
+	})
+
+	return catalog, nil
+}
+
+// FilterCatalog returns descriptions of metrics produced by collectors which are not disabled.
+func FilterCatalog(catalog []MetricDescription, disabled []string) []MetricDescription {
+	var filtered []MetricDescription
+	for _, m := range catalog {
+		group := strings.Split(m.Collector, "/")[0]
+		if stringsContains(disabled, group) || stringsContains(disabled, m.Collector) {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+
+	return filtered
+}
+
+// WriteCatalog writes descriptions of all builtin metrics in specified format: 'text' or 'json'.
+func WriteCatalog(w io.Writer, format string) error {
+	catalog, err := Catalog(Config{})
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(catalog)
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "COLLECTOR\tNAME\tTYPE\tLABELS\tHELP")
+		for _, m := range catalog {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.Collector, m.Name, m.Type, strings.Join(m.Labels, ","), m.Help)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown format '%s', supported formats: text, json", format)
+	}
+}
+
+// valueTypeString returns string representation of metric value type.
+func valueTypeString(t prometheus.ValueType) string {
+	switch t {
+	case prometheus.CounterValue:
+		return "counter"
+	case prometheus.GaugeValue:
+		return "gauge"
+	default:
+		return "untyped"
+	}
+}
@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	catalog, err := Catalog(Config{})
+	assert.NoError(t, err)
+	assert.Greater(t, len(catalog), 0)
+
+	// Check well-known metrics of different collectors.
+	want := map[string]MetricDescription{
+		"postgres_table_seq_scan_total": {
+			Collector: "postgres/tables", Name: "postgres_table_seq_scan_total", Type: "counter",
+			Labels: []string{"database", "schema", "table", "service_id"}, Help: "The total number of sequential scans have been done.",
+		},
+		"node_load1":           {},
+		"node_memory_MemTotal": {},
+		"pgbouncer_up":         {},
+	}
+
+	found := map[string]bool{}
+	for _, m := range catalog {
+		if w, ok := want[m.Name]; ok {
+			found[m.Name] = true
+			if w.Name != "" {
+				assert.Equal(t, w, m)
+			}
+		}
+	}
+
+	for name := range want {
+		assert.True(t, found[name], name)
+	}
+}
+
+func TestCatalog_config(t *testing.T) {
+	catalog, err := Catalog(Config{
+		Labels: map[string]string{"env": "prod"},
+		Settings: model.CollectorsSettings{
+			"postgres/custom": {
+				NullValues: model.NullValuesFlag,
+				Subsystems: model.Subsystems{
+					"example": {
+						Query: "SELECT 'label1' as label1, 1 as value1",
+						Metrics: model.Metrics{
+							{ShortName: "value1", Usage: "GAUGE", Labels: []string{"label1"}, Value: "value1", Description: "value1 description"},
+						},
+					},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	found := map[string]MetricDescription{}
+	for _, m := range catalog {
+		found[m.Name] = m
+	}
+
+	// Constant labels are appended to labels of all metrics.
+	assert.Equal(t, []string{"database", "schema", "table", "service_id", "env"}, found["postgres_table_seq_scan_total"].Labels)
+
+	// User-defined metrics and their NULL flags are described.
+	assert.Equal(t, MetricDescription{
+		Collector: "postgres/custom", Name: "postgres_example_value1", Type: "gauge",
+		Labels: []string{"label1", "service_id", "env"}, Help: "value1 description",
+	}, found["postgres_example_value1"])
+	assert.Contains(t, found, "postgres_example_value1_isnull")
+}
+
+func TestWriteCatalog(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteCatalog(&buf, "text"))
+	assert.Contains(t, buf.String(), "COLLECTOR")
+	assert.Contains(t, buf.String(), "postgres_table_seq_scan_total")
+
+	buf.Reset()
+	assert.NoError(t, WriteCatalog(&buf, "json"))
+	var catalog []MetricDescription
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &catalog))
+	assert.Greater(t, len(catalog), 0)
+
+	assert.Error(t, WriteCatalog(&buf, "invalid"))
+}
+
+func TestFilterCatalog(t *testing.T) {
+	catalog := []MetricDescription{
+		{Collector: "postgres/database", Name: "a"},
+		{Collector: "postgres/tables", Name: "b"},
+		{Collector: "system/cpu", Name: "c"},
+		{Collector: "pgbouncer/pools", Name: "d"},
+	}
+
+	assert.Equal(t, catalog, FilterCatalog(catalog, nil))
+	assert.Equal(t,
+		[]MetricDescription{{Collector: "postgres/database", Name: "a"}, {Collector: "pgbouncer/pools", Name: "d"}},
+		FilterCatalog(catalog, []string{"system", "postgres/tables"}),
+	)
+}
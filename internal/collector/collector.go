@@ -1,11 +1,20 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
 	"github.com/lesovsky/pgscv/internal/filter"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/relabel"
 	"github.com/prometheus/client_golang/prometheus"
+	"net"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Factories defines collector functions which used for collecting metrics.
@@ -18,20 +27,7 @@ func (f Factories) RegisterSystemCollectors(disabled []string) {
 		return
 	}
 
-	funcs := map[string]func(labels, model.CollectorSettings) (Collector, error){
-		"system/pgscv":       NewPgscvServicesCollector,
-		"system/sysinfo":     NewSysInfoCollector,
-		"system/loadaverage": NewLoadAverageCollector,
-		"system/cpu":         NewCPUCollector,
-		"system/diskstats":   NewDiskstatsCollector,
-		"system/filesystems": NewFilesystemCollector,
-		"system/netdev":      NewNetdevCollector,
-		"system/network":     NewNetworkCollector,
-		"system/memory":      NewMeminfoCollector,
-		"system/sysconfig":   NewSysconfigCollector,
-	}
-
-	for name, fn := range funcs {
+	for name, fn := range systemCollectors() {
 		if stringsContains(disabled, name) {
 			log.Debugln("disable ", name)
 			continue
@@ -54,20 +50,36 @@ func (f Factories) RegisterPostgresCollectors(disabled []string) {
 		"postgres/activity":          NewPostgresActivityCollector,
 		"postgres/archiver":          NewPostgresWalArchivingCollector,
 		"postgres/bgwriter":          NewPostgresBgwriterCollector,
+		"postgres/bloat":             NewPostgresBloatCollector,
 		"postgres/conflicts":         NewPostgresConflictsCollector,
 		"postgres/databases":         NewPostgresDatabasesCollector,
+		"postgres/ddl":               NewPostgresDDLCollector,
+		"postgres/fdw":               NewPostgresFdwCollector,
 		"postgres/indexes":           NewPostgresIndexesCollector,
 		"postgres/functions":         NewPostgresFunctionsCollector,
 		"postgres/locks":             NewPostgresLocksCollector,
+		"postgres/logical":           NewPostgresLogicalCollector,
 		"postgres/logs":              NewPostgresLogsCollector,
+		"postgres/memory":            NewPostgresMemoryCollector,
+		"postgres/plans":             NewPostgresPlansCollector,
+		"postgres/progress":          NewPostgresProgressCollector,
+		"postgres/recovery":          NewPostgresRecoveryCollector,
+		"postgres/relations":         NewPostgresRelationsCollector,
 		"postgres/replication":       NewPostgresReplicationCollector,
 		"postgres/replication_slots": NewPostgresReplicationSlotsCollector,
 		"postgres/statements":        NewPostgresStatementsCollector,
 		"postgres/schemas":           NewPostgresSchemasCollector,
 		"postgres/settings":          NewPostgresSettingsCollector,
+		"postgres/shmem":             NewPostgresShmemCollector,
+		"postgres/slru":              NewPostgresSlruCollector,
+		"postgres/ssl":               NewPostgresSSLCollector,
 		"postgres/storage":           NewPostgresStorageCollector,
+		"postgres/subscriptions":     NewPostgresSubscriptionsCollector,
 		"postgres/tables":            NewPostgresTablesCollector,
+		"postgres/unused_indexes":    NewPostgresUnusedIndexesCollector,
 		"postgres/wal":               NewPostgresWalCollector,
+		"postgres/walinspect":        NewPostgresWalInspectCollector,
+		"postgres/walreceiver":       NewPostgresWalReceiverCollector,
 		"postgres/custom":            NewPostgresCustomCollector,
 	}
 
@@ -106,6 +118,137 @@ func (f Factories) RegisterPgbouncerCollectors(disabled []string) {
 	}
 }
 
+// newAllFactories returns factories of all builtin collectors.
+func newAllFactories() Factories {
+	f := Factories{}
+	f.RegisterSystemCollectors(nil)
+	f.RegisterPostgresCollectors(nil)
+	f.RegisterPgbouncerCollectors(nil)
+	return f
+}
+
+// Names returns sorted names of all builtin collectors.
+func Names() []string {
+	f := newAllFactories()
+
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// requirement describes conditions necessary for collector to produce metrics.
+type requirement struct {
+	minVersion       int  // minimal required Postgres version, zero if any version is supported
+	localService     bool // service should be running on the same host
+	loggingCollector bool // 'logging_collector' should be enabled
+	pgStatStatements bool // pg_stat_statements or pg_stat_monitor should be available
+	pgStorePlans     bool // pg_store_plans should be available
+}
+
+// requirements defines collectors which produce metrics only when specific conditions are met.
+var requirements = map[string]requirement{
+	"postgres/archiver":      {minVersion: PostgresV12},
+	"postgres/logical":       {minVersion: PostgresV10},
+	"postgres/logs":          {minVersion: PostgresV10, localService: true, loggingCollector: true},
+	"postgres/memory":        {minVersion: PostgresV14, localService: true, loggingCollector: true},
+	"postgres/plans":         {pgStorePlans: true},
+	"postgres/recovery":      {minVersion: PostgresV15},
+	"postgres/shmem":         {minVersion: PostgresV13},
+	"postgres/slru":          {minVersion: PostgresV13},
+	"postgres/ssl":           {minVersion: PostgresV10},
+	"postgres/statements":    {pgStatStatements: true},
+	"postgres/storage":       {minVersion: PostgresV10},
+	"postgres/subscriptions": {minVersion: PostgresV10},
+	"postgres/walinspect":    {minVersion: PostgresV15},
+	"postgres/walreceiver":   {minVersion: PostgresV10},
+}
+
+// check returns reason why collector doesn't produce metrics, or empty string if requirements are satisfied.
+func (r requirement) check(config postgresServiceConfig) string {
+	if r.minVersion > 0 && config.serverVersionNum < r.minVersion {
+		return fmt.Sprintf("requires Postgres %d or newer, found %d", r.minVersion, config.serverVersionNum)
+	}
+	if r.localService && !config.localService {
+		return "requires service running on the local host"
+	}
+	if r.loggingCollector && !config.loggingCollector {
+		return "requires logging_collector enabled"
+	}
+	if r.pgStatStatements && !config.pgStatStatements && !config.pgStatMonitor {
+		return "requires pg_stat_statements or pg_stat_monitor extension"
+	}
+	if r.pgStorePlans && !config.pgStorePlans {
+		return "requires pg_store_plans extension"
+	}
+	return ""
+}
+
+// State describes collector's state regarding to the specific service.
+type State struct {
+	// Name defines collector's name.
+	Name string
+	// Enabled defines collector is enabled in configuration.
+	Enabled bool
+	// Reason defines why collector doesn't produce metrics, empty if requirements are satisfied.
+	Reason string
+	// Interval defines how often collector runs, empty if collector is disabled.
+	Interval string
+}
+
+// States returns states of all collectors of the specified service type. For Postgres services, collectors'
+// requirements are evaluated against the service using passed connection string.
+func States(serviceType string, connStr string, disabled []string, settings model.CollectorsSettings) ([]State, error) {
+	f := Factories{}
+	switch serviceType {
+	case model.ServiceTypeSystem:
+		f.RegisterSystemCollectors(disabled)
+	case model.ServiceTypePostgresql:
+		f.RegisterPostgresCollectors(disabled)
+	case model.ServiceTypePgbouncer:
+		f.RegisterPgbouncerCollectors(disabled)
+	default:
+		return nil, fmt.Errorf("unknown service type '%s'", serviceType)
+	}
+
+	var config postgresServiceConfig
+	if serviceType == model.ServiceTypePostgresql {
+		cfg, err := newPostgresServiceConfig(connStr)
+		if err != nil {
+			return nil, err
+		}
+		config = cfg
+	}
+
+	var states []State
+	for _, name := range Names() {
+		if !strings.HasPrefix(name, serviceType+"/") {
+			continue
+		}
+
+		factory, enabled := f[name]
+		state := State{Name: name, Enabled: enabled}
+		if r, ok := requirements[name]; ok && serviceType == model.ServiceTypePostgresql {
+			state.Reason = r.check(config)
+		}
+
+		if enabled {
+			c, err := factory(labels{}, settings[name])
+			if err != nil {
+				return nil, fmt.Errorf("create %s collector failed: %s", name, err)
+			}
+			state.Interval = effectiveInterval(name, settings[name], c)
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
 // register is the generic routine which register any kind of collectors.
 func (f Factories) register(collector string, factory func(labels, model.CollectorSettings) (Collector, error)) {
 	f[collector] = factory
@@ -115,6 +258,8 @@ func (f Factories) register(collector string, factory func(labels, model.Collect
 type Collector interface {
 	// Update does collecting new metrics and expose them via prometheus registry.
 	Update(config Config, ch chan<- prometheus.Metric) error
+	// descriptors returns descriptors of metrics which could be produced by collector.
+	descriptors() []typedDesc
 }
 
 // PgscvCollector implements the prometheus.Collector interface.
@@ -123,12 +268,57 @@ type PgscvCollector struct {
 	Collectors map[string]Collector
 	// anchorDesc is a metric descriptor used for distinguishing collectors when unregister is required.
 	anchorDesc typedDesc
+	// droppedDesc is a metric descriptor used for exposing number of series dropped due to exceeded series limits.
+	droppedDesc typedDesc
+	// dropped accounts number of series dropped due to exceeded series limits.
+	dropped *seriesCounter
+	// durationDesc is a metric descriptor used for exposing collectors' duration.
+	durationDesc typedDesc
+	// errorsDesc is a metric descriptor used for exposing number of collectors' failures.
+	errorsDesc typedDesc
+	// errors accounts number of collectors' failures.
+	errors *seriesCounter
+	// cache keeps metrics collected during the last collection.
+	cache *metricsCache
+	// status keeps state of the last collection.
+	status *collectStatus
+	// trippedDesc is a metric descriptor used for exposing collectors disabled due to consecutive failures.
+	trippedDesc typedDesc
+	// breaker disables collectors which failed too many times in a row.
+	breaker *breaker
+	// intervalDesc is a metric descriptor used for exposing effective intervals of scheduled collectors.
+	intervalDesc typedDesc
+	// lastRunDesc is a metric descriptor used for exposing time of the last run of scheduled collectors.
+	lastRunDesc typedDesc
+	// schedules keeps state of collectors which run less often than metrics are scraped.
+	schedules map[string]*schedule
+	// upDesc is a metric descriptor used for exposing whether the service has been reached during the last collection.
+	upDesc typedDesc
+	// lastSuccessDesc is a metric descriptor used for exposing time of the last collection when the service has been reached.
+	lastSuccessDesc typedDesc
+}
+
+// Status describes the state of the last metrics collection.
+type Status struct {
+	// Version defines detected version of the service, zero if unknown.
+	Version int
+	// LastCollect defines time when the last collection has been started.
+	LastCollect time.Time
+	// LastError defines the last error occurred during the last collection.
+	LastError string
+	// LastSuccess defines time when the last collection which reached the service has been started.
+	LastSuccess time.Time
+	// Collectors defines names of enabled collectors.
+	Collectors []string
 }
 
 // NewPgscvCollector accepts Factories and creates per-service instance of Collector.
 func NewPgscvCollector(serviceID string, factories Factories, config Config) (*PgscvCollector, error) {
 	collectors := make(map[string]Collector)
 	constLabels := labels{"service_id": serviceID}
+	for k, v := range config.Labels {
+		constLabels[k] = v
+	}
 
 	for key := range factories {
 		settings := config.Settings[key]
@@ -149,7 +339,115 @@ func NewPgscvCollector(serviceID string, factories Factories, config Config) (*P
 		filter.New(),
 	)
 
-	return &PgscvCollector{Config: config, Collectors: collectors, anchorDesc: desc}, nil
+	droppedDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "series", "dropped_total", "Total number of series dropped due to exceeded series limit.", 0},
+		prometheus.CounterValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	durationDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "duration_seconds", "Duration of the last collection by collector, in seconds.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	trippedDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "tripped", "Collector is temporarily disabled due to consecutive failures, 1 if disabled.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	errorsDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "errors_total", "Total number of failed collections by collector.", 0},
+		prometheus.CounterValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	// Initialize errors counters for all collectors, hence errors metrics are exposed even nothing failed.
+	errs := newSeriesCounter()
+	for key := range collectors {
+		errs.add(key, 0)
+	}
+
+	// Initialize counters for all configured limits, hence dropped series metrics are exposed even nothing dropped.
+	dropped := newSeriesCounter()
+	if config.SeriesLimit > 0 {
+		dropped.add("all", 0)
+	}
+	for key := range collectors {
+		if config.Settings[key].SeriesLimit > 0 {
+			dropped.add(key, 0)
+		}
+	}
+
+	intervalDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "interval_seconds", "Effective interval of the collector runs, in seconds. Zero means every scrape.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	lastRunDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "last_run_timestamp_seconds", "Time of the last successful run of the scheduled collector, in unixtime. Metrics of the run are sent until the next run.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	// Create schedules for collectors which have configured interval or which interval depends on number of relations.
+	schedules := make(map[string]*schedule)
+	for key, c := range collectors {
+		interval := config.Settings[key].Interval
+		if interval == 0 {
+			interval = defaultIntervals[key]
+		}
+
+		_, adaptive := c.(relationsCounter)
+		if interval > 0 || adaptive {
+			schedules[key] = newSchedule(interval)
+		}
+	}
+
+	upDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "service", "up", "State of the service during the last collection: 0 is unreachable, 1 is reachable.", 0},
+		prometheus.GaugeValue,
+		nil, constLabels,
+		filter.New(),
+	)
+
+	lastSuccessDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "service", "last_collect_success_timestamp", "Time of the last collection when the service has been reached, in unixtime.", 0},
+		prometheus.GaugeValue,
+		nil, constLabels,
+		filter.New(),
+	)
+
+	config.ServiceID = serviceID
+
+	return &PgscvCollector{
+		Config:       config,
+		Collectors:   collectors,
+		anchorDesc:   desc,
+		droppedDesc:  droppedDesc,
+		dropped:      dropped,
+		durationDesc: durationDesc,
+		errorsDesc:   errorsDesc,
+		errors:       errs,
+		cache:        &metricsCache{},
+		status:       &collectStatus{},
+		trippedDesc:  trippedDesc,
+		breaker:      newBreaker(config.BreakerThreshold, config.BreakerBackoff),
+		intervalDesc: intervalDesc,
+		lastRunDesc:  lastRunDesc,
+		schedules:    schedules,
+
+		upDesc:          upDesc,
+		lastSuccessDesc: lastSuccessDesc,
+	}, nil
 }
 
 // Describe implements the prometheus.Collector interface.
@@ -159,15 +457,71 @@ func (n PgscvCollector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect implements the prometheus.Collector interface.
 func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
+	// Hold the lock during collecting, concurrent scrapes of the service are serialized and wait for the result
+	// instead of querying service simultaneously.
+	n.cache.mu.Lock()
+	defer n.cache.mu.Unlock()
+
+	// Caching is disabled, collect metrics directly.
+	if n.Config.CacheTTL <= 0 {
+		n.collectMetrics(out)
+		return
+	}
+
+	if time.Since(n.cache.updated) >= n.Config.CacheTTL {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			n.collectMetrics(ch)
+			close(ch)
+		}()
+
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+
+		n.cache.metrics = metrics
+		n.cache.updated = time.Now()
+	} else {
+		log.Debugf("use cached metrics collected at %s", n.cache.updated.Format(time.RFC3339))
+	}
+
+	for _, m := range n.cache.metrics {
+		out <- m
+	}
+}
+
+// Status returns state of the last metrics collection.
+func (n PgscvCollector) Status() Status {
+	n.status.mu.Lock()
+	status := n.status.Status
+	n.status.mu.Unlock()
+
+	status.Collectors = make([]string, 0, len(n.Collectors))
+	for name := range n.Collectors {
+		status.Collectors = append(status.Collectors, name)
+	}
+	sort.Strings(status.Collectors)
+
+	return status
+}
+
+// collectMetrics runs all collectors and sends collected metrics to the channel.
+func (n PgscvCollector) collectMetrics(out chan<- prometheus.Metric) {
+	n.status.start()
+
 	// Update settings of Postgres collectors
 	if n.Config.ServiceType == "postgres" {
 		cfg, err := newPostgresServiceConfig(n.Config.ConnString)
 		if err != nil {
-			log.Errorf("update service config failed: %s, skip collect", err.Error())
+			log.KVErrorf(log.KV{"service_id": n.Config.ServiceID, "error_class": errorClass(err)}, "update service config failed: %s, skip collect", err.Error())
+			n.status.setError(fmt.Errorf("update service config failed: %s", err))
+			n.sendServiceState(out, false)
 			return
 		}
 
 		n.Config.postgresServiceConfig = cfg
+		n.status.setVersion(cfg.serverVersionNum)
 	}
 
 	wgCollector := sync.WaitGroup{}
@@ -176,11 +530,62 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 	// Create pipe channel used transmitting metrics from collectors to sender.
 	pipelineIn := make(chan prometheus.Metric)
 
+	// Duration of collectors.
+	durations := make(map[string]float64, len(n.Collectors))
+	durationsMu := sync.Mutex{}
+
+	// Number of collectors which have been run and number of failed ones.
+	var ran, failed int32
+
 	// Run collectors.
 	wgCollector.Add(len(n.Collectors))
 	for name, c := range n.Collectors {
 		go func(name string, c Collector) {
-			collect(name, n.Config, c, pipelineIn)
+			// Skip collectors disabled due to consecutive failures.
+			if !n.breaker.allow(name) {
+				log.KVDebugf(log.KV{"service_id": n.Config.ServiceID, "collector": name}, "%s collector is tripped, skip", name)
+				wgCollector.Done()
+				return
+			}
+
+			// Scheduled collectors run only when their interval is elapsed, metrics collected during the previous
+			// run are sent in between.
+			s := n.schedules[name]
+			if s != nil && !s.due() {
+				s.replay(pipelineIn)
+				wgCollector.Done()
+				return
+			}
+
+			var ch chan<- prometheus.Metric = pipelineIn
+			var stop func() []prometheus.Metric
+			if s != nil {
+				ch, stop = s.record(pipelineIn)
+			}
+
+			start := time.Now()
+			err := collect(name, n.Config, c, ch, n.dropped)
+			if s != nil {
+				s.finish(name, c, stop(), err)
+			}
+			atomic.AddInt32(&ran, 1)
+			if err != nil {
+				n.status.setError(fmt.Errorf("%s collector failed: %s", name, err))
+				n.errors.add(name, 1)
+				atomic.AddInt32(&failed, 1)
+			}
+
+			if n.breaker.report(name, err) {
+				log.KVWarnf(
+					log.KV{"service_id": n.Config.ServiceID, "collector": name},
+					"%s collector failed %d times in a row, disable it for %s", name, n.breaker.threshold, n.breaker.backoff,
+				)
+			}
+
+			durationsMu.Lock()
+			durations[name] = time.Since(start).Seconds()
+			durationsMu.Unlock()
+
 			wgCollector.Done()
 		}(name, c)
 	}
@@ -188,7 +593,11 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 	// Run sender.
 	wgSender.Add(1)
 	go func() {
-		send(pipelineIn, out)
+		dropped := send(pipelineIn, out, n.Config.Relabel, n.Config.SeriesLimit)
+		if dropped > 0 {
+			log.Warnf("series limit %d exceeded, %d series dropped", n.Config.SeriesLimit, dropped)
+			n.dropped.add("all", float64(dropped))
+		}
 		wgSender.Done()
 	}()
 
@@ -198,26 +607,459 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 
 	// Wait until metrics have been sent.
 	wgSender.Wait()
+
+	// Send number of series dropped due to exceeded limits.
+	for name, value := range n.dropped.snapshot() {
+		out <- n.droppedDesc.newConstMetric(value, name)
+	}
+
+	// Send state of collectors' circuit breakers.
+	if n.breaker.enabled() {
+		for name := range n.Collectors {
+			var value float64
+			if n.breaker.tripped(name) {
+				value = 1
+			}
+			out <- n.trippedDesc.newConstMetric(value, name)
+		}
+	}
+
+	// Send effective intervals and time of the last runs of scheduled collectors.
+	for name, s := range n.schedules {
+		out <- n.intervalDesc.newConstMetric(s.effective().Seconds(), name)
+
+		if last := s.last(); !last.IsZero() {
+			out <- n.lastRunDesc.newConstMetric(float64(last.Unix()), name)
+		}
+	}
+
+	// Send collectors' duration and number of failures.
+	for name, value := range durations {
+		out <- n.durationDesc.newConstMetric(value, name)
+	}
+
+	for name, value := range n.errors.snapshot() {
+		out <- n.errorsDesc.newConstMetric(value, name)
+	}
+
+	// Service is considered unreachable when all run collectors have failed.
+	n.sendServiceState(out, ran == 0 || failed < ran)
+}
+
+// sendServiceState sends state of the service and time of the last successful collection.
+func (n PgscvCollector) sendServiceState(out chan<- prometheus.Metric, up bool) {
+	var value float64
+	if up {
+		value = 1
+	}
+	out <- n.upDesc.newConstMetric(value)
+
+	// Time of the last successful collection is not sent until the first successful collection.
+	if last := n.status.finish(up); !last.IsZero() {
+		out <- n.lastSuccessDesc.newConstMetric(float64(last.Unix()))
+	}
 }
 
 // send acts like a middleware between metric collector functions which produces metrics and Prometheus who accepts metrics.
-func send(in <-chan prometheus.Metric, out chan<- prometheus.Metric) {
+// Returns number of metrics dropped due to exceeded series limit.
+func send(in <-chan prometheus.Metric, out chan<- prometheus.Metric, rules relabel.Rules, limit int) int {
+	var sent, dropped int
 	for m := range in {
 		// Skip received nil values
 		if m == nil {
 			continue
 		}
 
+		// Apply relabeling rules, skip metrics which have been dropped.
+		m = rules.Apply(m)
+		if m == nil {
+			continue
+		}
+
+		// Drop surplus metrics if series limit is exceeded.
+		if limit > 0 && sent >= limit {
+			dropped++
+			continue
+		}
+
 		// implement other middlewares here.
 
 		out <- m
+		sent++
 	}
+
+	return dropped
 }
 
 // collect runs metric collection function and wraps it into instrumenting logic.
-func collect(name string, config Config, c Collector, ch chan<- prometheus.Metric) {
-	err := c.Update(config, ch)
+func collect(name string, config Config, c Collector, ch chan<- prometheus.Metric, dropped *seriesCounter) error {
+	start := time.Now()
+	kv := log.KV{"service_id": config.ServiceID, "collector": name}
+
+	var err error
+
+	limit := config.Settings[name].SeriesLimit
+	if limit <= 0 {
+		// Collector has no series limit, send metrics directly.
+		err = c.Update(config, ch)
+	} else {
+		// Collector has series limit, pass metrics through the limiter.
+		in := make(chan prometheus.Metric)
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			if n := limitSeries(in, ch, limit); n > 0 {
+				log.KVWarnf(kv, "%s collector exceeded series limit %d, %d series dropped", name, limit, n)
+				dropped.add(name, float64(n))
+			}
+			wg.Done()
+		}()
+
+		err = c.Update(config, in)
+		close(in)
+		wg.Wait()
+	}
+
+	kv["duration"] = time.Since(start).String()
+
 	if err != nil {
-		log.Errorf("%s collector failed; %s", name, err)
+		kv["error_class"] = errorClass(err)
+		log.KVErrorf(kv, "%s collector failed; %s", name, err)
+		return err
+	}
+
+	log.KVDebugf(kv, "%s collector finished", name)
+	return nil
+}
+
+// errorClass returns class of the error used for aggregating errors in logs. For Postgres errors it is the class of
+// SQLSTATE code, e.g. '42' for syntax errors or access rule violations.
+func errorClass(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && len(pgErr.Code) >= 2 {
+		return pgErr.Code[:2]
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return "network"
+	}
+
+	return "other"
+}
+
+// limitSeries forwards metrics until limit is reached, surplus metrics are dropped. Returns number of dropped metrics.
+func limitSeries(in <-chan prometheus.Metric, out chan<- prometheus.Metric, limit int) int {
+	var sent, dropped int
+	for m := range in {
+		// Skip received nil values
+		if m == nil {
+			continue
+		}
+
+		if sent >= limit {
+			dropped++
+			continue
+		}
+
+		out <- m
+		sent++
+	}
+
+	return dropped
+}
+
+// metricsCache keeps metrics collected during the last collection.
+type metricsCache struct {
+	mu      sync.Mutex
+	updated time.Time
+	// metrics are sent without explicit timestamps, time of the collection is exposed by service state metrics.
+	metrics []prometheus.Metric
+}
+
+// collectStatus keeps state of the last collection.
+type collectStatus struct {
+	mu sync.Mutex
+	Status
+}
+
+// start resets state when new collection is started.
+func (s *collectStatus) start() {
+	s.mu.Lock()
+	s.LastCollect = time.Now()
+	s.LastError = ""
+	s.mu.Unlock()
+}
+
+// setVersion saves detected version of the service.
+func (s *collectStatus) setVersion(version int) {
+	s.mu.Lock()
+	s.Version = version
+	s.mu.Unlock()
+}
+
+// finish saves start time of the collection as the time of the last successful collection if the service has been
+// reached. Returns time of the last successful collection.
+func (s *collectStatus) finish(up bool) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if up {
+		s.LastSuccess = s.LastCollect
+	}
+	return s.LastSuccess
+}
+
+// setError saves error occurred during collection.
+func (s *collectStatus) setError(err error) {
+	s.mu.Lock()
+	s.LastError = err.Error()
+	s.mu.Unlock()
+}
+
+// seriesCounter accounts number of series dropped by collectors.
+type seriesCounter struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// newSeriesCounter creates new empty seriesCounter.
+func newSeriesCounter() *seriesCounter {
+	return &seriesCounter{values: map[string]float64{}}
+}
+
+// add increments counter of specified collector.
+func (c *seriesCounter) add(name string, value float64) {
+	c.mu.Lock()
+	c.values[name] += value
+	c.mu.Unlock()
+}
+
+// snapshot returns copy of counters values.
+func (c *seriesCounter) snapshot() map[string]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make(map[string]float64, len(c.values))
+	for k, v := range c.values {
+		values[k] = v
+	}
+
+	return values
+}
+
+// breaker counts consecutive failures of collectors and disables collectors which failed too many times in a row.
+// After backoff period collector is allowed to run again, it is disabled again on the first failure.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	backoff   time.Duration
+	failures  map[string]int
+	until     map[string]time.Time
+}
+
+// newBreaker creates new breaker. Zero threshold means collectors are never disabled.
+func newBreaker(threshold int, backoff time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		backoff:   backoff,
+		failures:  map[string]int{},
+		until:     map[string]time.Time{},
+	}
+}
+
+// enabled returns true if breaker is configured to disable failed collectors.
+func (b *breaker) enabled() bool {
+	return b.threshold > 0
+}
+
+// allow returns true if collector is allowed to run.
+func (b *breaker) allow(name string) bool {
+	return !b.tripped(name)
+}
+
+// tripped returns true if collector is disabled at the moment.
+func (b *breaker) tripped(name string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return time.Now().Before(b.until[name])
+}
+
+// report accounts result of the collector's run. Returns true if collector has been disabled due to the failure.
+func (b *breaker) report(name string, err error) bool {
+	if !b.enabled() {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		delete(b.failures, name)
+		delete(b.until, name)
+		return false
+	}
+
+	b.failures[name]++
+	if b.failures[name] < b.threshold {
+		return false
+	}
+
+	b.until[name] = time.Now().Add(b.backoff)
+	return true
+}
+
+// relationsCounter is implemented by collectors which produce per-relation metrics. Interval of these collectors
+// depends on number of relations, unless interval is configured explicitly.
+type relationsCounter interface {
+	// relationsCount returns number of relations processed during the last update.
+	relationsCount() int
+}
+
+// defaultIntervals defines intervals of collectors which run less often than metrics are scraped, unless interval
+// is configured explicitly.
+var defaultIntervals = map[string]time.Duration{
+	"postgres/bloat":          time.Hour,
+	"postgres/fdw":            5 * time.Minute,
+	"postgres/memory":         5 * time.Minute,
+	"postgres/plans":          5 * time.Minute,
+	"postgres/relations":      15 * time.Minute,
+	"postgres/unused_indexes": time.Hour,
+}
+
+// adaptiveIntervals defines intervals of per-relation collectors depending on number of relations, in descending order.
+var adaptiveIntervals = []struct {
+	relations int
+	interval  time.Duration
+}{
+	{relations: 50000, interval: 15 * time.Minute},
+	{relations: 10000, interval: 5 * time.Minute},
+}
+
+// adaptiveInterval returns interval of per-relation collector depending on number of relations.
+func adaptiveInterval(relations int) time.Duration {
+	for _, v := range adaptiveIntervals {
+		if relations > v.relations {
+			return v.interval
+		}
+	}
+	return 0
+}
+
+// effectiveInterval returns description of how often collector runs: configured interval, adaptive interval which
+// depends on number of relations, default interval of the collector or on every scrape.
+func effectiveInterval(name string, settings model.CollectorSettings, c Collector) string {
+	if settings.Interval > 0 {
+		return settings.Interval.String()
+	}
+
+	if _, ok := c.(relationsCounter); ok {
+		return "adaptive"
+	}
+
+	if interval, ok := defaultIntervals[name]; ok {
+		return interval.String()
+	}
+
+	return "every scrape"
+}
+
+// schedule keeps state of collector which runs less often than metrics are scraped.
+type schedule struct {
+	mu sync.Mutex
+	// configured defines interval specified in configuration, zero means interval is adaptive.
+	configured time.Duration
+	// interval defines effective interval.
+	interval time.Duration
+	lastRun  time.Time
+	metrics  []prometheus.Metric
+}
+
+// newSchedule creates new schedule with configured interval.
+func newSchedule(interval time.Duration) *schedule {
+	return &schedule{configured: interval, interval: interval}
+}
+
+// due returns true if collector has to be run.
+func (s *schedule) due() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.interval <= 0 || time.Since(s.lastRun) >= s.interval
+}
+
+// effective returns effective interval of the collector.
+func (s *schedule) effective() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.interval
+}
+
+// replay sends metrics collected during the previous run. Metrics are sent without explicit timestamps, otherwise
+// Prometheus considers them stale when interval is longer than lookback period. Time of the run is exposed separately.
+func (s *schedule) replay(out chan<- prometheus.Metric) {
+	s.mu.Lock()
+	metrics := s.metrics
+	s.mu.Unlock()
+
+	for _, m := range metrics {
+		out <- m
+	}
+}
+
+// last returns time of the last successful run, zero if collector has not been run yet.
+func (s *schedule) last() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastRun
+}
+
+// record returns channel which passes metrics to output channel and remembers them. Returned function must be called
+// when collector is finished, it closes the channel and returns passed metrics.
+func (s *schedule) record(out chan<- prometheus.Metric) (chan<- prometheus.Metric, func() []prometheus.Metric) {
+	in := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	var metrics []prometheus.Metric
+	go func() {
+		for m := range in {
+			if m == nil {
+				continue
+			}
+			metrics = append(metrics, m)
+			out <- m
+		}
+		close(done)
+	}()
+
+	return in, func() []prometheus.Metric {
+		close(in)
+		<-done
+		return metrics
+	}
+}
+
+// finish saves metrics of the successful run and updates adaptive interval. Failed collectors are run again on the
+// next scrape.
+func (s *schedule) finish(name string, c Collector, metrics []prometheus.Metric, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.metrics = nil
+		return
+	}
+
+	s.lastRun = time.Now()
+	s.metrics = metrics
+
+	if rc, ok := c.(relationsCounter); ok && s.configured == 0 {
+		interval := adaptiveInterval(rc.relationsCount())
+		if interval != s.interval {
+			log.Infof("%s collector processed %d relations, interval changed to %s", name, rc.relationsCount(), interval)
+		}
+		s.interval = interval
 	}
 }
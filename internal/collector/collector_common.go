@@ -2,6 +2,7 @@ package collector
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/lesovsky/pgscv/internal/filter"
 	"github.com/lesovsky/pgscv/internal/log"
@@ -11,6 +12,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // labels is a local wrapper over prometheus.Labels which is a simple map[string]string.
@@ -20,6 +22,10 @@ type labels prometheus.Labels
 type typedDesc struct {
 	// desc is the descriptor used by every Prometheus Metric.
 	desc *prometheus.Desc
+	// fqName defines fully-qualified metric name.
+	fqName string
+	// help defines metric description.
+	help string
 	// valueType is an enumeration of metric types that represent a simple value.
 	valueType prometheus.ValueType
 	// multiplier used to cast value to necessary units.
@@ -35,6 +41,10 @@ type typedDesc struct {
 	labels labels
 	// filters defines settings for label-based metrics filtering
 	filters filter.Filters
+	// nullValues defines how NULL values are handled, see model.NullValues* constants.
+	nullValues string
+	// isnull defines descriptor of the metric which flags NULL values, used when nullValues is 'flag'.
+	isnull *typedDesc
 }
 
 // descOpts defines metric descriptor options.
@@ -48,13 +58,12 @@ type descOpts struct {
 
 // newBuiltinTypedDesc is a constructor for builtin metric descriptor.
 func newBuiltinTypedDesc(opts descOpts, dtype prometheus.ValueType, varLabelNames []string, constLabels labels, filters filter.Filters) typedDesc {
+	fqName := prometheus.BuildFQName(opts.namespace, opts.subsystem, opts.name)
+
 	return typedDesc{
-		desc: prometheus.NewDesc(
-			prometheus.BuildFQName(opts.namespace, opts.subsystem, opts.name),
-			opts.help,
-			varLabelNames,
-			prometheus.Labels(constLabels),
-		),
+		desc:       prometheus.NewDesc(fqName, opts.help, varLabelNames, prometheus.Labels(constLabels)),
+		fqName:     fqName,
+		help:       opts.help,
 		factor:     opts.factor,
 		valueType:  dtype,
 		labelNames: varLabelNames,
@@ -65,13 +74,12 @@ func newBuiltinTypedDesc(opts descOpts, dtype prometheus.ValueType, varLabelName
 
 // newCustomTypedDesc is a constructor for user-defined metric descriptor.
 func newCustomTypedDesc(opts descOpts, dtype prometheus.ValueType, valueSource string, labeledValues map[string][]string, varLabelNames []string, constLabels labels, filters filter.Filters) typedDesc {
+	fqName := prometheus.BuildFQName(opts.namespace, opts.subsystem, opts.name)
+
 	return typedDesc{
-		desc: prometheus.NewDesc(
-			prometheus.BuildFQName(opts.namespace, opts.subsystem, opts.name),
-			opts.help,
-			varLabelNames,
-			prometheus.Labels(constLabels),
-		),
+		desc:          prometheus.NewDesc(fqName, opts.help, varLabelNames, prometheus.Labels(constLabels)),
+		fqName:        fqName,
+		help:          opts.help,
 		valueType:     dtype,
 		labelNames:    varLabelNames,
 		labels:        map[string]string{},
@@ -105,6 +113,70 @@ func (d *typedDesc) newConstMetric(value float64, labelValues ...string) prometh
 	return m
 }
 
+// newConstHistogram creates new histogram metric using descriptor. Descriptor's factor is applied to the sum of
+// observations, buckets' upper bounds should be in final units.
+func (d *typedDesc) newConstHistogram(count uint64, sum float64, buckets map[float64]uint64, labelValues ...string) prometheus.Metric {
+	if d.factor != 0 {
+		sum *= d.factor
+	}
+
+	if len(d.labelNames) != len(labelValues) {
+		log.Errorf("number of labels and collected label values does not match, want: %v; got %v; metric description: %s; skip metric", d.labelNames, labelValues, d.desc.String())
+		return nil
+	}
+
+	// Check passed label values against configured filters.
+	if d.hasFilter(labelValues) {
+		return nil
+	}
+
+	m, err := prometheus.NewConstHistogram(d.desc, count, sum, buckets, labelValues...)
+	if err != nil {
+		log.Errorf("create const histogram failed: %s; skip. Failed metric descriptor: '%s'", err, d.desc.String())
+	}
+
+	return m
+}
+
+// withNullValues returns copy of descriptor which handles NULL values according to passed policy.
+func (d typedDesc) withNullValues(policy string, constLabels labels) typedDesc {
+	d.nullValues = policy
+
+	if policy == model.NullValuesFlag {
+		isnull := typedDesc{
+			desc:       prometheus.NewDesc(d.fqName+"_isnull", fmt.Sprintf("Value of %s is NULL, 1 if NULL.", d.fqName), d.labelNames, prometheus.Labels(constLabels)),
+			fqName:     d.fqName + "_isnull",
+			help:       fmt.Sprintf("Value of %s is NULL, 1 if NULL.", d.fqName),
+			valueType:  prometheus.GaugeValue,
+			labelNames: d.labelNames,
+			labels:     map[string]string{},
+			filters:    d.filters,
+		}
+		d.isnull = &isnull
+	}
+
+	return d
+}
+
+// sendNullable sends metric which value could be NULL (not valid). NULL values are handled according to descriptor's
+// policy, by default they are skipped.
+func (d *typedDesc) sendNullable(ch chan<- prometheus.Metric, value float64, valid bool, labelValues ...string) {
+	switch {
+	case valid:
+		ch <- d.newConstMetric(value, labelValues...)
+	case d.nullValues == model.NullValuesZero:
+		ch <- d.newConstMetric(0, labelValues...)
+	}
+
+	if d.isnull != nil {
+		var flag float64
+		if !valid {
+			flag = 1
+		}
+		ch <- d.isnull.newConstMetric(flag, labelValues...)
+	}
+}
+
 // hasFilter checks label values against configured filters. Returns true if metric has to be filtered and false otherwise.
 func (d *typedDesc) hasFilter(labelValues []string) bool {
 	for i, key := range d.labelNames {
@@ -119,6 +191,31 @@ func (d *typedDesc) hasFilter(labelValues []string) bool {
 	return false
 }
 
+// typedDescCache keeps descriptors of metrics whose names become known only at collection time. Reusing descriptors
+// avoids building them on every scrape.
+type typedDescCache struct {
+	mu    sync.Mutex
+	descs map[string]*typedDesc
+}
+
+// get returns descriptor cached under the key. If there is no such descriptor, it is created using newDesc and cached.
+func (c *typedDescCache) get(key string, newDesc func() typedDesc) *typedDesc {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d, ok := c.descs[key]; ok {
+		return d
+	}
+
+	if c.descs == nil {
+		c.descs = map[string]*typedDesc{}
+	}
+
+	d := newDesc()
+	c.descs[key] = &d
+	return &d
+}
+
 // typedDescSet unions metrics in a set, which could be collected using query.
 type typedDescSet struct {
 	namespace   string         // namespace to which all nested metrics are belong
@@ -129,13 +226,13 @@ type typedDescSet struct {
 }
 
 // newDeskSetsFromSubsystems parses subsystem object and produces []typedDescSet object.
-func newDeskSetsFromSubsystems(namespace string, subsystems model.Subsystems, constLabels labels) []typedDescSet {
+func newDeskSetsFromSubsystems(namespace string, subsystems model.Subsystems, constLabels labels, nullValues string) []typedDescSet {
 	var sets []typedDescSet
 
 	// Iterate over all passed subsystems and create dedicated descs set per each subsystem.
 	// Consider all metrics are in the 'postgres' namespace.
 	for subsystemName, subsystem := range subsystems {
-		descs, err := newDescSet(namespace, subsystemName, subsystem, constLabels)
+		descs, err := newDescSet(namespace, subsystemName, subsystem, constLabels, nullValues)
 		if err != nil {
 			log.Warnf("create metrics descriptors set failed: %s; skip", err)
 		}
@@ -146,7 +243,7 @@ func newDeskSetsFromSubsystems(namespace string, subsystems model.Subsystems, co
 }
 
 // newDescSet creates new typedDescSet based on passed metrics attributes.
-func newDescSet(namespace string, subsystemName string, subsystem model.MetricsSubsystem, constLabels labels) (typedDescSet, error) {
+func newDescSet(namespace string, subsystemName string, subsystem model.MetricsSubsystem, constLabels labels, nullValues string) (typedDescSet, error) {
 
 	// Compile regexp object if databases are specified
 	var databasesRE *regexp.Regexp
@@ -199,7 +296,7 @@ func newDescSet(namespace string, subsystemName string, subsystem model.MetricsS
 			filter.New(),
 		)
 
-		descs = append(descs, d)
+		descs = append(descs, d.withNullValues(nullValues, constLabels))
 	}
 
 	return typedDescSet{
@@ -232,18 +329,11 @@ func updateAllDescSets(config Config, descSets []typedDescSet, ch chan<- prometh
 
 // updateFromMultipleDatabases method visits all requested databases and collects necessary metrics.
 func updateFromMultipleDatabases(config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+	realDatabases, err := queryServiceDatabases(config.ConnString, listDatabases)
 	if err != nil {
 		return err
 	}
 
-	realDatabases, err := listDatabases(conn)
-	if err != nil {
-		return err
-	}
-
-	conn.Close()
-
 	pgconfig, err := pgx.ParseConfig(config.ConnString)
 	if err != nil {
 		return err
@@ -357,7 +447,7 @@ func updateMultipleMetrics(row []sql.NullString, desc typedDesc, colnames []stri
 		for _, descColname := range valueCols { // walk through column names from labeledValues of metric descriptor
 
 			labelValues, labelValuesOK := append([]string{}, initialLabelValues...), false
-			value, valueOK := float64(0), false
+			value, valueOK, valueNull := float64(0), false, false
 
 			// Sanity check. Can't imaging such case when this condition is satisfied, but who knows...
 			if len(labelValues) == len(desc.labelNames) {
@@ -368,17 +458,18 @@ func updateMultipleMetrics(row []sql.NullString, desc typedDesc, colnames []stri
 				// Check for value.
 				sourceName, destName := parseLabeledValue(descColname)
 
-				if sourceName == resColname && !valueOK {
-					// Skip NULL values, metric must not be unknown (NULL).
+				if sourceName == resColname && !valueOK && !valueNull {
+					// NULL values are handled according to descriptor's policy, metric must not be unknown (NULL).
 					if !row[i].Valid {
-						continue
-					}
-
-					var err error
-					value, err = strconv.ParseFloat(row[i].String, 64)
-					if err != nil {
-						log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
-						continue
+						valueNull = true
+					} else {
+						var err error
+						value, err = strconv.ParseFloat(row[i].String, 64)
+						if err != nil {
+							log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+							continue
+						}
+						valueOK = true
 					}
 
 					// When value found also update associated label.
@@ -386,7 +477,6 @@ func updateMultipleMetrics(row []sql.NullString, desc typedDesc, colnames []stri
 					if len(labelValues) == len(desc.labelNames) {
 						labelValuesOK = true
 					}
-					valueOK = true
 
 					continue
 				}
@@ -403,12 +493,12 @@ func updateMultipleMetrics(row []sql.NullString, desc typedDesc, colnames []stri
 
 			// Update metric only when value and all necessary labels are collected.
 
-			if !valueOK || !labelValuesOK {
+			if (!valueOK && !valueNull) || !labelValuesOK {
 				log.Warnln("metric value or labels are not collected, skip")
 				continue
 			}
 
-			ch <- desc.newConstMetric(value, labelValues...)
+			desc.sendNullable(ch, value, valueOK, labelValues...)
 		}
 	}
 }
@@ -416,7 +506,7 @@ func updateMultipleMetrics(row []sql.NullString, desc typedDesc, colnames []stri
 // updateSingleMetric parses data row and update single metric using passed metric descriptor.
 func updateSingleMetric(row []sql.NullString, desc typedDesc, colnames []string, ch chan<- prometheus.Metric, databaseLabelValue string) {
 	labelValues, labelValuesOK := []string{}, false
-	value, valueOK := float64(0), false
+	value, valueOK, valueNull := float64(0), false, false
 
 	// Insert into labels passed database name in case when there is no 'database' value in data row.
 	if databaseLabelValue != "" && !stringsContains(colnames, "database") {
@@ -435,8 +525,9 @@ func updateSingleMetric(row []sql.NullString, desc typedDesc, colnames []string,
 	for i, colname := range colnames {
 		// Check for value.
 		if colname == desc.value {
-			// Skip NULL values - metric must not be unknown (NULL)
+			// NULL values are handled according to descriptor's policy, metric must not be unknown (NULL).
 			if !row[i].Valid {
+				valueNull = true
 				continue
 			}
 
@@ -464,12 +555,12 @@ func updateSingleMetric(row []sql.NullString, desc typedDesc, colnames []string,
 
 	// Update metric only when value and all necessary labels are collected.
 
-	if !valueOK || !labelValuesOK {
+	if (!valueOK && !valueNull) || !labelValuesOK {
 		log.Warnln("metric value or labels are not collected, skip")
 		return
 	}
 
-	ch <- desc.newConstMetric(value, labelValues...)
+	desc.sendNullable(ch, value, valueOK, labelValues...)
 }
 
 // needMultipleUpdate returns true if databases regexp has been found.
@@ -496,3 +587,35 @@ func parseLabeledValue(s string) (string, string) {
 
 	return ff[0], ff[1]
 }
+
+// newRowsTruncatedDesc returns descriptor of metric which shows result of collector's query has been truncated
+// due to rows limit.
+func newRowsTruncatedDesc(constLabels labels) typedDesc {
+	return newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "rows_truncated", "Result of collector's query has been truncated due to rows limit: 1 is truncated, 0 is not.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+}
+
+// limitRowsQuery wraps query for returning top rows ordered by passed column. One extra row is requested for detecting
+// truncation of the result. Query is returned as-is if no limit specified.
+func limitRowsQuery(query string, orderBy string, limit int) string {
+	if limit <= 0 {
+		return query
+	}
+
+	return fmt.Sprintf("SELECT * FROM (%s) q ORDER BY %s DESC NULLS LAST LIMIT %d", query, orderBy, limit+1)
+}
+
+// truncateResult truncates query result to the limit and returns true if some rows have been removed.
+func truncateResult(res *model.PGResult, limit int) bool {
+	if limit <= 0 || res.Nrows <= limit {
+		return false
+	}
+
+	res.Rows = res.Rows[:limit]
+	res.Nrows = limit
+	return true
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/lesovsky/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"regexp"
 	"strings"
@@ -28,6 +29,26 @@ func Test_newConstMetric(t *testing.T) {
 	assert.Nil(t, m)
 }
 
+func Test_newConstHistogram(t *testing.T) {
+	d := newBuiltinTypedDesc(
+		descOpts{"postgres", "statements", "latency_seconds", "Test description.", .001},
+		prometheus.UntypedValue,
+		[]string{"L1"}, nil,
+		filter.New(),
+	)
+	m := d.newConstHistogram(10, 2000, map[float64]uint64{0.1: 4, 1: 10}, "L1")
+	assert.NotNil(t, m)
+
+	metric := &dto.Metric{}
+	assert.NoError(t, m.Write(metric))
+	assert.Equal(t, uint64(10), metric.GetHistogram().GetSampleCount())
+	assert.Equal(t, float64(2), metric.GetHistogram().GetSampleSum())
+	assert.Len(t, metric.GetHistogram().GetBucket(), 2)
+
+	m = d.newConstHistogram(10, 2000, map[float64]uint64{0.1: 4, 1: 10}, "L1", "L2")
+	assert.Nil(t, m)
+}
+
 func Test_typedDesc_hasFilter(t *testing.T) {
 	f := filter.New()
 	f.Add("target", filter.Filter{Exclude: "unwanted"})
@@ -63,6 +84,69 @@ func Test_typedDesc_hasFilter(t *testing.T) {
 	}
 }
 
+func Test_typedDesc_sendNullable(t *testing.T) {
+	testcases := []struct {
+		policy string
+		valid  bool
+		want   []string
+	}{
+		{policy: "", valid: true, want: []string{"m_test_example"}},
+		{policy: "", valid: false, want: nil},
+		{policy: model.NullValuesSkip, valid: false, want: nil},
+		{policy: model.NullValuesZero, valid: false, want: []string{"m_test_example"}},
+		{policy: model.NullValuesFlag, valid: true, want: []string{"m_test_example", "m_test_example_isnull"}},
+		{policy: model.NullValuesFlag, valid: false, want: []string{"m_test_example_isnull"}},
+	}
+
+	for _, tc := range testcases {
+		d := newBuiltinTypedDesc(
+			descOpts{"m", "test", "example", "description", 0},
+			prometheus.GaugeValue,
+			[]string{"L1"}, nil,
+			filter.New(),
+		).withNullValues(tc.policy, nil)
+
+		ch := make(chan prometheus.Metric, 2)
+		d.sendNullable(ch, 1, tc.valid, "example")
+		close(ch)
+
+		var got []string
+		for m := range ch {
+			got = append(got, m.Desc().String())
+		}
+
+		assert.Len(t, got, len(tc.want))
+		for i := range tc.want {
+			assert.Contains(t, got[i], `fqName: "`+tc.want[i]+`"`)
+		}
+	}
+}
+
+func Test_typedDescCache(t *testing.T) {
+	var cache typedDescCache
+	var created int
+
+	newDesc := func() typedDesc {
+		created++
+		return newBuiltinTypedDesc(
+			descOpts{"m", "test", "example", "description", 0},
+			prometheus.GaugeValue,
+			nil, nil,
+			filter.New(),
+		)
+	}
+
+	d1 := cache.get("example", newDesc)
+	d2 := cache.get("example", newDesc)
+	assert.Equal(t, 1, created)
+	assert.Same(t, d1, d2)
+	assert.Same(t, d1.desc, d2.desc)
+
+	d3 := cache.get("another", newDesc)
+	assert.Equal(t, 2, created)
+	assert.NotSame(t, d1, d3)
+}
+
 func Test_newDeskSetsFromSubsystems(t *testing.T) {
 	subsystems := map[string]model.MetricsSubsystem{
 		// This should be in the output
@@ -84,7 +168,7 @@ func Test_newDeskSetsFromSubsystems(t *testing.T) {
 
 	constLabels := labels{"const": "constlabel"}
 
-	subsysDescs := newDeskSetsFromSubsystems("example", subsystems, constLabels)
+	subsysDescs := newDeskSetsFromSubsystems("example", subsystems, constLabels, "")
 	assert.Equal(t, 2, len(subsysDescs))
 
 	for _, set := range subsysDescs {
@@ -115,14 +199,14 @@ func Test_newDescSet(t *testing.T) {
 		},
 	}
 
-	desc, err := newDescSet("example", "test", subsys1, labels{"const": "constlabel"})
+	desc, err := newDescSet("example", "test", subsys1, labels{"const": "constlabel"}, "")
 	assert.NoError(t, err)
 	assert.NotNil(t, desc)
 	assert.NotNil(t, desc.databasesRE)
 	assert.Equal(t, "SELECT 'l1' as label1, 'l21' as label2_1, 'l22' as label2_2, 100 as v1, 200 as v2", desc.query)
 	assert.Equal(t, 2, len(desc.descs))
 
-	desc2, err := newDescSet("example", "test", subsys2, labels{"const": "constlabel"})
+	desc2, err := newDescSet("example", "test", subsys2, labels{"const": "constlabel"}, "")
 	assert.NoError(t, err)
 	assert.NotNil(t, desc2)
 	assert.Nil(t, desc2.databasesRE)
@@ -166,7 +250,7 @@ func Test_updateAllDescSets(t *testing.T) {
 		},
 	}
 
-	desksets := newDeskSetsFromSubsystems("postgres", subsystems, labels{"const": "example"})
+	desksets := newDeskSetsFromSubsystems("postgres", subsystems, labels{"const": "example"}, "")
 
 	ch := make(chan prometheus.Metric)
 
@@ -219,7 +303,7 @@ func Test_updateFromMultipleDatabases(t *testing.T) {
 		},
 	}
 
-	desksets := newDeskSetsFromSubsystems("postgres", subsystems, labels{"const": "example"})
+	desksets := newDeskSetsFromSubsystems("postgres", subsystems, labels{"const": "example"}, "")
 
 	ch := make(chan prometheus.Metric)
 
@@ -262,7 +346,7 @@ func Test_updateFromSingleDatabase(t *testing.T) {
 		},
 	}
 
-	desksets := newDeskSetsFromSubsystems("postgres", subsystems, labels{"const": "example"})
+	desksets := newDeskSetsFromSubsystems("postgres", subsystems, labels{"const": "example"}, "")
 
 	ch := make(chan prometheus.Metric)
 
@@ -330,7 +414,7 @@ func Test_updateSingleDescSet(t *testing.T) {
 
 	for i, tc := range testcases {
 		t.Run(fmt.Sprintf("test-%d", i), func(t *testing.T) {
-			set, err := newDescSet("postgres", tc.subsysName, tc.subsys, tc.constLabels)
+			set, err := newDescSet("postgres", tc.subsysName, tc.subsys, tc.constLabels, "")
 			assert.NoError(t, err)
 			ch := make(chan prometheus.Metric)
 
@@ -557,6 +641,54 @@ func Test_updateSingleMetric(t *testing.T) {
 			dbLabelValue: "testdb",
 			want:         0,
 		},
+		{
+			// NULL value is skipped by default.
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "nullable", "description", 0},
+				prometheus.GaugeValue,
+				"nullable", nil,
+				[]string{"database", "relname"}, labels{"const": "example"},
+				filter.New(),
+			),
+			dbLabelValue: "testdb",
+			want:         0,
+		},
+		{
+			// NULL value is sent as zero.
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "nullable", "description", 0},
+				prometheus.GaugeValue,
+				"nullable", nil,
+				[]string{"database", "relname"}, labels{"const": "example"},
+				filter.New(),
+			).withNullValues(model.NullValuesZero, labels{"const": "example"}),
+			dbLabelValue: "testdb",
+			want:         1,
+		},
+		{
+			// NULL value is flagged.
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "nullable", "description", 0},
+				prometheus.GaugeValue,
+				"nullable", nil,
+				[]string{"database", "relname"}, labels{"const": "example"},
+				filter.New(),
+			).withNullValues(model.NullValuesFlag, labels{"const": "example"}),
+			dbLabelValue: "testdb",
+			want:         1,
+		},
+		{
+			// Not NULL value is sent with the flag.
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "seq_scan_total", "description", 0},
+				prometheus.CounterValue,
+				"seq_scan", nil,
+				[]string{"database", "relname"}, labels{"const": "example"},
+				filter.New(),
+			).withNullValues(model.NullValuesFlag, labels{"const": "example"}),
+			dbLabelValue: "testdb",
+			want:         2,
+		},
 	}
 
 	for _, tc := range testcases {
@@ -627,3 +759,33 @@ func Test_parseLabeledValue(t *testing.T) {
 		assert.Equal(t, tc.s2, s2)
 	}
 }
+
+func Test_limitRowsQuery(t *testing.T) {
+	assert.Equal(t, "SELECT 1 AS size_bytes", limitRowsQuery("SELECT 1 AS size_bytes", "size_bytes", 0))
+	assert.Equal(t,
+		"SELECT * FROM (SELECT 1 AS size_bytes) q ORDER BY size_bytes DESC NULLS LAST LIMIT 11",
+		limitRowsQuery("SELECT 1 AS size_bytes", "size_bytes", 10),
+	)
+}
+
+func Test_truncateResult(t *testing.T) {
+	newResult := func() *model.PGResult {
+		return &model.PGResult{
+			Nrows: 3, Ncols: 1,
+			Rows: [][]sql.NullString{{{String: "1", Valid: true}}, {{String: "2", Valid: true}}, {{String: "3", Valid: true}}},
+		}
+	}
+
+	res := newResult()
+	assert.False(t, truncateResult(res, 0))
+	assert.Equal(t, 3, res.Nrows)
+
+	res = newResult()
+	assert.False(t, truncateResult(res, 3))
+	assert.Equal(t, 3, res.Nrows)
+
+	res = newResult()
+	assert.True(t, truncateResult(res, 2))
+	assert.Equal(t, 2, res.Nrows)
+	assert.Len(t, res.Rows, 2)
+}
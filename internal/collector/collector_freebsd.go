@@ -0,0 +1,14 @@
+package collector
+
+import "github.com/lesovsky/pgscv/internal/model"
+
+// systemCollectors returns system collectors supported on FreeBSD.
+func systemCollectors() map[string]func(labels, model.CollectorSettings) (Collector, error) {
+	return map[string]func(labels, model.CollectorSettings) (Collector, error){
+		"system/pgscv":       NewPgscvServicesCollector,
+		"system/cpu":         NewCPUCollector,
+		"system/filesystems": NewFilesystemCollector,
+		"system/netdev":      NewNetdevCollector,
+		"system/memory":      NewMeminfoCollector,
+	}
+}
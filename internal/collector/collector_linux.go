@@ -0,0 +1,19 @@
+package collector
+
+import "github.com/lesovsky/pgscv/internal/model"
+
+// systemCollectors returns system collectors supported on Linux.
+func systemCollectors() map[string]func(labels, model.CollectorSettings) (Collector, error) {
+	return map[string]func(labels, model.CollectorSettings) (Collector, error){
+		"system/pgscv":       NewPgscvServicesCollector,
+		"system/sysinfo":     NewSysInfoCollector,
+		"system/loadaverage": NewLoadAverageCollector,
+		"system/cpu":         NewCPUCollector,
+		"system/diskstats":   NewDiskstatsCollector,
+		"system/filesystems": NewFilesystemCollector,
+		"system/netdev":      NewNetdevCollector,
+		"system/network":     NewNetworkCollector,
+		"system/memory":      NewMeminfoCollector,
+		"system/sysconfig":   NewSysconfigCollector,
+	}
+}
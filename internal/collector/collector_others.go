@@ -0,0 +1,13 @@
+//go:build !linux && !freebsd
+
+package collector
+
+import "github.com/lesovsky/pgscv/internal/model"
+
+// systemCollectors returns system collectors supported on platforms other than Linux and FreeBSD. Remaining system
+// collectors rely on procfs and sysfs, hence are not available.
+func systemCollectors() map[string]func(labels, model.CollectorSettings) (Collector, error) {
+	return map[string]func(labels, model.CollectorSettings) (Collector, error){
+		"system/pgscv": NewPgscvServicesCollector,
+	}
+}
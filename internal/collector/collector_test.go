@@ -1,9 +1,18 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"net"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPgscvCollector_Collect(t *testing.T) {
@@ -35,4 +44,447 @@ func TestPgscvCollector_Collect(t *testing.T) {
 	// Check metrics slice should not be nil or empty.
 	assert.NotNil(t, metrics)
 	assert.Greater(t, len(metrics), 0)
+
+	// Check state of the last collection has been updated.
+	assert.False(t, c.Status().LastCollect.IsZero())
+}
+
+func TestPgscvCollector_Collect_SeriesLimit(t *testing.T) {
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{
+		SeriesLimit: 20,
+		Settings:    model.CollectorsSettings{"system/cpu": {SeriesLimit: 1}},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	ch := make(chan prometheus.Metric)
+
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	// Expect limited number of series plus dropped series metrics for 'all' and 'system/cpu', plus duration and
+	// errors metrics for every collector, plus service state metrics.
+	assert.Len(t, metrics, 22+2*len(c.Collectors)+2)
+
+	got := c.dropped.snapshot()
+	assert.Contains(t, got, "all")
+	assert.Greater(t, got["system/cpu"], float64(0))
+}
+
+func Test_limitSeries(t *testing.T) {
+	desc := prometheus.NewDesc("example", "example", nil, nil)
+
+	in := make(chan prometheus.Metric)
+	out := make(chan prometheus.Metric, 10)
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			in <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(i))
+		}
+		in <- nil
+		close(in)
+	}()
+
+	assert.Equal(t, 2, limitSeries(in, out, 3))
+	close(out)
+	assert.Len(t, out, 3)
+}
+
+func Test_breaker(t *testing.T) {
+	b := newBreaker(2, time.Hour)
+	assert.True(t, b.enabled())
+
+	// Success resets failures counter.
+	assert.False(t, b.report("example", fmt.Errorf("failed")))
+	assert.False(t, b.report("example", nil))
+	assert.False(t, b.report("example", fmt.Errorf("failed")))
+	assert.True(t, b.allow("example"))
+
+	// Collector is tripped after threshold is reached.
+	assert.True(t, b.report("example", fmt.Errorf("failed")))
+	assert.False(t, b.allow("example"))
+	assert.True(t, b.tripped("example"))
+	assert.True(t, b.allow("another"))
+
+	// Collector is allowed after backoff and tripped again on the first failure.
+	b.until["example"] = time.Now().Add(-time.Second)
+	assert.True(t, b.allow("example"))
+	assert.True(t, b.report("example", fmt.Errorf("failed")))
+	assert.False(t, b.allow("example"))
+
+	// Disabled breaker never trips.
+	b = newBreaker(0, time.Hour)
+	assert.False(t, b.enabled())
+	assert.False(t, b.report("example", fmt.Errorf("failed")))
+	assert.True(t, b.allow("example"))
+}
+
+// relationsCollector is a stub of per-relation collector.
+type relationsCollector struct {
+	relations int
+}
+
+func (c *relationsCollector) Update(_ Config, _ chan<- prometheus.Metric) error { return nil }
+
+func (c *relationsCollector) descriptors() []typedDesc { return nil }
+
+func (c *relationsCollector) relationsCount() int { return c.relations }
+
+func Test_adaptiveInterval(t *testing.T) {
+	assert.Equal(t, time.Duration(0), adaptiveInterval(100))
+	assert.Equal(t, 5*time.Minute, adaptiveInterval(20000))
+	assert.Equal(t, 15*time.Minute, adaptiveInterval(100000))
+}
+
+func Test_effectiveInterval(t *testing.T) {
+	c := &relationsCollector{}
+	assert.Equal(t, "10m0s", effectiveInterval("postgres/tables", model.CollectorSettings{Interval: 10 * time.Minute}, c))
+	assert.Equal(t, "adaptive", effectiveInterval("postgres/tables", model.CollectorSettings{}, c))
+	assert.Equal(t, "1h0m0s", effectiveInterval("postgres/bloat", model.CollectorSettings{}, nil))
+	assert.Equal(t, "every scrape", effectiveInterval("postgres/activity", model.CollectorSettings{}, nil))
+}
+
+func Test_schedule(t *testing.T) {
+	desc := prometheus.NewDesc("example", "example", nil, nil)
+
+	s := newSchedule(0)
+	assert.True(t, s.due())
+
+	// Record metrics passed by collector.
+	out := make(chan prometheus.Metric, 10)
+	in, stop := s.record(out)
+	in <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+	in <- nil
+	metrics := stop()
+	assert.Len(t, metrics, 1)
+	assert.Len(t, out, 1)
+
+	// Interval depends on number of relations.
+	c := &relationsCollector{relations: 20000}
+	s.finish("example", c, metrics, nil)
+	assert.Equal(t, 5*time.Minute, s.effective())
+	assert.False(t, s.due())
+
+	// Metrics of the previous run are replayed.
+	out = make(chan prometheus.Metric, 10)
+	s.replay(out)
+	assert.Len(t, out, 1)
+
+	assert.Equal(t, s.lastRun, s.last())
+
+	// Metrics of the run older than Prometheus lookback period are replayed without timestamps, hence they are not
+	// considered stale.
+	s.lastRun = time.Now().Add(-20 * time.Minute)
+	out = make(chan prometheus.Metric, 10)
+	s.replay(out)
+	assert.Len(t, out, 1)
+
+	m := &dto.Metric{}
+	assert.NoError(t, (<-out).Write(m))
+	assert.Nil(t, m.TimestampMs)
+
+	// Failed run is retried on the next scrape.
+	s.finish("example", c, nil, fmt.Errorf("failed"))
+	s.lastRun = time.Time{}
+	assert.True(t, s.due())
+
+	// Configured interval is not changed.
+	s = newSchedule(time.Minute)
+	s.finish("example", c, nil, nil)
+	assert.Equal(t, time.Minute, s.effective())
+}
+
+func TestPgscvCollector_Collect_adaptiveInterval(t *testing.T) {
+	f := Factories{}
+	f.register("example/relations", func(labels, model.CollectorSettings) (Collector, error) {
+		return &relationsCollector{relations: 20000}, nil
+	})
+
+	c, err := NewPgscvCollector("test:0", f, Config{})
+	assert.NoError(t, err)
+
+	collectAll := func() map[string]*dto.Metric {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			c.Collect(ch)
+			close(ch)
+		}()
+
+		metrics := map[string]*dto.Metric{}
+		for m := range ch {
+			pb := &dto.Metric{}
+			assert.NoError(t, m.Write(pb))
+			name := strings.Split(strings.Split(m.Desc().String(), `"`)[1], `"`)[0]
+			metrics[name] = pb
+		}
+		return metrics
+	}
+
+	// Number of relations exceeds the threshold, interval is stretched after the first run.
+	metrics := collectAll()
+	assert.Equal(t, float64(300), metrics["pgscv_collector_interval_seconds"].GetGauge().GetValue())
+	assert.Equal(t, "example/relations", metrics["pgscv_collector_interval_seconds"].GetLabel()[0].GetValue())
+
+	lastRun := metrics["pgscv_collector_last_run_timestamp_seconds"].GetGauge().GetValue()
+	assert.InDelta(t, float64(time.Now().Unix()), lastRun, 5)
+
+	// Collector is not run until interval is elapsed, time of the last run is not changed.
+	c.schedules["example/relations"].lastRun = time.Unix(int64(lastRun), 0).Add(-time.Minute)
+	metrics = collectAll()
+	assert.Equal(t, float64(300), metrics["pgscv_collector_interval_seconds"].GetGauge().GetValue())
+	assert.Equal(t, lastRun-60, metrics["pgscv_collector_last_run_timestamp_seconds"].GetGauge().GetValue())
+}
+
+func TestNewPgscvCollector_defaultIntervals(t *testing.T) {
+	f := Factories{}
+	f.RegisterPostgresCollectors([]string{})
+
+	c, err := NewPgscvCollector("test:0", f, Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, c.schedules["postgres/fdw"].effective())
+	assert.Equal(t, 5*time.Minute, c.schedules["postgres/plans"].effective())
+	assert.Equal(t, 15*time.Minute, c.schedules["postgres/relations"].effective())
+	assert.Equal(t, time.Hour, c.schedules["postgres/bloat"].effective())
+	assert.Equal(t, time.Hour, c.schedules["postgres/unused_indexes"].effective())
+
+	// Configured interval overrides default one.
+	c, err = NewPgscvCollector("test:0", f, Config{Settings: model.CollectorsSettings{"postgres/plans": {Interval: time.Minute}}})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, c.schedules["postgres/plans"].effective())
+}
+
+func Test_send(t *testing.T) {
+	desc := prometheus.NewDesc("example", "example", nil, nil)
+
+	var testcases = []struct {
+		limit       int
+		wantSent    int
+		wantDropped int
+	}{
+		{limit: 0, wantSent: 5, wantDropped: 0},
+		{limit: 3, wantSent: 3, wantDropped: 2},
+		{limit: 10, wantSent: 5, wantDropped: 0},
+	}
+
+	for _, tc := range testcases {
+		in := make(chan prometheus.Metric)
+		out := make(chan prometheus.Metric, 10)
+
+		go func() {
+			for i := 0; i < 5; i++ {
+				in <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(i))
+			}
+			close(in)
+		}()
+
+		assert.Equal(t, tc.wantDropped, send(in, out, nil, tc.limit))
+		close(out)
+		assert.Len(t, out, tc.wantSent)
+	}
+}
+
+func TestPgscvCollector_Collect_Cache(t *testing.T) {
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{CacheTTL: time.Minute})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	collectAll := func() []prometheus.Metric {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			c.Collect(ch)
+			close(ch)
+		}()
+
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		return metrics
+	}
+
+	first := collectAll()
+	assert.Greater(t, len(first), 0)
+	updated := c.cache.updated
+
+	// Second collect should return the same metrics from cache.
+	second := collectAll()
+	assert.Equal(t, first, second)
+	assert.Equal(t, updated, c.cache.updated)
+
+	// Expire cache, metrics should be collected again.
+	c.cache.updated = time.Time{}
+	_ = collectAll()
+	assert.True(t, c.cache.updated.After(updated))
+}
+
+func Test_errorClass(t *testing.T) {
+	testcases := []struct {
+		err  error
+		want string
+	}{
+		{err: &pgconn.PgError{Code: "42P01"}, want: "42"},
+		{err: fmt.Errorf("query failed: %w", &pgconn.PgError{Code: "57014"}), want: "57"},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: "network"},
+		{err: errors.New("example"), want: "other"},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, errorClass(tc.err))
+	}
+}
+
+func TestPgscvCollector_Collect_Durations(t *testing.T) {
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var durations, errs int
+	for m := range ch {
+		switch m.Desc() {
+		case c.durationDesc.desc:
+			durations++
+		case c.errorsDesc.desc:
+			errs++
+		}
+	}
+
+	// Duration and number of failures should be exposed for every collector.
+	assert.Equal(t, len(c.Collectors), durations)
+	assert.Equal(t, len(c.Collectors), errs)
+}
+
+func TestPgscvCollector_Collect_ServiceState(t *testing.T) {
+	collectState := func(c *PgscvCollector) (up float64, lastSuccess int) {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			c.Collect(ch)
+			close(ch)
+		}()
+
+		up = -1
+		for m := range ch {
+			switch m.Desc() {
+			case c.upDesc.desc:
+				metric := &dto.Metric{}
+				assert.NoError(t, m.Write(metric))
+				up = metric.GetGauge().GetValue()
+			case c.lastSuccessDesc.desc:
+				lastSuccess++
+			}
+		}
+		return up, lastSuccess
+	}
+
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{})
+	assert.NoError(t, err)
+
+	up, lastSuccess := collectState(c)
+	assert.Equal(t, float64(1), up)
+	assert.Equal(t, 1, lastSuccess)
+	assert.False(t, c.Status().LastSuccess.IsZero())
+
+	// Unreachable service is exposed as down, time of the last successful collection is not sent yet.
+	f = Factories{}
+	f.RegisterPostgresCollectors([]string{})
+	c, err = NewPgscvCollector("test:0", f, Config{ServiceType: model.ServiceTypePostgresql, ConnString: "host=127.0.0.1 port=1 connect_timeout=1"})
+	assert.NoError(t, err)
+
+	up, lastSuccess = collectState(c)
+	assert.Equal(t, float64(0), up)
+	assert.Equal(t, 0, lastSuccess)
+	assert.True(t, c.Status().LastSuccess.IsZero())
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	assert.Contains(t, names, "system/cpu")
+	assert.Contains(t, names, "postgres/tables")
+	assert.Contains(t, names, "pgbouncer/pools")
+	assert.True(t, sort.StringsAreSorted(names))
+}
+
+func Test_requirement_check(t *testing.T) {
+	var testcases = []struct {
+		name   string
+		req    requirement
+		config postgresServiceConfig
+		want   bool // true if requirements are satisfied
+	}{
+		{name: "no requirements", req: requirement{}, config: postgresServiceConfig{}, want: true},
+		{name: "version ok", req: requirement{minVersion: PostgresV12}, config: postgresServiceConfig{serverVersionNum: PostgresV13}, want: true},
+		{name: "version too old", req: requirement{minVersion: PostgresV12}, config: postgresServiceConfig{serverVersionNum: PostgresV10}, want: false},
+		{name: "local service", req: requirement{localService: true}, config: postgresServiceConfig{localService: false}, want: false},
+		{name: "logging collector", req: requirement{loggingCollector: true}, config: postgresServiceConfig{loggingCollector: false}, want: false},
+		{name: "pg_stat_statements", req: requirement{pgStatStatements: true}, config: postgresServiceConfig{pgStatStatements: true}, want: true},
+		{name: "pg_stat_monitor", req: requirement{pgStatStatements: true}, config: postgresServiceConfig{pgStatMonitor: true}, want: true},
+		{name: "no statements extension", req: requirement{pgStatStatements: true}, config: postgresServiceConfig{}, want: false},
+		{name: "pg_store_plans", req: requirement{pgStorePlans: true}, config: postgresServiceConfig{pgStorePlans: false}, want: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.req.check(tc.config) == "")
+		})
+	}
+}
+
+func TestStates(t *testing.T) {
+	settings := model.CollectorsSettings{"system/memory": {Interval: time.Minute}}
+	states, err := States(model.ServiceTypeSystem, "", []string{"system/cpu"}, settings)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, states)
+	for _, s := range states {
+		assert.True(t, strings.HasPrefix(s.Name, "system/"))
+		assert.Equal(t, s.Name != "system/cpu", s.Enabled)
+		assert.Empty(t, s.Reason)
+
+		switch s.Name {
+		case "system/cpu":
+			assert.Empty(t, s.Interval)
+		case "system/memory":
+			assert.Equal(t, "1m0s", s.Interval)
+		default:
+			assert.Equal(t, "every scrape", s.Interval)
+		}
+	}
+
+	_, err = States("unknown", "", nil, nil)
+	assert.Error(t, err)
+}
+
+func TestNewPgscvCollector_Labels(t *testing.T) {
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{Labels: map[string]string{"env": "prod"}})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	for m := range ch {
+		assert.Contains(t, m.Desc().String(), `env="prod"`)
+	}
 }
@@ -5,15 +5,19 @@ import (
 	"github.com/jackc/pgx/v4"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/relabel"
 	"github.com/lesovsky/pgscv/internal/store"
 	"net"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config defines collector's global configuration.
 type Config struct {
+	// ServiceID defines the identifier of the service used in logging.
+	ServiceID string
 	// ServiceType defines the type of discovered service. Depending on the type there should be different settings or
 	// settings-specifics metric collection usecases.
 	ServiceType string
@@ -25,8 +29,27 @@ type Config struct {
 	postgresServiceConfig
 	// DatabasesRE defines regexp with databases from which builtin metrics should be collected.
 	DatabasesRE *regexp.Regexp
+	// ExcludeDatabasesRE defines regexp with databases which should be skipped by collectors.
+	ExcludeDatabasesRE *regexp.Regexp
+	// DatabasesMinSize defines min size of databases in bytes, smaller databases are skipped by collectors.
+	DatabasesMinSize int64
 	// Settings defines collectors settings propagated from main YAML configuration.
 	Settings model.CollectorsSettings
+	// Relabel defines rules for relabeling and renaming collected metrics.
+	Relabel relabel.Rules
+	// SeriesLimit defines max number of series produced by all collectors of the service. Zero means no limit.
+	SeriesLimit int
+	// CacheTTL defines how long collected metrics are reused by subsequent scrapes. Zero means no caching.
+	CacheTTL time.Duration
+	// BreakerThreshold defines number of consecutive failures after which collector is disabled. Zero means collectors
+	// are never disabled.
+	BreakerThreshold int
+	// BreakerBackoff defines how long failed collector stays disabled.
+	BreakerBackoff time.Duration
+	// Audit defines audit mode is enabled.
+	Audit bool
+	// Labels defines constant labels attached to all metrics of the service.
+	Labels map[string]string
 }
 
 // postgresServiceConfig defines Postgres-specific stuff required during collecting Postgres metrics.
@@ -49,6 +72,18 @@ type postgresServiceConfig struct {
 	pgStatStatementsDatabase string
 	// pgStatStatementsSchema defines the schema name where pg_stat_statements is installed
 	pgStatStatementsSchema string
+	// pgStatMonitor defines is pg_stat_monitor available in shared_preload_libraries and available for queries
+	pgStatMonitor bool
+	// pgStatMonitorDatabase defines the database name where pg_stat_monitor is available
+	pgStatMonitorDatabase string
+	// pgStatMonitorSchema defines the schema name where pg_stat_monitor is installed
+	pgStatMonitorSchema string
+	// pgStorePlans defines is pg_store_plans available in shared_preload_libraries and available for queries
+	pgStorePlans bool
+	// pgStorePlansDatabase defines the database name where pg_store_plans is available
+	pgStorePlansDatabase string
+	// pgStorePlansSchema defines the schema name where pg_store_plans is installed
+	pgStorePlansSchema string
 }
 
 // newPostgresServiceConfig defines new config for Postgres-based collectors.
@@ -68,10 +103,56 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	// Determine is service running locally.
 	config.localService = isAddressLocal(pgconfig.Host)
 
-	conn, err := store.NewWithConfig(pgconfig)
+	// Settings are read using dedicated connection which is closed before discovering extensions, hence discovery
+	// doesn't wait for connection slot occupied by the same service when number of connections is limited.
+	err = readPostgresSettings(pgconfig, &config)
 	if err != nil {
 		return config, err
 	}
+
+	// Discover pg_stat_statements.
+	exists, database, schema, err := discoverExtension(connStr, "pg_stat_statements")
+	if err != nil {
+		return config, err
+	}
+
+	config.pgStatStatements = exists
+	config.pgStatStatementsDatabase = database
+	config.pgStatStatementsSchema = schema
+
+	// Discover pg_stat_monitor, it is preferred over pg_stat_statements when available.
+	exists, database, schema, err = discoverExtension(connStr, "pg_stat_monitor")
+	if err != nil {
+		return config, err
+	}
+
+	config.pgStatMonitor = exists
+	config.pgStatMonitorDatabase = database
+	config.pgStatMonitorSchema = schema
+
+	if !config.pgStatStatements && !config.pgStatMonitor {
+		log.Warnln("neither pg_stat_statements nor pg_stat_monitor found, skip collecting statements metrics")
+	}
+
+	// Discover pg_store_plans.
+	exists, database, schema, err = discoverExtension(connStr, "pg_store_plans")
+	if err != nil {
+		return config, err
+	}
+
+	config.pgStorePlans = exists
+	config.pgStorePlansDatabase = database
+	config.pgStorePlansSchema = schema
+
+	return config, nil
+}
+
+// readPostgresSettings reads Postgres settings necessary for collectors into passed config.
+func readPostgresSettings(pgconfig *pgx.ConnConfig, config *postgresServiceConfig) error {
+	conn, err := store.NewWithConfig(pgconfig)
+	if err != nil {
+		return err
+	}
 	defer conn.Close()
 
 	var setting string
@@ -79,11 +160,11 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	// Get Postgres block size.
 	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'block_size'").Scan(&setting)
 	if err != nil {
-		return config, err
+		return err
 	}
 	bsize, err := strconv.ParseUint(setting, 10, 64)
 	if err != nil {
-		return config, err
+		return err
 	}
 
 	config.blockSize = bsize
@@ -91,11 +172,11 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	// Get Postgres WAL segment size.
 	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'wal_segment_size'").Scan(&setting)
 	if err != nil {
-		return config, err
+		return err
 	}
 	walSegSize, err := strconv.ParseUint(setting, 10, 64)
 	if err != nil {
-		return config, err
+		return err
 	}
 
 	config.walSegmentSize = walSegSize
@@ -103,11 +184,11 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	// Get Postgres server version
 	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'server_version_num'").Scan(&setting)
 	if err != nil {
-		return config, err
+		return err
 	}
 	version, err := strconv.Atoi(setting)
 	if err != nil {
-		return config, err
+		return err
 	}
 
 	if version < PostgresVMinNum {
@@ -119,7 +200,7 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	// Get Postgres data directory
 	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'data_directory'").Scan(&setting)
 	if err != nil {
-		return config, err
+		return err
 	}
 
 	config.dataDirectory = setting
@@ -127,28 +208,14 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	// Get setting of 'logging_collector' GUC.
 	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'logging_collector'").Scan(&setting)
 	if err != nil {
-		return config, err
+		return err
 	}
 
 	if setting == "on" {
 		config.loggingCollector = true
 	}
 
-	// Discover pg_stat_statements.
-	exists, database, schema, err := discoverPgStatStatements(connStr)
-	if err != nil {
-		return config, err
-	}
-
-	if !exists {
-		log.Warnln("pg_stat_statements not found, skip collecting statements metrics")
-	}
-
-	config.pgStatStatements = exists
-	config.pgStatStatementsDatabase = database
-	config.pgStatStatementsSchema = schema
-
-	return config, nil
+	return nil
 }
 
 // isAddressLocal return true if passed address is local, and return false otherwise.
@@ -181,8 +248,9 @@ func isAddressLocal(addr string) bool {
 	return false
 }
 
-// discoverPgStatStatements discovers pg_stat_statements, what database and schema it is installed.
-func discoverPgStatStatements(connStr string) (bool, string, string, error) {
+// discoverExtension discovers extension which requires shared_preload_libraries (e.g. pg_stat_statements), what
+// database and schema it is installed.
+func discoverExtension(connStr string, name string) (bool, string, string, error) {
 	pgconfig, err := pgx.ParseConfig(connStr)
 	if err != nil {
 		return false, "", "", err
@@ -200,20 +268,20 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 		return false, "", "", err
 	}
 
-	// If pg_stat_statements is not enabled globally, no reason to continue.
-	if !strings.Contains(setting, "pg_stat_statements") {
+	// If extension is not enabled globally, no reason to continue.
+	if !strings.Contains(setting, name) {
 		conn.Close()
 		return false, "", "", nil
 	}
 
-	// Check for pg_stat_statements in default database specified in connection string.
-	if schema := extensionInstalledSchema(conn, "pg_stat_statements"); schema != "" {
+	// Check for extension in default database specified in connection string.
+	if schema := extensionInstalledSchema(conn, name); schema != "" {
 		conn.Close()
 		return true, conn.Conn().Config().Database, schema, nil
 	}
 
 	// Pessimistic case.
-	// If we're here it means pg_stat_statements is not available
+	// If we're here it means extension is not available
 	// and we have to walk through all database and looking for it.
 
 	// Get databases list from current connection.
@@ -226,7 +294,7 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 	// Close connection to current database, it's not interesting anymore.
 	conn.Close()
 
-	// Establish connection to each database in the list and check where pg_stat_statements is installed.
+	// Establish connection to each database in the list and check where extension is installed.
 	for _, d := range databases {
 		pgconfig.Database = d
 		conn, err := store.NewWithConfig(pgconfig)
@@ -235,8 +303,8 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 			continue
 		}
 
-		// If pg_stat_statements found, update source and return connection.
-		if schema := extensionInstalledSchema(conn, "pg_stat_statements"); schema != "" {
+		// If extension found, update source and return connection.
+		if schema := extensionInstalledSchema(conn, name); schema != "" {
 			conn.Close()
 			return true, conn.Conn().Config().Database, schema, nil
 		}
@@ -247,7 +315,7 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 
 	// No luck.
 	// If we are here it means all database checked and
-	// pg_stat_statements is not found (not installed).
+	// extension is not found (not installed).
 	return false, "", "", nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"github.com/lesovsky/pgscv/internal/store"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func Test_newPostgresServiceConfig(t *testing.T) {
@@ -28,6 +29,25 @@ func Test_newPostgresServiceConfig(t *testing.T) {
 	}
 }
 
+func Test_newPostgresServiceConfig_maxConnections(t *testing.T) {
+	store.SetMaxConnections(1)
+	defer store.SetMaxConnections(0)
+
+	// Discovery of extensions doesn't wait for the connection used for reading settings.
+	done := make(chan error)
+	go func() {
+		_, err := newPostgresServiceConfig(store.TestPostgresConnStr)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("service config discovery hangs")
+	}
+}
+
 func Test_isAddressLocal(t *testing.T) {
 	testcases := []struct {
 		addr string
@@ -49,7 +69,7 @@ func Test_isAddressLocal(t *testing.T) {
 	}
 }
 
-func Test_discoverPgStatStatements(t *testing.T) {
+func Test_discoverExtension(t *testing.T) {
 	testcases := []struct {
 		valid   bool
 		connstr string
@@ -60,7 +80,7 @@ func Test_discoverPgStatStatements(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
-		exists, database, schema, err := discoverPgStatStatements(tc.connstr)
+		exists, database, schema, err := discoverExtension(tc.connstr, "pg_stat_statements")
 		if tc.valid {
 			assert.True(t, exists)
 			assert.Equal(t, "pgscv_fixtures", database)
@@ -0,0 +1,140 @@
+//go:build freebsd
+
+package collector
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+	"time"
+	"unsafe"
+)
+
+type cpuCollector struct {
+	cpu      typedDesc
+	cpuAll   typedDesc
+	uptime   typedDesc
+	idletime typedDesc
+}
+
+// NewCPUCollector returns a new Collector exposing kernel/system statistics.
+func NewCPUCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &cpuCollector{
+		cpu: newBuiltinTypedDesc(
+			descOpts{"node", "cpu", "seconds_total", "Seconds the CPUs spent in each mode.", 0},
+			prometheus.CounterValue,
+			[]string{"mode"}, constLabels,
+			settings.Filters,
+		),
+		cpuAll: newBuiltinTypedDesc(
+			descOpts{"node", "cpu", "seconds_all_total", "Seconds the CPUs spent in all modes.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		uptime: newBuiltinTypedDesc(
+			descOpts{"node", "uptime", "up_seconds_total", "Total number of seconds the system has been up, accordingly to kern.boottime.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		idletime: newBuiltinTypedDesc(
+			descOpts{"node", "uptime", "idle_seconds_total", "Total number of seconds all cores have spent idle, accordingly to kern.cp_time.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *cpuCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.cpu,
+		c.cpuAll,
+		c.uptime,
+		c.idletime,
+	}
+}
+
+// Update implements Collector and exposes cpu related metrics from kern.cp_time and kern.boottime sysctls.
+func (c *cpuCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	stat, err := getCPUStat()
+	if err != nil {
+		return fmt.Errorf("collect cpu usage stats failed: %s; skip", err)
+	}
+
+	boottime, err := unix.SysctlTimeval("kern.boottime")
+	if err != nil {
+		return fmt.Errorf("collect uptime stats failed: %s; skip", err)
+	}
+
+	// Collected time represents summary time spent by ALL cpu cores.
+	ch <- c.cpu.newConstMetric(stat.user, "user")
+	ch <- c.cpu.newConstMetric(stat.nice, "nice")
+	ch <- c.cpu.newConstMetric(stat.system, "system")
+	ch <- c.cpu.newConstMetric(stat.irq, "irq")
+	ch <- c.cpu.newConstMetric(stat.idle, "idle")
+
+	ch <- c.cpuAll.newConstMetric(stat.user + stat.nice + stat.system + stat.irq + stat.idle)
+
+	ch <- c.uptime.newConstMetric(time.Since(time.Unix(boottime.Unix())).Seconds())
+	ch <- c.idletime.newConstMetric(stat.idle)
+
+	return nil
+}
+
+// cpuStat describes time spent by all CPUs in each mode, in seconds.
+type cpuStat struct {
+	user   float64
+	nice   float64
+	system float64
+	irq    float64
+	idle   float64
+}
+
+// getCPUStat reads kern.cp_time and kern.clockrate sysctls and returns total CPU usage stat.
+func getCPUStat() (cpuStat, error) {
+	// kern.clockrate is the 'struct clockinfo' of five integers: hz, tick, spare, stathz, profhz.
+	clockrate, err := unix.SysctlRaw("kern.clockrate")
+	if err != nil {
+		return cpuStat{}, err
+	}
+
+	clockinfo, err := decodeSysctlNumbers(clockrate, 4)
+	if err != nil || len(clockinfo) < 4 {
+		return cpuStat{}, fmt.Errorf("invalid kern.clockrate value")
+	}
+
+	cptime, err := unix.SysctlRaw("kern.cp_time")
+	if err != nil {
+		return cpuStat{}, err
+	}
+
+	return parseCPTime(cptime, int(unsafe.Sizeof(int(0))), clockinfo[3])
+}
+
+// parseCPTime parses kern.cp_time value - array of 'long' ticks spent in user, nice, system, interrupt and idle modes.
+func parseCPTime(buf []byte, size int, stathz float64) (cpuStat, error) {
+	if stathz <= 0 {
+		return cpuStat{}, fmt.Errorf("invalid stathz value %f", stathz)
+	}
+
+	values, err := decodeSysctlNumbers(buf, size)
+	if err != nil {
+		return cpuStat{}, err
+	}
+
+	if len(values) != 5 {
+		return cpuStat{}, fmt.Errorf("invalid input, wrong number of values: %d", len(values))
+	}
+
+	return cpuStat{
+		user:   values[0] / stathz,
+		nice:   values[1] / stathz,
+		system: values[2] / stathz,
+		irq:    values[3] / stathz,
+		idle:   values[4] / stathz,
+	}, nil
+}
@@ -0,0 +1,25 @@
+//go:build freebsd
+
+package collector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"unsafe"
+)
+
+func Test_parseCPTime(t *testing.T) {
+	ticks := []uint64{12800, 256, 6400, 128, 128000}
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(&ticks[0])), len(ticks)*8)
+
+	stat, err := parseCPTime(buf, 8, 128)
+	assert.NoError(t, err)
+	assert.Equal(t, cpuStat{user: 100, nice: 2, system: 50, irq: 1, idle: 1000}, stat)
+
+	_, err = parseCPTime(buf, 8, 0)
+	assert.Error(t, err)
+	_, err = parseCPTime(buf[:16], 8, 128)
+	assert.Error(t, err)
+	_, err = parseCPTime(buf[:15], 8, 128)
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+//go:build freebsd
+
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+type filesystemCollector struct {
+	bytes      typedDesc
+	bytesTotal typedDesc
+	files      typedDesc
+	filesTotal typedDesc
+}
+
+// NewFilesystemCollector returns a new Collector exposing filesystem stats.
+func NewFilesystemCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+
+	// Define default filters (if no already present) to avoid collecting metrics about exotic filesystems.
+	if _, ok := settings.Filters["fstype"]; !ok {
+		if settings.Filters == nil {
+			settings.Filters = filter.New()
+		}
+
+		settings.Filters.Add("fstype", filter.Filter{Include: `^(ufs|zfs)$`})
+		err := settings.Filters.Compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &filesystemCollector{
+		bytes: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "bytes", "Number of bytes of filesystem by usage.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype", "usage"}, constLabels,
+			settings.Filters,
+		),
+		bytesTotal: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "bytes_total", "Total number of bytes of filesystem capacity.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype"}, constLabels,
+			settings.Filters,
+		),
+		files: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "files", "Number of files (inodes) of filesystem by usage.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype", "usage"}, constLabels,
+			settings.Filters,
+		),
+		filesTotal: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "files_total", "Total number of files (inodes) of filesystem capacity.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *filesystemCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.bytes,
+		c.bytesTotal,
+		c.files,
+		c.filesTotal,
+	}
+}
+
+// Update method collects filesystem usage statistics.
+func (c *filesystemCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	stats, err := getFilesystemStats()
+	if err != nil {
+		return fmt.Errorf("get filesystem stats failed: %s", err)
+	}
+
+	for _, s := range stats {
+		// Truncate device paths to device names, e.g /dev/ada0p2 -> ada0p2
+		device := truncateDeviceName(s.mount.device)
+
+		// bytes; free = avail + reserved; total = used + free
+		ch <- c.bytesTotal.newConstMetric(s.size, device, s.mount.mountpoint, s.mount.fstype)
+		ch <- c.bytes.newConstMetric(s.avail, device, s.mount.mountpoint, s.mount.fstype, "avail")
+		ch <- c.bytes.newConstMetric(s.free-s.avail, device, s.mount.mountpoint, s.mount.fstype, "reserved")
+		ch <- c.bytes.newConstMetric(s.size-s.free, device, s.mount.mountpoint, s.mount.fstype, "used")
+		// files (inodes)
+		ch <- c.filesTotal.newConstMetric(s.files, device, s.mount.mountpoint, s.mount.fstype)
+		ch <- c.files.newConstMetric(s.filesfree, device, s.mount.mountpoint, s.mount.fstype, "free")
+		ch <- c.files.newConstMetric(s.files-s.filesfree, device, s.mount.mountpoint, s.mount.fstype, "used")
+	}
+
+	return nil
+}
+
+// filesystemStat describes various stats related to filesystem usage.
+type filesystemStat struct {
+	mount     mount
+	size      float64
+	free      float64
+	avail     float64
+	files     float64
+	filesfree float64
+}
+
+// getFilesystemStats requests stats of mounted filesystems from kernel. Stats are requested with MNT_NOWAIT flag,
+// hence kernel returns cached stats and doesn't poll filesystems, which might stuck.
+func getFilesystemStats() ([]filesystemStat, error) {
+	n, err := unix.Getfsstat(nil, unix.MNT_NOWAIT)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]unix.Statfs_t, n)
+	n, err = unix.Getfsstat(buf, unix.MNT_NOWAIT)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]filesystemStat, 0, n)
+	for _, s := range buf[:n] {
+		stats = append(stats, filesystemStat{
+			mount: mount{
+				device:     cString(s.Mntfromname[:]),
+				mountpoint: cString(s.Mntonname[:]),
+				fstype:     cString(s.Fstypename[:]),
+			},
+			size:      float64(s.Blocks) * float64(s.Bsize),
+			free:      float64(s.Bfree) * float64(s.Bsize),
+			avail:     float64(s.Bavail) * float64(s.Bsize),
+			files:     float64(s.Files),
+			filesfree: float64(s.Ffree),
+		})
+	}
+
+	return stats, nil
+}
+
+// cString converts NUL-terminated bytes to string.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
@@ -0,0 +1,108 @@
+//go:build freebsd
+
+package collector
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type meminfoCollector struct {
+	subsysFilters filter.Filters
+	constLabels   labels
+	memused       typedDesc
+	meminfoDescs  typedDescCache
+}
+
+// NewMeminfoCollector returns a new Collector exposing memory stats.
+func NewMeminfoCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &meminfoCollector{
+		subsysFilters: settings.Filters,
+		constLabels:   constLabels,
+		memused: newBuiltinTypedDesc(
+			descOpts{"node", "memory", "MemUsed", "Memory information composite field MemUsed.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *meminfoCollector) descriptors() []typedDesc {
+	descs := []typedDesc{c.memused}
+	for _, param := range []string{"MemTotal", "MemFree", "Active", "Inactive", "Wired", "Buffers"} {
+		descs = append(descs, c.meminfoDesc(param))
+	}
+	return descs
+}
+
+// Update method collects memory statistics.
+func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	meminfo, err := getMeminfoStats()
+	if err != nil {
+		return fmt.Errorf("get memory stats failed: %s", err)
+	}
+
+	for param, value := range meminfo {
+		desc := c.meminfoDescs.get(param, func() typedDesc { return c.meminfoDesc(param) })
+
+		ch <- desc.newConstMetric(value)
+	}
+
+	// MemUsed is composite metric, inactive pages and buffers are considered as reclaimable.
+	ch <- c.memused.newConstMetric(meminfo["MemTotal"] - meminfo["MemFree"] - meminfo["Inactive"] - meminfo["Buffers"])
+
+	return nil
+}
+
+// meminfoDesc returns descriptor of metric for memory stats field.
+func (c *meminfoCollector) meminfoDesc(param string) typedDesc {
+	return newBuiltinTypedDesc(
+		descOpts{"node", "memory", param, fmt.Sprintf("Memory information field %s.", param), 0},
+		prometheus.GaugeValue,
+		nil, c.constLabels,
+		c.subsysFilters,
+	)
+}
+
+// getMeminfoStats reads memory stats from sysctls and returns them in bytes, named similar to /proc/meminfo fields.
+func getMeminfoStats() (map[string]float64, error) {
+	pagesize, err := sysctlNumber("hw.pagesize")
+	if err != nil {
+		return nil, err
+	}
+
+	var stats = map[string]float64{}
+
+	total, err := sysctlNumber("hw.physmem")
+	if err != nil {
+		return nil, err
+	}
+	stats["MemTotal"] = total
+
+	buffers, err := sysctlNumber("vfs.bufspace")
+	if err != nil {
+		return nil, err
+	}
+	stats["Buffers"] = buffers
+
+	pages := map[string]string{
+		"MemFree":  "vm.stats.vm.v_free_count",
+		"Active":   "vm.stats.vm.v_active_count",
+		"Inactive": "vm.stats.vm.v_inactive_count",
+		"Wired":    "vm.stats.vm.v_wire_count",
+	}
+
+	for param, name := range pages {
+		value, err := sysctlNumber(name)
+		if err != nil {
+			return nil, err
+		}
+		stats[param] = value * pagesize
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,138 @@
+//go:build freebsd
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"os/exec"
+	"strings"
+)
+
+type netdevCollector struct {
+	bytes   typedDesc
+	packets typedDesc
+	events  typedDesc
+}
+
+// NewNetdevCollector returns a new Collector exposing network interfaces stats.
+func NewNetdevCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+
+	// Define default filters (if no already present) to avoid collecting metrics about virtual interfaces.
+	if _, ok := settings.Filters["device"]; !ok {
+		if settings.Filters == nil {
+			settings.Filters = filter.New()
+		}
+
+		settings.Filters.Add("device", filter.Filter{Exclude: `^(lo|pflog|enc)[0-9]+$`})
+		err := settings.Filters.Compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &netdevCollector{
+		bytes: newBuiltinTypedDesc(
+			descOpts{"node", "network", "bytes_total", "Total number of bytes processed by network device, by each direction.", 0},
+			prometheus.CounterValue,
+			[]string{"device", "type"}, constLabels,
+			settings.Filters,
+		),
+		packets: newBuiltinTypedDesc(
+			descOpts{"node", "network", "packets_total", "Total number of packets processed by network device, by each direction.", 0},
+			prometheus.CounterValue,
+			[]string{"device", "type"}, constLabels,
+			settings.Filters,
+		),
+		events: newBuiltinTypedDesc(
+			descOpts{"node", "network", "events_total", "Total number of events occurred on network device, by each type and direction.", 0},
+			prometheus.CounterValue,
+			[]string{"device", "type", "event"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *netdevCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.bytes,
+		c.packets,
+		c.events,
+	}
+}
+
+// Update method collects network interfaces statistics
+func (c *netdevCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	stats, err := getNetdevStats()
+	if err != nil {
+		return fmt.Errorf("get network interfaces stats failed: %s", err)
+	}
+
+	for _, s := range stats {
+		// recv
+		ch <- c.bytes.newConstMetric(s.RecvBytes, s.Name, "recv")
+		ch <- c.packets.newConstMetric(s.RecvPackets, s.Name, "recv")
+		ch <- c.events.newConstMetric(s.RecvErrors, s.Name, "recv", "errs")
+		ch <- c.events.newConstMetric(s.RecvDropped, s.Name, "recv", "drop")
+
+		// sent
+		ch <- c.bytes.newConstMetric(s.SentBytes, s.Name, "sent")
+		ch <- c.packets.newConstMetric(s.SentPackets, s.Name, "sent")
+		ch <- c.events.newConstMetric(s.SentErrors, s.Name, "sent", "errs")
+		ch <- c.events.newConstMetric(s.Collisions, s.Name, "sent", "colls")
+	}
+
+	return nil
+}
+
+// netdevStat describes network interface stats reported by netstat(1).
+type netdevStat struct {
+	Name        string  `json:"name"`
+	Network     string  `json:"network"`
+	RecvPackets float64 `json:"received-packets"`
+	RecvErrors  float64 `json:"received-errors"`
+	RecvDropped float64 `json:"dropped-packets"`
+	RecvBytes   float64 `json:"received-bytes"`
+	SentPackets float64 `json:"sent-packets"`
+	SentErrors  float64 `json:"send-errors"`
+	SentBytes   float64 `json:"sent-bytes"`
+	Collisions  float64 `json:"collisions"`
+}
+
+// getNetdevStats runs netstat(1) with JSON output and parses its output.
+func getNetdevStats() ([]netdevStat, error) {
+	output, err := exec.Command("netstat", "-i", "-b", "-d", "-n", "-W", "--libxo", "json").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNetdevStats(output)
+}
+
+// parseNetdevStats parses netstat(1) JSON output. Interfaces are listed once per each address, only link-level
+// records are used.
+func parseNetdevStats(data []byte) ([]netdevStat, error) {
+	var output struct {
+		Statistics struct {
+			Interface []netdevStat `json:"interface"`
+		} `json:"statistics"`
+	}
+
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+
+	var stats []netdevStat
+	for _, s := range output.Statistics.Interface {
+		if !strings.HasPrefix(s.Network, "<Link#") {
+			continue
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,25 @@
+//go:build freebsd
+
+package collector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_parseNetdevStats(t *testing.T) {
+	data := []byte(`{"statistics": {"interface": [
+{"name":"em0","flags":"0x8843","network":"<Link#1>","address":"08:00:27:a6:94:0b","received-packets":1000,"received-errors":1,"dropped-packets":2,"received-bytes":100000,"sent-packets":500,"send-errors":3,"sent-bytes":50000,"collisions":4},
+{"name":"em0","flags":"0x8843","network":"10.0.2.0/24","address":"10.0.2.15","received-packets":900,"received-bytes":90000,"sent-packets":400,"sent-bytes":40000}
+]}}`)
+
+	stats, err := parseNetdevStats(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []netdevStat{{
+		Name: "em0", Network: "<Link#1>", RecvPackets: 1000, RecvErrors: 1, RecvDropped: 2, RecvBytes: 100000,
+		SentPackets: 500, SentErrors: 3, SentBytes: 50000, Collisions: 4,
+	}}, stats)
+
+	_, err = parseNetdevStats([]byte("invalid"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+//go:build freebsd
+
+package collector
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"unsafe"
+)
+
+// sysctlNumber reads numeric sysctl value. Depending on platform and parameter, values could be 4 or 8 bytes long.
+func sysctlNumber(name string) (float64, error) {
+	buf, err := unix.SysctlRaw(name)
+	if err != nil {
+		return 0, fmt.Errorf("read sysctl %s failed: %s", name, err)
+	}
+
+	values, err := decodeSysctlNumbers(buf, len(buf))
+	if err != nil {
+		return 0, fmt.Errorf("read sysctl %s failed: %s", name, err)
+	}
+
+	return values[0], nil
+}
+
+// decodeSysctlNumbers decodes raw sysctl value into list of numbers of specified size in bytes.
+func decodeSysctlNumbers(buf []byte, size int) ([]float64, error) {
+	if (size != 4 && size != 8) || len(buf) == 0 || len(buf)%size != 0 {
+		return nil, fmt.Errorf("unexpected value size %d, item size %d", len(buf), size)
+	}
+
+	values := make([]float64, 0, len(buf)/size)
+	for i := 0; i < len(buf); i += size {
+		switch size {
+		case 4:
+			values = append(values, float64(*(*uint32)(unsafe.Pointer(&buf[i]))))
+		case 8:
+			values = append(values, float64(*(*uint64)(unsafe.Pointer(&buf[i]))))
+		}
+	}
+
+	return values, nil
+}
@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -73,6 +75,17 @@ func NewCPUCollector(constLabels labels, settings model.CollectorSettings) (Coll
 	return c, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *cpuCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.cpu,
+		c.cpuAll,
+		c.cpuGuest,
+		c.uptime,
+		c.idletime,
+	}
+}
+
 // Update implements Collector and exposes cpu related metrics from /proc/stat and /sys/.../cpu/.
 func (c *cpuCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	stat, err := getCPUStat(c.systicks)
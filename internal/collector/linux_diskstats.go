@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -140,6 +142,25 @@ func NewDiskstatsCollector(constLabels labels, settings model.CollectorSettings)
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *diskstatsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.completed,
+		c.completedAll,
+		c.merged,
+		c.mergedAll,
+		c.bytes,
+		c.bytesAll,
+		c.times,
+		c.timesAll,
+		c.ionow,
+		c.iotime,
+		c.iotimeweighted,
+		c.storageInfo,
+		c.storageSize,
+	}
+}
+
 func (c *diskstatsCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	stats, err := getDiskstats()
 	if err != nil {
@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -68,6 +70,16 @@ func NewFilesystemCollector(constLabels labels, settings model.CollectorSettings
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *filesystemCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.bytes,
+		c.bytesTotal,
+		c.files,
+		c.filesTotal,
+	}
+}
+
 // Update method collects filesystem usage statistics.
 func (c *filesystemCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	stats, err := getFilesystemStats()
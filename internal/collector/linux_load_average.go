@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -40,6 +42,15 @@ func NewLoadAverageCollector(constLabels labels, settings model.CollectorSetting
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *loadaverageCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.load1,
+		c.load5,
+		c.load15,
+	}
+}
+
 // Update implements Collector and exposes load average related metrics from /proc/loadavg.
 func (c *loadaverageCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	stats, err := getLoadAverageStats()
@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -14,12 +16,29 @@ import (
 	"strings"
 )
 
+var (
+	// meminfoCommonFields defines commonly used /proc/meminfo fields.
+	meminfoCommonFields = []string{
+		"MemTotal", "MemFree", "MemAvailable", "Buffers", "Cached", "SwapCached", "Active", "Inactive",
+		"Active(anon)", "Inactive(anon)", "Active(file)", "Inactive(file)", "SwapTotal", "SwapFree", "Dirty",
+		"Writeback", "AnonPages", "Mapped", "Shmem", "Slab", "SReclaimable", "SUnreclaim", "PageTables",
+		"CommitLimit", "Committed_AS", "HugePages_Total", "HugePages_Free", "HugePages_Rsvd", "Hugepagesize",
+	}
+
+	// vmstatCommonFields defines commonly used /proc/vmstat fields.
+	vmstatCommonFields = []string{
+		"nr_dirty", "nr_writeback", "pgpgin", "pgpgout", "pswpin", "pswpout", "pgfault", "pgmajfault",
+	}
+)
+
 type meminfoCollector struct {
 	re            *regexp.Regexp
 	subsysFilters filter.Filters
 	constLabels   labels
 	memused       typedDesc
 	swapused      typedDesc
+	meminfoDescs  typedDescCache
+	vmstatDescs   typedDescCache
 }
 
 // NewMeminfoCollector returns a new Collector exposing memory stats.
@@ -43,6 +62,19 @@ func NewMeminfoCollector(constLabels labels, settings model.CollectorSettings) (
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector. Set of /proc/meminfo and /proc/vmstat fields
+// depends on kernel version, hence only commonly used fields are described.
+func (c *meminfoCollector) descriptors() []typedDesc {
+	descs := []typedDesc{c.memused, c.swapused}
+	for _, param := range meminfoCommonFields {
+		descs = append(descs, c.meminfoDesc(param))
+	}
+	for _, param := range vmstatCommonFields {
+		descs = append(descs, c.vmstatDesc(param))
+	}
+	return descs
+}
+
 // Update method collects network interfaces statistics.
 func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	meminfo, err := getMeminfoStats()
@@ -57,13 +89,7 @@ func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 
 	// Processing meminfo stats.
 	for param, value := range meminfo {
-		param = c.re.ReplaceAllString(param, "_${1}")
-		desc := newBuiltinTypedDesc(
-			descOpts{"node", "memory", param, fmt.Sprintf("Memory information field %s.", param), 0},
-			prometheus.GaugeValue,
-			nil, c.constLabels,
-			c.subsysFilters,
-		)
+		desc := c.meminfoDescs.get(param, func() typedDesc { return c.meminfoDesc(param) })
 
 		ch <- desc.newConstMetric(value)
 	}
@@ -74,20 +100,7 @@ func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 
 	// Processing vmstat stats.
 	for param, value := range vmstat {
-		// Depending on key name, make an assumption about metric type.
-		// Analyzing of vmstat content shows that gauge values have 'nr_' prefix. But without of
-		// strong knowledge of kernel internals this is just an assumption and could be mistaken.
-		t := prometheus.CounterValue
-		if strings.HasPrefix(param, "nr_") {
-			t = prometheus.GaugeValue
-		}
-
-		param = c.re.ReplaceAllString(param, "_${1}")
-
-		desc := newBuiltinTypedDesc(
-			descOpts{"node", "vmstat", param, fmt.Sprintf("Vmstat information field %s.", param), 0},
-			t, nil, c.constLabels, c.subsysFilters,
-		)
+		desc := c.vmstatDescs.get(param, func() typedDesc { return c.vmstatDesc(param) })
 
 		ch <- desc.newConstMetric(value)
 	}
@@ -95,6 +108,35 @@ func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	return nil
 }
 
+// meminfoDesc returns descriptor of metric for /proc/meminfo field.
+func (c *meminfoCollector) meminfoDesc(param string) typedDesc {
+	name := c.re.ReplaceAllString(param, "_${1}")
+	return newBuiltinTypedDesc(
+		descOpts{"node", "memory", name, fmt.Sprintf("Memory information field %s.", name), 0},
+		prometheus.GaugeValue,
+		nil, c.constLabels,
+		c.subsysFilters,
+	)
+}
+
+// vmstatDesc returns descriptor of metric for /proc/vmstat field.
+func (c *meminfoCollector) vmstatDesc(param string) typedDesc {
+	// Depending on key name, make an assumption about metric type.
+	// Analyzing of vmstat content shows that gauge values have 'nr_' prefix. But without of
+	// strong knowledge of kernel internals this is just an assumption and could be mistaken.
+	t := prometheus.CounterValue
+	if strings.HasPrefix(param, "nr_") {
+		t = prometheus.GaugeValue
+	}
+
+	name := c.re.ReplaceAllString(param, "_${1}")
+
+	return newBuiltinTypedDesc(
+		descOpts{"node", "vmstat", name, fmt.Sprintf("Vmstat information field %s.", name), 0},
+		t, nil, c.constLabels, c.subsysFilters,
+	)
+}
+
 // getMeminfoStats is the intermediate function which opens stats file and run stats parser for extracting stats.
 func getMeminfoStats() (map[string]float64, error) {
 	file, err := os.Open("/proc/meminfo")
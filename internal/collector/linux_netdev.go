@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -57,6 +59,15 @@ func NewNetdevCollector(constLabels labels, settings model.CollectorSettings) (C
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *netdevCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.bytes,
+		c.packets,
+		c.events,
+	}
+}
+
 // Update method collects network interfaces statistics
 func (c *netdevCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	stats, err := getNetdevStats()
@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -31,6 +33,14 @@ func NewNetworkCollector(constLabels labels, settings model.CollectorSettings) (
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *networkCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.privateAddresses,
+		c.publicAddresses,
+	}
+}
+
 func (c *networkCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	addresses, err := net.InterfaceAddrs()
 	if err != nil {
@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -89,6 +91,19 @@ func NewSysconfigCollector(constLabels labels, settings model.CollectorSettings)
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *systemCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.sysctl,
+		c.cpucores,
+		c.governors,
+		c.numanodes,
+		c.ctxt,
+		c.forks,
+		c.btime,
+	}
+}
+
 // Update method collects filesystem usage statistics.
 func (c *systemCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	sysctls := readSysctls(c.sysctlList)
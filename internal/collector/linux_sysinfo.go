@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -35,6 +37,14 @@ func NewSysInfoCollector(constLabels labels, settings model.CollectorSettings) (
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *sysinfoCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.platform,
+		c.os,
+	}
+}
+
 // Update implements Collector and exposes system info metrics.
 func (c *sysinfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	info, err := getSysInfo()
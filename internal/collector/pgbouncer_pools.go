@@ -50,6 +50,15 @@ func NewPgbouncerPoolsCollector(constLabels labels, settings model.CollectorSett
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *pgbouncerPoolsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.conns,
+		c.maxwait,
+		c.clients,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *pgbouncerPoolsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -59,6 +59,16 @@ func NewPgbouncerSettingsCollector(constLabels labels, settings model.CollectorS
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *pgbouncerSettingsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.version,
+		c.settings,
+		c.dbSettings,
+		c.poolSize,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *pgbouncerSettingsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -28,7 +28,7 @@ func NewPgbouncerStatsCollector(constLabels labels, settings model.CollectorSett
 		labelNames: pgbouncerLabelNames,
 		up: newBuiltinTypedDesc(
 			descOpts{"pgbouncer", "", "up", "State of Pgbouncer service: 0 is down, 1 is up.", 0},
-			prometheus.CounterValue,
+			prometheus.GaugeValue,
 			nil, constLabels,
 			settings.Filters,
 		),
@@ -63,6 +63,17 @@ func NewPgbouncerStatsCollector(constLabels labels, settings model.CollectorSett
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *pgbouncerStatsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.up,
+		c.xacts,
+		c.queries,
+		c.bytes,
+		c.time,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *pgbouncerStatsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -21,6 +21,13 @@ func NewPgscvServicesCollector(constLabels labels, settings model.CollectorSetti
 		)}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *pgscvServicesCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.service,
+	}
+}
+
 // Update method is used for sending pgscvServicesCollector's metrics.
 func (c *pgscvServicesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	ch <- c.service.newConstMetric(1, config.ServiceType)
@@ -51,6 +51,12 @@ const (
 
 	postgresPreparedXactQuery = "SELECT count(*) AS total FROM pg_prepared_xacts"
 
+	// postgresPreparedXactsByOwnerQuery defines query for querying number of prepared transactions and age of the
+	// oldest one per database and owner.
+	postgresPreparedXactsByOwnerQuery = "SELECT database, owner, count(*) AS count, " +
+		"extract(epoch FROM clock_timestamp() - min(prepared)) AS max_age_seconds " +
+		"FROM pg_prepared_xacts GROUP BY database, owner"
+
 	postgresStartTimeQuery = "SELECT extract(epoch FROM pg_postmaster_start_time())"
 
 	// Backend states accordingly to pg_stat_activity.state
@@ -68,16 +74,18 @@ const (
 
 // postgresActivityCollector contains metrics related to Postgres activity.
 type postgresActivityCollector struct {
-	up         typedDesc
-	startTime  typedDesc
-	waitEvents typedDesc
-	states     typedDesc
-	statesAll  typedDesc
-	activity   typedDesc
-	prepared   typedDesc
-	inflight   typedDesc
-	vacuums    typedDesc
-	re         queryRegexp // regexps for queries classification
+	up             typedDesc
+	startTime      typedDesc
+	waitEvents     typedDesc
+	states         typedDesc
+	statesAll      typedDesc
+	activity       typedDesc
+	prepared       typedDesc
+	preparedBy     typedDesc
+	preparedMaxAge typedDesc
+	inflight       typedDesc
+	vacuums        typedDesc
+	re             queryRegexp // regexps for queries classification
 }
 
 // NewPostgresActivityCollector returns a new Collector exposing postgres activity stats.
@@ -128,6 +136,18 @@ func NewPostgresActivityCollector(constLabels labels, settings model.CollectorSe
 			nil, constLabels,
 			settings.Filters,
 		),
+		preparedBy: newBuiltinTypedDesc(
+			descOpts{"postgres", "activity", "prepared_transactions_by_owner_in_flight", "Number of transactions that are currently prepared for two-phase commit, per database and owner.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "owner"}, constLabels,
+			settings.Filters,
+		),
+		preparedMaxAge: newBuiltinTypedDesc(
+			descOpts{"postgres", "activity", "prepared_transactions_max_age_seconds", "Age of the oldest transaction prepared for two-phase commit, per database and owner, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "owner"}, constLabels,
+			settings.Filters,
+		),
 		inflight: newBuiltinTypedDesc(
 			descOpts{"postgres", "activity", "queries_in_flight", "Number of queries running in-flight of each type.", 0},
 			prometheus.GaugeValue,
@@ -144,6 +164,23 @@ func NewPostgresActivityCollector(constLabels labels, settings model.CollectorSe
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresActivityCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.up,
+		c.startTime,
+		c.waitEvents,
+		c.states,
+		c.statesAll,
+		c.activity,
+		c.prepared,
+		c.preparedBy,
+		c.preparedMaxAge,
+		c.inflight,
+		c.vacuums,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -171,6 +208,15 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 		stats.prepared = float64(count)
 	}
 
+	// get prepared transactions per database and owner
+	var prepared []postgresPreparedXacts
+	res, err = conn.Query(postgresPreparedXactsByOwnerQuery)
+	if err != nil {
+		log.Warnf("query pg_prepared_xacts failed: %s; skip", err)
+	} else {
+		prepared = parsePostgresPreparedXacts(res)
+	}
+
 	// get postmaster start time
 	var startTime float64
 	err = conn.Conn().QueryRow(context.Background(), postgresStartTimeQuery).Scan(&startTime)
@@ -222,6 +268,11 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 	// prepared transactions
 	ch <- c.prepared.newConstMetric(stats.prepared)
 
+	for _, p := range prepared {
+		ch <- c.preparedBy.newConstMetric(p.Count, p.Database, p.Owner)
+		ch <- c.preparedMaxAge.newConstMetric(p.MaxAge, p.Database, p.Owner)
+	}
+
 	// Longest activity by states, per user/database
 	for tag, values := range map[string]map[string]float64{
 		"idlexact/user":        stats.maxIdleUser,    // max duration of user's idle_xacts per user/database.
@@ -652,3 +703,28 @@ func selectActivityQuery(version int) string {
 		return postgresActivityQueryLatest
 	}
 }
+
+// postgresPreparedXacts describes prepared transactions of a single database and owner.
+type postgresPreparedXacts struct {
+	Database string  `column:"database"`
+	Owner    string  `column:"owner"`
+	Count    float64 `column:"count"`
+	MaxAge   float64 `column:"max_age_seconds"`
+}
+
+// parsePostgresPreparedXacts parses PGResult and returns structs with prepared transactions.
+func parsePostgresPreparedXacts(r *model.PGResult) []postgresPreparedXacts {
+	log.Debug("parse postgres prepared transactions")
+
+	stats := make([]postgresPreparedXacts, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresPreparedXacts{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
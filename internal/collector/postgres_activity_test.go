@@ -21,6 +21,10 @@ func TestPostgresActivityCollector_Update(t *testing.T) {
 			"postgres_activity_queries_in_flight",
 			"postgres_activity_vacuums_in_flight",
 		},
+		optional: []string{
+			"postgres_activity_prepared_transactions_by_owner_in_flight",
+			"postgres_activity_prepared_transactions_max_age_seconds",
+		},
 		collector: NewPostgresActivityCollector,
 		service:   model.ServiceTypePostgresql,
 	}
@@ -456,3 +460,22 @@ func Test_updateQueryStat(t *testing.T) {
 		re:          testRE,
 	}, s)
 }
+
+func Test_parsePostgresPreparedXacts(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("owner")}, {Name: []byte("count")}, {Name: []byte("max_age_seconds")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "testdb", Valid: true}, {String: "app", Valid: true}, {String: "2", Valid: true}, {String: "3600.5", Valid: true}},
+			{{String: "testdb", Valid: true}, {String: "postgres", Valid: true}, {String: "1", Valid: true}, {String: "10", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []postgresPreparedXacts{
+		{Database: "testdb", Owner: "app", Count: 2, MaxAge: 3600.5},
+		{Database: "testdb", Owner: "postgres", Count: 1, MaxAge: 10},
+	}, parsePostgresPreparedXacts(res))
+}
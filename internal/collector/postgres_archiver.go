@@ -10,13 +10,15 @@ import (
 
 const walArchivingQuery = "SELECT archived_count, failed_count, " +
 	"extract(epoch from now() - last_archived_time) AS since_last_archive_seconds, " +
+	"extract(epoch from now() - last_failed_time) AS since_last_failure_seconds, " +
 	"(SELECT count(*) FROM pg_ls_archive_statusdir() WHERE name ~'.ready') AS lag_files " +
-	"FROM pg_stat_archiver WHERE archived_count > 0"
+	"FROM pg_stat_archiver WHERE archived_count > 0 OR failed_count > 0"
 
 type postgresWalArchivingCollector struct {
 	archived             typedDesc
 	failed               typedDesc
 	sinceArchivedSeconds typedDesc
+	sinceFailedSeconds   typedDesc
 	archivingLag         typedDesc
 }
 
@@ -42,6 +44,12 @@ func NewPostgresWalArchivingCollector(constLabels labels, settings model.Collect
 			nil, constLabels,
 			settings.Filters,
 		),
+		sinceFailedSeconds: newBuiltinTypedDesc(
+			descOpts{"postgres", "archiver", "since_last_failure_seconds", "Number of seconds since the last failed attempt to archive WAL segment.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 		archivingLag: newBuiltinTypedDesc(
 			descOpts{"postgres", "archiver", "lag_bytes", "Amount of WAL segments ready, but not archived, in bytes.", 0},
 			prometheus.GaugeValue,
@@ -51,6 +59,17 @@ func NewPostgresWalArchivingCollector(constLabels labels, settings model.Collect
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresWalArchivingCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.archived,
+		c.failed,
+		c.sinceArchivedSeconds,
+		c.sinceFailedSeconds,
+		c.archivingLag,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresWalArchivingCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -71,14 +90,25 @@ func (c *postgresWalArchivingCollector) Update(config Config, ch chan<- promethe
 
 	stats := parsePostgresWalArchivingStats(res)
 
-	if stats.archived == 0 {
-		log.Debugln("zero archived WAL segments, skip collecting archiver stats")
+	// Archiving is not used, WAL segments have never been archived or attempted to archive.
+	if stats.archived == 0 && stats.failed == 0 {
+		log.Debugln("zero archived and failed WAL segments, skip collecting archiver stats")
 		return nil
 	}
 
 	ch <- c.archived.newConstMetric(stats.archived)
 	ch <- c.failed.newConstMetric(stats.failed)
-	ch <- c.sinceArchivedSeconds.newConstMetric(stats.sinceArchivedSeconds)
+
+	// Time since the last archived segment is known only if any segment has been archived.
+	if stats.archived > 0 {
+		ch <- c.sinceArchivedSeconds.newConstMetric(stats.sinceArchivedSeconds)
+	}
+
+	// Time since the last failure is known only if archiving has failed at least once.
+	if stats.failed > 0 {
+		ch <- c.sinceFailedSeconds.newConstMetric(stats.sinceFailedSeconds)
+	}
+
 	ch <- c.archivingLag.newConstMetric(stats.lagFiles * float64(config.walSegmentSize))
 
 	return nil
@@ -89,6 +119,7 @@ type postgresWalArchivingStat struct {
 	archived             float64
 	failed               float64
 	sinceArchivedSeconds float64
+	sinceFailedSeconds   float64
 	lagFiles             float64
 }
 
@@ -121,6 +152,8 @@ func parsePostgresWalArchivingStats(r *model.PGResult) postgresWalArchivingStat
 				stats.failed = v
 			case "since_last_archive_seconds":
 				stats.sinceArchivedSeconds = v
+			case "since_last_failure_seconds":
+				stats.sinceFailedSeconds = v
 			case "lag_files":
 				stats.lagFiles = v
 			default:
@@ -15,6 +15,7 @@ func TestPostgresWalArchivingCollector_Update(t *testing.T) {
 			"postgres_archiver_archived_total",
 			"postgres_archiver_failed_total",
 			"postgres_archiver_since_last_archive_seconds",
+			"postgres_archiver_since_last_failure_seconds",
 			"postgres_archiver_lag_bytes",
 		},
 		collector: NewPostgresWalArchivingCollector,
@@ -34,20 +35,59 @@ func Test_parsePostgresWalArchivingStats(t *testing.T) {
 			name: "normal output",
 			res: &model.PGResult{
 				Nrows: 1,
-				Ncols: 4,
+				Ncols: 5,
 				Colnames: []pgproto3.FieldDescription{
 					{Name: []byte("archived_count")}, {Name: []byte("failed_count")},
-					{Name: []byte("since_last_archive_seconds")}, {Name: []byte("lag_files")},
+					{Name: []byte("since_last_archive_seconds")}, {Name: []byte("since_last_failure_seconds")},
+					{Name: []byte("lag_files")},
 				},
 				Rows: [][]sql.NullString{
 					{
 						{String: "4587", Valid: true}, {String: "0", Valid: true},
-						{String: "17", Valid: true}, {String: "159", Valid: true},
+						{String: "17", Valid: true}, {String: "", Valid: false}, {String: "159", Valid: true},
 					},
 				},
 			},
 			want: postgresWalArchivingStat{archived: 4587, failed: 0, sinceArchivedSeconds: 17, lagFiles: 159},
 		},
+		{
+			name: "failed archiving",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 5,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("archived_count")}, {Name: []byte("failed_count")},
+					{Name: []byte("since_last_archive_seconds")}, {Name: []byte("since_last_failure_seconds")},
+					{Name: []byte("lag_files")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "4587", Valid: true}, {String: "3", Valid: true},
+						{String: "17", Valid: true}, {String: "5", Valid: true}, {String: "2", Valid: true},
+					},
+				},
+			},
+			want: postgresWalArchivingStat{archived: 4587, failed: 3, sinceArchivedSeconds: 17, sinceFailedSeconds: 5, lagFiles: 2},
+		},
+		{
+			name: "never archived",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 5,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("archived_count")}, {Name: []byte("failed_count")},
+					{Name: []byte("since_last_archive_seconds")}, {Name: []byte("since_last_failure_seconds")},
+					{Name: []byte("lag_files")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "0", Valid: true}, {String: "12", Valid: true},
+						{String: "", Valid: false}, {String: "5", Valid: true}, {String: "8", Valid: true},
+					},
+				},
+			},
+			want: postgresWalArchivingStat{archived: 0, failed: 12, sinceFailedSeconds: 5, lagFiles: 8},
+		},
 		{
 			name: "no rows output",
 			res: &model.PGResult{
@@ -84,6 +84,15 @@ func NewPostgresBgwriterCollector(constLabels labels, settings model.CollectorSe
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresBgwriterCollector) descriptors() []typedDesc {
+	descs := make([]typedDesc, 0, len(c.descs))
+	for _, d := range c.descs {
+		descs = append(descs, d)
+	}
+	return descs
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresBgwriterCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
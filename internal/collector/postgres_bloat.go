@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// tablesBloatQuery defines query for estimating bloat of tables, based on the widely used estimation query from
+	// https://github.com/ioguix/pgsql-bloat-estimation. Estimation relies on planner statistics, tables which have
+	// never been analyzed or have columns without statistics are skipped. The query is used as a format string, hence
+	// modulo operators are escaped.
+	tablesBloatQuery = "SELECT database, schema, \"table\", bloat_bytes, bloat_ratio FROM (" +
+		"SELECT current_database() AS database, schemaname AS schema, tblname AS \"table\", " +
+		"CASE WHEN tblpages > est_tblpages_ff THEN (tblpages - est_tblpages_ff) * bs ELSE 0 END AS bloat_bytes, " +
+		"CASE WHEN tblpages > est_tblpages_ff THEN (tblpages - est_tblpages_ff) / tblpages::float ELSE 0 END AS bloat_ratio " +
+		"FROM (" +
+		"SELECT ceil(reltuples / ((bs - page_hdr) * fillfactor / (tpl_size * 100))) + ceil(toasttuples / 4) AS est_tblpages_ff, " +
+		"tblpages, bs, schemaname, tblname, is_na " +
+		"FROM (" +
+		"SELECT (4 + tpl_hdr_size + tpl_data_size + (2 * ma) " +
+		"- CASE WHEN tpl_hdr_size %% ma = 0 THEN ma ELSE tpl_hdr_size %% ma END " +
+		"- CASE WHEN ceil(tpl_data_size)::int %% ma = 0 THEN ma ELSE ceil(tpl_data_size)::int %% ma END) AS tpl_size, " +
+		"(heappages + toastpages) AS tblpages, reltuples, toasttuples, bs, page_hdr, schemaname, tblname, fillfactor, is_na " +
+		"FROM (" +
+		"SELECT ns.nspname AS schemaname, tbl.relname AS tblname, tbl.reltuples, tbl.relpages AS heappages, " +
+		"coalesce(toast.relpages, 0) AS toastpages, coalesce(toast.reltuples, 0) AS toasttuples, " +
+		"coalesce(substring(array_to_string(tbl.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 100) AS fillfactor, " +
+		"current_setting('block_size')::numeric AS bs, " +
+		"CASE WHEN version() ~ 'mingw32' OR version() ~ '64-bit|x86_64|ppc64|ia64|amd64' THEN 8 ELSE 4 END AS ma, " +
+		"24 AS page_hdr, " +
+		"23 + CASE WHEN max(coalesce(s.null_frac, 0)) > 0 THEN (7 + count(s.attname)) / 8 ELSE 0::int END AS tpl_hdr_size, " +
+		"sum((1 - coalesce(s.null_frac, 0)) * coalesce(s.avg_width, 0)) AS tpl_data_size, " +
+		"bool_or(att.atttypid = 'pg_catalog.name'::regtype) " +
+		"OR sum(CASE WHEN att.attnum > 0 THEN 1 ELSE 0 END) <> count(s.attname) AS is_na " +
+		"FROM pg_attribute att " +
+		"JOIN pg_class tbl ON att.attrelid = tbl.oid " +
+		"JOIN pg_namespace ns ON ns.oid = tbl.relnamespace " +
+		"LEFT JOIN pg_stats s ON s.schemaname = ns.nspname AND s.tablename = tbl.relname AND NOT s.inherited AND s.attname = att.attname " +
+		"LEFT JOIN pg_class toast ON tbl.reltoastrelid = toast.oid " +
+		"WHERE NOT att.attisdropped AND att.attnum > 0 AND tbl.relkind IN ('r', 'm') " +
+		"AND ns.nspname NOT IN ('pg_catalog', 'information_schema') " +
+		"GROUP BY 1, 2, 3, 4, 5, 6, 7, 8, 9, 10" +
+		") AS s" +
+		") AS s2" +
+		") AS s3 WHERE NOT is_na" +
+		") AS bloat WHERE bloat_bytes > 0 AND bloat_bytes >= %d ORDER BY bloat_bytes DESC LIMIT %d"
+
+	// defaultBloatTablesLimit defines default number of the most bloated tables reported per database.
+	defaultBloatTablesLimit = 10
+)
+
+// postgresBloatCollector defines metric descriptors of the most bloated tables.
+type postgresBloatCollector struct {
+	bytes     typedDesc
+	ratio     typedDesc
+	filters   filter.Filters
+	limit     int
+	threshold int64
+}
+
+// NewPostgresBloatCollector returns a new Collector exposing estimated bloat of the most bloated tables of every
+// database. Number of tables is limited by 'rows_limit' setting, tables with bloat smaller than 'size_threshold' are
+// not reported.
+// For details see https://github.com/ioguix/pgsql-bloat-estimation
+func NewPostgresBloatCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	limit := settings.RowsLimit
+	if limit == 0 {
+		limit = defaultBloatTablesLimit
+	}
+
+	return &postgresBloatCollector{
+		filters:   settings.Filters,
+		limit:     limit,
+		threshold: settings.SizeThreshold,
+		bytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "bloat_bytes", "Estimated amount of bloat of the table, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table"}, constLabels,
+			settings.Filters,
+		),
+		ratio: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "bloat_ratio", "Estimated ratio of bloat to the table size.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresBloatCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.bytes,
+		c.ratio,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresBloatCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
+	if err != nil {
+		return err
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if it is rejected by collector's filters, avoid connecting to it.
+		if !c.filters.Pass("database", d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfig(pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.Query(fmt.Sprintf(tablesBloatQuery, c.threshold, c.limit))
+		conn.Close()
+		if err != nil {
+			log.Warnf("get tables bloat of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresBloatStats(res) {
+			ch <- c.bytes.newConstMetric(stat.Bytes, stat.Database, stat.Schema, stat.Table)
+			ch <- c.ratio.newConstMetric(stat.Ratio, stat.Database, stat.Schema, stat.Table)
+		}
+	}
+
+	return nil
+}
+
+// postgresBloatStat represents estimated bloat of a single table.
+type postgresBloatStat struct {
+	Database string  `column:"database"`
+	Schema   string  `column:"schema"`
+	Table    string  `column:"table"`
+	Bytes    float64 `column:"bloat_bytes"`
+	Ratio    float64 `column:"bloat_ratio"`
+}
+
+// parsePostgresBloatStats parses PGResult and returns structs with tables bloat.
+func parsePostgresBloatStats(r *model.PGResult) []postgresBloatStat {
+	log.Debug("parse postgres tables bloat")
+
+	stats := make([]postgresBloatStat, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresBloatStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
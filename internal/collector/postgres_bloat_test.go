@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestPostgresBloatCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_table_bloat_bytes",
+			"postgres_table_bloat_ratio",
+		},
+		collector: NewPostgresBloatCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresBloatStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 5,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("table")},
+			{Name: []byte("bloat_bytes")}, {Name: []byte("bloat_ratio")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "orders", Valid: true},
+				{String: "819200", Valid: true}, {String: "0.5", Valid: true},
+			},
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "clients", Valid: true},
+				{String: "8192", Valid: true}, {String: "0.1", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresBloatStat{
+		{Database: "testdb", Schema: "public", Table: "orders", Bytes: 819200, Ratio: 0.5},
+		{Database: "testdb", Schema: "public", Table: "clients", Bytes: 8192, Ratio: 0.1},
+	}, parsePostgresBloatStats(res))
+}
+
+func Test_tablesBloatQuery(t *testing.T) {
+	query := fmt.Sprintf(tablesBloatQuery, 1024, 10)
+
+	assert.NotContains(t, query, "%!")
+	assert.Contains(t, query, "tpl_hdr_size % ma")
+	assert.Contains(t, query, "ceil(tpl_data_size)::int % ma")
+	assert.True(t, strings.HasSuffix(query, "bloat_bytes >= 1024 ORDER BY bloat_bytes DESC LIMIT 10"))
+}
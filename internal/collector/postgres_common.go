@@ -2,11 +2,16 @@ package collector
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jackc/pgproto3/v2"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/lesovsky/pgscv/internal/store"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
@@ -18,6 +23,7 @@ const (
 	PostgresV12 = 120000
 	PostgresV13 = 130000
 	PostgresV14 = 140000
+	PostgresV15 = 150000
 
 	// Minimal required version is 9.5.
 	PostgresVMinNum = PostgresV95
@@ -142,10 +148,70 @@ func parsePostgresCustomStats(r *model.PGResult, labelNames []string) postgresCu
 	return stats
 }
 
+// listServiceDatabases connects to the service and returns names of databases which are allowed to be visited by
+// builtin collectors.
+func listServiceDatabases(config Config) ([]string, error) {
+	return queryServiceDatabases(config.ConnString, func(db *store.DB) ([]string, error) {
+		return listAllowedDatabases(db, config)
+	})
+}
+
+// queryServiceDatabases connects to the service and returns names of databases listed by passed function. Connection
+// is closed before return in any case, hence collectors don't occupy connection slots while visiting databases.
+func queryServiceDatabases(connStr string, list func(db *store.DB) ([]string, error)) ([]string, error) {
+	conn, err := store.New(connStr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return list(conn)
+}
+
 // listDatabases returns slice with databases names
 func listDatabases(db *store.DB) ([]string, error) {
-	// getDBList returns the list of databases that allowed for connection
-	rows, err := db.Conn().Query(context.Background(), "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn")
+	return queryDatabases(db, "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn")
+}
+
+// listAllowedDatabases returns names of databases which are allowed to be visited by builtin collectors. Databases
+// not matched to 'databases', matched to 'exclude_databases' or smaller than 'databases_min_size' are skipped.
+func listAllowedDatabases(db *store.DB, config Config) ([]string, error) {
+	query := "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn"
+	if config.DatabasesMinSize > 0 {
+		query += fmt.Sprintf(" AND pg_database_size(oid) >= %d", config.DatabasesMinSize)
+	}
+
+	databases, err := queryDatabases(db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var list = make([]string, 0, len(databases))
+	for _, d := range databases {
+		if isDatabaseAllowed(config, d) {
+			list = append(list, d)
+		}
+	}
+
+	return list, nil
+}
+
+// isDatabaseAllowed returns true if database is matched to 'databases' and not matched to 'exclude_databases'.
+func isDatabaseAllowed(config Config, database string) bool {
+	if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(database) {
+		return false
+	}
+
+	if config.ExcludeDatabasesRE != nil && config.ExcludeDatabasesRE.MatchString(database) {
+		return false
+	}
+
+	return true
+}
+
+// queryDatabases executes passed query and returns slice with databases names
+func queryDatabases(db *store.DB, query string) ([]string, error) {
+	rows, err := db.Conn().Query(context.Background(), query)
 	if err != nil {
 		return nil, err
 	}
@@ -161,3 +227,80 @@ func listDatabases(db *store.DB) ([]string, error) {
 	}
 	return list, nil
 }
+
+// nullFloat64Type defines type of fields which keep NULL values of columns.
+var nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+
+// columnFields caches mapping of columns names to struct fields indexes, per struct type.
+var columnFields sync.Map
+
+// scanRow fills exported fields of the struct pointed by dst using values of the row. Fields are matched to columns
+// using 'column' tag, columns without matched fields are ignored. String fields receive values as-is, float64 fields
+// receive parsed values, NULL values leave float64 fields zero. Columns which could be NULL should be scanned into
+// sql.NullFloat64 fields, hence NULL values could be handled according to collector's 'null_values' policy. Returns
+// error if value could not be parsed, the row should be skipped then.
+func scanRow(colnames []pgproto3.FieldDescription, row []sql.NullString, dst interface{}) error {
+	v := reflect.ValueOf(dst).Elem()
+	fields := structColumnFields(v.Type())
+
+	for i, colname := range colnames {
+		idx, ok := fields[string(colname.Name)]
+		if !ok {
+			continue
+		}
+
+		f := v.Field(idx)
+		if !f.CanSet() {
+			return fmt.Errorf("field %s of %s is not settable", v.Type().Field(idx).Name, v.Type())
+		}
+
+		if f.Type() == nullFloat64Type {
+			if !row[i].Valid {
+				f.Set(reflect.ValueOf(sql.NullFloat64{}))
+				continue
+			}
+
+			value, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				return fmt.Errorf("parse '%s' of column %s failed: %s", row[i].String, colname.Name, err)
+			}
+			f.Set(reflect.ValueOf(sql.NullFloat64{Float64: value, Valid: true}))
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(row[i].String)
+		case reflect.Float64:
+			if !row[i].Valid {
+				continue
+			}
+
+			// Get data value and convert it to float64 used by Prometheus.
+			value, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				return fmt.Errorf("parse '%s' of column %s failed: %s", row[i].String, colname.Name, err)
+			}
+			f.SetFloat(value)
+		}
+	}
+
+	return nil
+}
+
+// structColumnFields returns mapping of columns names to fields indexes of passed struct type.
+func structColumnFields(t reflect.Type) map[string]int {
+	if fields, ok := columnFields.Load(t); ok {
+		return fields.(map[string]int)
+	}
+
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if column, ok := t.Field(i).Tag.Lookup("column"); ok {
+			fields[column] = i
+		}
+	}
+
+	columnFields.Store(t, fields)
+	return fields
+}
@@ -2,10 +2,12 @@ package collector
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/lesovsky/pgscv/internal/store"
 	"github.com/stretchr/testify/assert"
+	"regexp"
 	"testing"
 )
 
@@ -110,3 +112,102 @@ func Test_listDatabases(t *testing.T) {
 	assert.Greater(t, len(databases), 0)
 	conn.Close()
 }
+
+func Test_listAllowedDatabases(t *testing.T) {
+	conn := store.NewTest(t)
+
+	databases, err := listAllowedDatabases(conn, Config{ExcludeDatabasesRE: regexp.MustCompile("^postgres$")})
+	assert.NoError(t, err)
+	assert.Greater(t, len(databases), 0)
+	assert.NotContains(t, databases, "postgres")
+
+	databases, err = listAllowedDatabases(conn, Config{DatabasesMinSize: 1 << 50})
+	assert.NoError(t, err)
+	assert.Len(t, databases, 0)
+	conn.Close()
+}
+
+func Test_queryServiceDatabases(t *testing.T) {
+	store.SetMaxConnections(1)
+	defer store.SetMaxConnections(0)
+
+	databases, err := queryServiceDatabases(store.TestPostgresConnStr, listDatabases)
+	assert.NoError(t, err)
+	assert.Greater(t, len(databases), 0)
+
+	// Connection is closed when listing failed, the only connection slot is available again.
+	_, err = queryServiceDatabases(store.TestPostgresConnStr, func(_ *store.DB) ([]string, error) {
+		return nil, fmt.Errorf("failed")
+	})
+	assert.Error(t, err)
+
+	databases, err = listServiceDatabases(Config{ConnString: store.TestPostgresConnStr})
+	assert.NoError(t, err)
+	assert.Greater(t, len(databases), 0)
+}
+
+func Test_isDatabaseAllowed(t *testing.T) {
+	testcases := []struct {
+		config   Config
+		database string
+		want     bool
+	}{
+		{config: Config{}, database: "example", want: true},
+		{config: Config{DatabasesRE: regexp.MustCompile("^ex")}, database: "example", want: true},
+		{config: Config{DatabasesRE: regexp.MustCompile("^ex")}, database: "test", want: false},
+		{config: Config{ExcludeDatabasesRE: regexp.MustCompile("^tenant_")}, database: "tenant_1", want: false},
+		{config: Config{ExcludeDatabasesRE: regexp.MustCompile("^tenant_")}, database: "example", want: true},
+		{
+			config:   Config{DatabasesRE: regexp.MustCompile(".+"), ExcludeDatabasesRE: regexp.MustCompile("^tenant_")},
+			database: "tenant_1", want: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, isDatabaseAllowed(tc.config, tc.database))
+	}
+}
+
+func Test_scanRow(t *testing.T) {
+	type example struct {
+		Name    string  `column:"name"`
+		Value   float64 `column:"value"`
+		Missing float64 `column:"missing"`
+		Other   float64
+	}
+
+	colnames := []pgproto3.FieldDescription{
+		{Name: []byte("name")}, {Name: []byte("value")}, {Name: []byte("missing")}, {Name: []byte("unknown")},
+	}
+
+	var got example
+	assert.NoError(t, scanRow(colnames, []sql.NullString{
+		{String: "example", Valid: true}, {String: "10.5", Valid: true}, {}, {String: "1", Valid: true},
+	}, &got))
+	assert.Equal(t, example{Name: "example", Value: 10.5}, got)
+
+	// Invalid values are reported.
+	got = example{}
+	assert.Error(t, scanRow(colnames, []sql.NullString{
+		{String: "example", Valid: true}, {String: "invalid", Valid: true}, {String: "1", Valid: true}, {},
+	}, &got))
+
+	// Unexported fields could not be filled.
+	type unexported struct {
+		name string `column:"name"`
+	}
+
+	assert.Error(t, scanRow(colnames[:1], []sql.NullString{{String: "example", Valid: true}}, &unexported{}))
+
+	// NULL values are kept in nullable fields.
+	type nullable struct {
+		Value   sql.NullFloat64 `column:"value"`
+		Missing sql.NullFloat64 `column:"missing"`
+	}
+
+	var gotNullable nullable
+	assert.NoError(t, scanRow(colnames, []sql.NullString{
+		{String: "example", Valid: true}, {String: "10.5", Valid: true}, {}, {},
+	}, &gotNullable))
+	assert.Equal(t, nullable{Value: sql.NullFloat64{Float64: 10.5, Valid: true}}, gotNullable)
+}
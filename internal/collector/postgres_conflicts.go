@@ -29,6 +29,13 @@ func NewPostgresConflictsCollector(constLabels labels, settings model.CollectorS
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresConflictsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.conflicts,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresConflictsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -12,10 +12,19 @@ type postgresCustomCollector struct {
 // NewPostgresCustomCollector returns a new Collector that expose user-defined postgres metrics.
 func NewPostgresCustomCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	return &postgresCustomCollector{
-		custom: newDeskSetsFromSubsystems("postgres", settings.Subsystems, constLabels),
+		custom: newDeskSetsFromSubsystems("postgres", settings.Subsystems, constLabels, settings.NullValues),
 	}, nil
 }
 
+// descriptors returns descriptors of user-defined metrics.
+func (c *postgresCustomCollector) descriptors() []typedDesc {
+	var descs []typedDesc
+	for _, set := range c.custom {
+		descs = append(descs, set.descs...)
+	}
+	return descs
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresCustomCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	return updateAllDescSets(config, c.custom, ch)
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"database/sql"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/lesovsky/pgscv/internal/store"
@@ -9,10 +10,15 @@ import (
 )
 
 const (
+	// databasesIOTimingColumns defines blocks access time columns, they are NULL when track_io_timing is disabled
+	// and time is not tracked.
+	databasesIOTimingColumns = "CASE WHEN current_setting('track_io_timing')::bool THEN blk_read_time END AS blk_read_time, " +
+		"CASE WHEN current_setting('track_io_timing')::bool THEN blk_write_time END AS blk_write_time, "
+
 	databasesQuery11 = "SELECT " +
 		"coalesce(datname, 'global') AS database, " +
 		"xact_commit, xact_rollback, blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, " +
-		"conflicts, temp_files, temp_bytes, deadlocks, blk_read_time, blk_write_time, pg_database_size(datname) as size_bytes, " +
+		"conflicts, temp_files, temp_bytes, deadlocks, " + databasesIOTimingColumns + "pg_database_size(datname) as size_bytes, " +
 		"coalesce(extract('epoch' from age(now(), stats_reset)), 0) as stats_age_seconds " +
 		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
 		"OR datname IS NULL"
@@ -21,7 +27,7 @@ const (
 		"coalesce(datname, 'global') AS database, " +
 		"xact_commit, xact_rollback, blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, " +
 		"conflicts, temp_files, temp_bytes, deadlocks, checksum_failures, coalesce(extract(epoch from checksum_last_failure), 0) AS last_checksum_failure_unixtime, " +
-		"blk_read_time, blk_write_time, pg_database_size(datname) as size_bytes, " +
+		databasesIOTimingColumns + "pg_database_size(datname) as size_bytes, " +
 		"coalesce(extract('epoch' from age(now(), stats_reset)), 0) as stats_age_seconds " +
 		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
 		"OR datname IS NULL"
@@ -30,7 +36,7 @@ const (
 		"coalesce(datname, 'global') AS database, " +
 		"xact_commit, xact_rollback, blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, " +
 		"conflicts, temp_files, temp_bytes, deadlocks, checksum_failures, coalesce(extract(epoch from checksum_last_failure), 0) AS last_checksum_failure_unixtime, " +
-		"blk_read_time, blk_write_time, " +
+		databasesIOTimingColumns +
 		"session_time, active_time, idle_in_transaction_time, sessions, sessions_abandoned, sessions_fatal, sessions_killed, " +
 		"pg_database_size(datname) as size_bytes, " +
 		"coalesce(extract('epoch' from age(now(), stats_reset)), 0) as stats_age_seconds " +
@@ -73,6 +79,12 @@ type postgresDatabasesCollector struct {
 func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	var labels = []string{"database"}
 
+	// NULL values of databases stats have been always sent as zero, keep it unless policy is configured explicitly.
+	nullValues := settings.NullValues
+	if nullValues == "" {
+		nullValues = model.NullValuesZero
+	}
+
 	return &postgresDatabasesCollector{
 		labelNames: labels,
 		commits: newBuiltinTypedDesc(
@@ -152,10 +164,10 @@ func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorS
 			prometheus.CounterValue,
 			labels, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(nullValues, constLabels),
 		csumlastfailunixts: newBuiltinTypedDesc(
 			descOpts{"postgres", "database", "last_checksum_failure_seconds", "Time of the last checksum failure occurred, in unixtime.", 0},
-			prometheus.CounterValue,
+			prometheus.GaugeValue,
 			labels, constLabels,
 			settings.Filters,
 		),
@@ -164,7 +176,7 @@ func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorS
 			prometheus.CounterValue,
 			[]string{"database", "type"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(nullValues, constLabels),
 		sessionalltime: newBuiltinTypedDesc(
 			descOpts{"postgres", "database", "session_time_seconds_all_total", "Total time spent by database sessions in this database in all states, in seconds", .001},
 			prometheus.CounterValue,
@@ -194,7 +206,7 @@ func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorS
 			prometheus.GaugeValue,
 			labels, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(nullValues, constLabels),
 		statsage: newBuiltinTypedDesc(
 			descOpts{"postgres", "database", "stats_age_seconds_total", "The age of the databases activity statistics, in seconds.", 0},
 			prometheus.CounterValue,
@@ -203,13 +215,41 @@ func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorS
 		),
 		xidlimit: newBuiltinTypedDesc(
 			descOpts{"postgres", "xacts", "left_before_wraparound", "The number of transactions left before force shutdown due to XID wraparound.", 0},
-			prometheus.CounterValue,
+			prometheus.GaugeValue,
 			[]string{"xid_from"}, constLabels,
 			settings.Filters,
 		),
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresDatabasesCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.commits,
+		c.rollbacks,
+		c.blocks,
+		c.tuplesReturned,
+		c.tuplesFetched,
+		c.tuplesInserted,
+		c.tuplesUpdated,
+		c.tuplesDeleted,
+		c.tempbytes,
+		c.tempfiles,
+		c.conflicts,
+		c.deadlocks,
+		c.csumfails,
+		c.csumlastfailunixts,
+		c.blockstime,
+		c.sessionalltime,
+		c.sessiontime,
+		c.sessionsall,
+		c.sessions,
+		c.sizes,
+		c.statsage,
+		c.xidlimit,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -218,56 +258,51 @@ func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.
 	}
 	defer conn.Close()
 
-	res, err := conn.Query(selectDatabasesQuery(config.serverVersionNum))
-	if err != nil {
-		return err
-	}
-
-	stats := parsePostgresDatabasesStats(res, c.labelNames)
-
-	res, err = conn.Query(xidLimitQuery)
+	// Databases stats and XID limits are requested in a single round trip.
+	res, err := conn.QueryBatch(selectDatabasesQuery(config.serverVersionNum), xidLimitQuery)
 	if err != nil {
 		return err
 	}
 
-	xidStats := parsePostgresXidLimitStats(res)
+	stats := parsePostgresDatabasesStats(res[0])
+	xidStats := parsePostgresXidLimitStats(res[1])
 
 	for _, stat := range stats {
-		ch <- c.commits.newConstMetric(stat.xactcommit, stat.database)
-		ch <- c.rollbacks.newConstMetric(stat.xactrollback, stat.database)
-		ch <- c.blocks.newConstMetric(stat.blksread, stat.database, "read")
-		ch <- c.blocks.newConstMetric(stat.blkshit, stat.database, "hit")
-		ch <- c.tuplesReturned.newConstMetric(stat.tupreturned, stat.database)
-		ch <- c.tuplesFetched.newConstMetric(stat.tupfetched, stat.database)
-		ch <- c.tuplesInserted.newConstMetric(stat.tupinserted, stat.database)
-		ch <- c.tuplesUpdated.newConstMetric(stat.tupupdated, stat.database)
-		ch <- c.tuplesDeleted.newConstMetric(stat.tupdeleted, stat.database)
-
-		ch <- c.tempbytes.newConstMetric(stat.tempbytes, stat.database)
-		ch <- c.tempfiles.newConstMetric(stat.tempfiles, stat.database)
-		ch <- c.conflicts.newConstMetric(stat.conflicts, stat.database)
-		ch <- c.deadlocks.newConstMetric(stat.deadlocks, stat.database)
-
-		ch <- c.blockstime.newConstMetric(stat.blkreadtime, stat.database, "read")
-		ch <- c.blockstime.newConstMetric(stat.blkwritetime, stat.database, "write")
-		ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database)
-		ch <- c.statsage.newConstMetric(stat.statsage, stat.database)
+		ch <- c.commits.newConstMetric(stat.Xactcommit, stat.Database)
+		ch <- c.rollbacks.newConstMetric(stat.Xactrollback, stat.Database)
+		ch <- c.blocks.newConstMetric(stat.Blksread, stat.Database, "read")
+		ch <- c.blocks.newConstMetric(stat.Blkshit, stat.Database, "hit")
+		ch <- c.tuplesReturned.newConstMetric(stat.Tupreturned, stat.Database)
+		ch <- c.tuplesFetched.newConstMetric(stat.Tupfetched, stat.Database)
+		ch <- c.tuplesInserted.newConstMetric(stat.Tupinserted, stat.Database)
+		ch <- c.tuplesUpdated.newConstMetric(stat.Tupupdated, stat.Database)
+		ch <- c.tuplesDeleted.newConstMetric(stat.Tupdeleted, stat.Database)
+
+		ch <- c.tempbytes.newConstMetric(stat.Tempbytes, stat.Database)
+		ch <- c.tempfiles.newConstMetric(stat.Tempfiles, stat.Database)
+		ch <- c.conflicts.newConstMetric(stat.Conflicts, stat.Database)
+		ch <- c.deadlocks.newConstMetric(stat.Deadlocks, stat.Database)
+
+		c.blockstime.sendNullable(ch, stat.Blkreadtime.Float64, stat.Blkreadtime.Valid, stat.Database, "read")
+		c.blockstime.sendNullable(ch, stat.Blkwritetime.Float64, stat.Blkwritetime.Valid, stat.Database, "write")
+		c.sizes.sendNullable(ch, stat.Sizebytes.Float64, stat.Sizebytes.Valid, stat.Database)
+		ch <- c.statsage.newConstMetric(stat.Statsage, stat.Database)
 
 		if config.serverVersionNum >= PostgresV12 {
-			ch <- c.csumfails.newConstMetric(stat.csumfails, stat.database)
-			ch <- c.csumlastfailunixts.newConstMetric(stat.csumlastfailunixts, stat.database)
+			c.csumfails.sendNullable(ch, stat.Csumfails.Float64, stat.Csumfails.Valid, stat.Database)
+			ch <- c.csumlastfailunixts.newConstMetric(stat.Csumlastfailunixts, stat.Database)
 		}
 
 		if config.serverVersionNum >= PostgresV14 {
-			ch <- c.sessionalltime.newConstMetric(stat.sessiontime, stat.database)
-			ch <- c.sessiontime.newConstMetric(stat.activetime, stat.database, "active")
-			ch <- c.sessiontime.newConstMetric(stat.idletxtime, stat.database, "idle_in_transaction")
-			ch <- c.sessiontime.newConstMetric(stat.sessiontime-(stat.activetime+stat.idletxtime), stat.database, "idle")
-			ch <- c.sessionsall.newConstMetric(stat.sessions, stat.database)
-			ch <- c.sessions.newConstMetric(stat.sessabandoned, stat.database, "abandoned")
-			ch <- c.sessions.newConstMetric(stat.sessfatal, stat.database, "fatal")
-			ch <- c.sessions.newConstMetric(stat.sesskilled, stat.database, "killed")
-			ch <- c.sessions.newConstMetric(stat.sessions-(stat.sessabandoned+stat.sessfatal+stat.sesskilled), stat.database, "normal")
+			ch <- c.sessionalltime.newConstMetric(stat.Sessiontime, stat.Database)
+			ch <- c.sessiontime.newConstMetric(stat.Activetime, stat.Database, "active")
+			ch <- c.sessiontime.newConstMetric(stat.Idletxtime, stat.Database, "idle_in_transaction")
+			ch <- c.sessiontime.newConstMetric(stat.Sessiontime-(stat.Activetime+stat.Idletxtime), stat.Database, "idle")
+			ch <- c.sessionsall.newConstMetric(stat.Sessions, stat.Database)
+			ch <- c.sessions.newConstMetric(stat.Sessabandoned, stat.Database, "abandoned")
+			ch <- c.sessions.newConstMetric(stat.Sessfatal, stat.Database, "fatal")
+			ch <- c.sessions.newConstMetric(stat.Sesskilled, stat.Database, "killed")
+			ch <- c.sessions.newConstMetric(stat.Sessions-(stat.Sessabandoned+stat.Sessfatal+stat.Sesskilled), stat.Database, "normal")
 		}
 	}
 
@@ -280,37 +315,37 @@ func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.
 
 // postgresDatabaseStat represents per-database stats based on pg_stat_database.
 type postgresDatabaseStat struct {
-	database           string
-	xactcommit         float64
-	xactrollback       float64
-	blksread           float64
-	blkshit            float64
-	tupreturned        float64
-	tupfetched         float64
-	tupinserted        float64
-	tupupdated         float64
-	tupdeleted         float64
-	conflicts          float64
-	tempfiles          float64
-	tempbytes          float64
-	deadlocks          float64
-	csumfails          float64
-	csumlastfailunixts float64
-	blkreadtime        float64
-	blkwritetime       float64
-	sessiontime        float64
-	activetime         float64
-	idletxtime         float64
-	sessions           float64
-	sessabandoned      float64
-	sessfatal          float64
-	sesskilled         float64
-	sizebytes          float64
-	statsage           float64
+	Database           string          `column:"database"`
+	Xactcommit         float64         `column:"xact_commit"`
+	Xactrollback       float64         `column:"xact_rollback"`
+	Blksread           float64         `column:"blks_read"`
+	Blkshit            float64         `column:"blks_hit"`
+	Tupreturned        float64         `column:"tup_returned"`
+	Tupfetched         float64         `column:"tup_fetched"`
+	Tupinserted        float64         `column:"tup_inserted"`
+	Tupupdated         float64         `column:"tup_updated"`
+	Tupdeleted         float64         `column:"tup_deleted"`
+	Conflicts          float64         `column:"conflicts"`
+	Tempfiles          float64         `column:"temp_files"`
+	Tempbytes          float64         `column:"temp_bytes"`
+	Deadlocks          float64         `column:"deadlocks"`
+	Csumfails          sql.NullFloat64 `column:"checksum_failures"`
+	Csumlastfailunixts float64         `column:"last_checksum_failure_unixtime"`
+	Blkreadtime        sql.NullFloat64 `column:"blk_read_time"`
+	Blkwritetime       sql.NullFloat64 `column:"blk_write_time"`
+	Sessiontime        float64         `column:"session_time"`
+	Activetime         float64         `column:"active_time"`
+	Idletxtime         float64         `column:"idle_in_transaction_time"`
+	Sessions           float64         `column:"sessions"`
+	Sessabandoned      float64         `column:"sessions_abandoned"`
+	Sessfatal          float64         `column:"sessions_fatal"`
+	Sesskilled         float64         `column:"sessions_killed"`
+	Sizebytes          sql.NullFloat64 `column:"size_bytes"`
+	Statsage           float64         `column:"stats_age_seconds"`
 }
 
 // parsePostgresDatabasesStats parses PGResult, extract data and return struct with stats values.
-func parsePostgresDatabasesStats(r *model.PGResult, labelNames []string) map[string]postgresDatabaseStat {
+func parsePostgresDatabasesStats(r *model.PGResult) map[string]postgresDatabaseStat {
 	log.Debug("parse postgres database stats")
 
 	var stats = make(map[string]postgresDatabaseStat)
@@ -318,102 +353,13 @@ func parsePostgresDatabasesStats(r *model.PGResult, labelNames []string) map[str
 	// process row by row
 	for _, row := range r.Rows {
 		stat := postgresDatabaseStat{}
-
-		// collect label values
-		for i, colname := range r.Colnames {
-			switch string(colname.Name) {
-			case "database":
-				stat.database = row[i].String
-			}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
 		}
 
 		// Define a map key as a database name.
-		databaseFQName := stat.database
-
-		// Put stats with labels (but with no data values yet) into stats store.
-		stats[databaseFQName] = stat
-
-		// fetch data values from columns
-		for i, colname := range r.Colnames {
-			// skip columns if its value used as a label
-			if stringsContains(labelNames, string(colname.Name)) {
-				continue
-			}
-
-			// Skip empty (NULL) values.
-			if !row[i].Valid {
-				continue
-			}
-
-			// Get data value and convert it to float64 used by Prometheus.
-			v, err := strconv.ParseFloat(row[i].String, 64)
-			if err != nil {
-				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
-				continue
-			}
-
-			s := stats[databaseFQName]
-			// Run column-specific logic
-			switch string(colname.Name) {
-			case "xact_commit":
-				s.xactcommit = v
-			case "xact_rollback":
-				s.xactrollback = v
-			case "blks_read":
-				s.blksread = v
-			case "blks_hit":
-				s.blkshit = v
-			case "tup_returned":
-				s.tupreturned = v
-			case "tup_fetched":
-				s.tupfetched = v
-			case "tup_inserted":
-				s.tupinserted = v
-			case "tup_updated":
-				s.tupupdated = v
-			case "tup_deleted":
-				s.tupdeleted = v
-			case "conflicts":
-				s.conflicts = v
-			case "temp_files":
-				s.tempfiles = v
-			case "temp_bytes":
-				s.tempbytes = v
-			case "deadlocks":
-				s.deadlocks = v
-			case "checksum_failures":
-				s.csumfails = v
-			case "last_checksum_failure_unixtime":
-				s.csumlastfailunixts = v
-			case "blk_read_time":
-				s.blkreadtime = v
-			case "blk_write_time":
-				s.blkwritetime = v
-			case "session_time":
-				s.sessiontime = v
-			case "active_time":
-				s.activetime = v
-			case "idle_in_transaction_time":
-				s.idletxtime = v
-			case "sessions":
-				s.sessions = v
-			case "sessions_abandoned":
-				s.sessabandoned = v
-			case "sessions_fatal":
-				s.sessfatal = v
-			case "sessions_killed":
-				s.sesskilled = v
-			case "size_bytes":
-				s.sizebytes = v
-			case "stats_age_seconds":
-				s.statsage = v
-			default:
-				continue
-			}
-
-			// Store updated stats into local store.
-			stats[databaseFQName] = s
-		}
+		stats[stat.Database] = stat
 	}
 
 	return stats
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -41,6 +42,29 @@ func TestPostgresDatabasesCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestNewPostgresDatabasesCollector_valueTypes(t *testing.T) {
+	c, err := NewPostgresDatabasesCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	// Values which could decrease are gauges.
+	dc := c.(*postgresDatabasesCollector)
+	assert.Equal(t, prometheus.GaugeValue, dc.xidlimit.valueType)
+	assert.Equal(t, prometheus.GaugeValue, dc.csumlastfailunixts.valueType)
+	assert.Equal(t, prometheus.CounterValue, dc.commits.valueType)
+}
+
+func TestNewPostgresDatabasesCollector_nullValues(t *testing.T) {
+	// NULL values are sent as zero by default.
+	c, err := NewPostgresDatabasesCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, model.NullValuesZero, c.(*postgresDatabasesCollector).blockstime.nullValues)
+
+	c, err = NewPostgresDatabasesCollector(labels{}, model.CollectorSettings{NullValues: model.NullValuesFlag})
+	assert.NoError(t, err)
+	assert.Equal(t, model.NullValuesFlag, c.(*postgresDatabasesCollector).blockstime.nullValues)
+	assert.NotNil(t, c.(*postgresDatabasesCollector).blockstime.isnull)
+}
+
 func Test_parsePostgresDatabasesStats(t *testing.T) {
 	var testCases = []struct {
 		name string
@@ -90,32 +114,49 @@ func Test_parsePostgresDatabasesStats(t *testing.T) {
 			},
 			want: map[string]postgresDatabaseStat{
 				"testdb1": {
-					database: "testdb1", xactcommit: 100, xactrollback: 5, blksread: 10000, blkshit: 845785,
-					tupreturned: 758, tupfetched: 542, tupinserted: 452, tupupdated: 174, tupdeleted: 125,
-					conflicts: 33, tempfiles: 41, tempbytes: 85642585, deadlocks: 25,
-					csumfails: 13, csumlastfailunixts: 1628668483,
-					blkreadtime: 542542, blkwritetime: 150150,
-					sessiontime: 12345678, activetime: 5425682, idletxtime: 125478,
-					sessions: 54872, sessabandoned: 458, sessfatal: 8942, sesskilled: 69,
-					sizebytes: 485254752, statsage: 4589,
+					Database: "testdb1", Xactcommit: 100, Xactrollback: 5, Blksread: 10000, Blkshit: 845785,
+					Tupreturned: 758, Tupfetched: 542, Tupinserted: 452, Tupupdated: 174, Tupdeleted: 125,
+					Conflicts: 33, Tempfiles: 41, Tempbytes: 85642585, Deadlocks: 25,
+					Csumfails: sql.NullFloat64{Float64: 13, Valid: true}, Csumlastfailunixts: 1628668483,
+					Blkreadtime: sql.NullFloat64{Float64: 542542, Valid: true}, Blkwritetime: sql.NullFloat64{Float64: 150150, Valid: true},
+					Sessiontime: 12345678, Activetime: 5425682, Idletxtime: 125478,
+					Sessions: 54872, Sessabandoned: 458, Sessfatal: 8942, Sesskilled: 69,
+					Sizebytes: sql.NullFloat64{Float64: 485254752, Valid: true}, Statsage: 4589,
 				},
 				"testdb2": {
-					database: "testdb2", xactcommit: 254, xactrollback: 41, blksread: 4853, blkshit: 48752,
-					tupreturned: 7856, tupfetched: 4254, tupinserted: 894, tupupdated: 175, tupdeleted: 245,
-					conflicts: 26, tempfiles: 84, tempbytes: 125784686, deadlocks: 11,
-					csumfails: 1, csumlastfailunixts: 54324565,
-					blkreadtime: 458751, blkwritetime: 235578,
-					sessiontime: 78541256, activetime: 8542214, idletxtime: 85475,
-					sessions: 854124, sessabandoned: 8874, sessfatal: 4114, sesskilled: 5477,
-					sizebytes: 856964774, statsage: 6896,
+					Database: "testdb2", Xactcommit: 254, Xactrollback: 41, Blksread: 4853, Blkshit: 48752,
+					Tupreturned: 7856, Tupfetched: 4254, Tupinserted: 894, Tupupdated: 175, Tupdeleted: 245,
+					Conflicts: 26, Tempfiles: 84, Tempbytes: 125784686, Deadlocks: 11,
+					Csumfails: sql.NullFloat64{Float64: 1, Valid: true}, Csumlastfailunixts: 54324565,
+					Blkreadtime: sql.NullFloat64{Float64: 458751, Valid: true}, Blkwritetime: sql.NullFloat64{Float64: 235578, Valid: true},
+					Sessiontime: 78541256, Activetime: 8542214, Idletxtime: 85475,
+					Sessions: 854124, Sessabandoned: 8874, Sessfatal: 4114, Sesskilled: 5477,
+					Sizebytes: sql.NullFloat64{Float64: 856964774, Valid: true}, Statsage: 6896,
+				},
+			},
+		},
+		{
+			name: "NULL values",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 6,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("database")}, {Name: []byte("xact_commit")}, {Name: []byte("checksum_failures")},
+					{Name: []byte("blk_read_time")}, {Name: []byte("blk_write_time")}, {Name: []byte("size_bytes")},
 				},
+				Rows: [][]sql.NullString{
+					{{String: "global", Valid: true}, {String: "100", Valid: true}, {}, {}, {}, {}},
+				},
+			},
+			want: map[string]postgresDatabaseStat{
+				"global": {Database: "global", Xactcommit: 100},
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := parsePostgresDatabasesStats(tc.res, []string{"database"})
+			got := parsePostgresDatabasesStats(tc.res)
 			assert.EqualValues(t, tc.want, got)
 		})
 	}
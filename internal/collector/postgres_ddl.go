@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// catalogChangesQuery defines query for querying number of rows changed in system catalogs which are modified by
+// DDL commands.
+const catalogChangesQuery = "SELECT current_database() AS database, " +
+	"coalesce(sum(n_tup_ins + n_tup_upd + n_tup_del), 0) AS changes " +
+	"FROM pg_stat_sys_tables WHERE schemaname = 'pg_catalog' AND relname IN ('pg_class', 'pg_proc', 'pg_namespace')"
+
+// postgresDDLCollector defines metric descriptors and state of catalog changes observed during previous updates.
+type postgresDDLCollector struct {
+	changes typedDesc
+	lastDDL typedDesc
+	filters filter.Filters
+	// previous defines number of catalog changes observed during the previous update, per database.
+	previous map[string]float64
+	// lastChange defines time when catalog changes have been detected the last time, per database.
+	lastChange map[string]time.Time
+}
+
+// NewPostgresDDLCollector returns a new Collector exposing DDL activity of every database. DDL commands are not
+// tracked directly, instead changes of pg_class, pg_proc and pg_namespace system catalogs are used. Creating
+// temporary tables is accounted as DDL too. Time of the last DDL is the time of the update which detected new changes.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-ALL-TABLES-VIEW
+func NewPostgresDDLCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresDDLCollector{
+		filters:    settings.Filters,
+		previous:   map[string]float64{},
+		lastChange: map[string]time.Time{},
+		changes: newBuiltinTypedDesc(
+			descOpts{"postgres", "ddl", "catalog_changes_total", "Total number of rows inserted, updated or deleted in system catalogs by DDL commands.", 0},
+			prometheus.CounterValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		lastDDL: newBuiltinTypedDesc(
+			descOpts{"postgres", "ddl", "last_change_timestamp_seconds", "Time when changes of system catalogs made by DDL commands have been detected the last time, in unixtime.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresDDLCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.changes,
+		c.lastDDL,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresDDLCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
+	if err != nil {
+		return err
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if it is rejected by collector's filters, avoid connecting to it.
+		if !c.filters.Pass("database", d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfig(pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.Query(catalogChangesQuery)
+		conn.Close()
+		if err != nil {
+			log.Warnf("get catalog changes of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresCatalogChanges(res) {
+			c.update(stat, time.Now())
+
+			ch <- c.changes.newConstMetric(stat.Changes, stat.Database)
+			if ts, ok := c.lastChange[stat.Database]; ok {
+				ch <- c.lastDDL.newConstMetric(float64(ts.Unix()), stat.Database)
+			}
+		}
+	}
+
+	return nil
+}
+
+// update compares number of catalog changes with the previous one and remembers time when new changes are detected.
+// Changes made before the first update are not considered, because their time is unknown.
+func (c *postgresDDLCollector) update(stat postgresCatalogChanges, now time.Time) {
+	previous, ok := c.previous[stat.Database]
+	c.previous[stat.Database] = stat.Changes
+
+	// Counters could be decreased after statistics reset.
+	if ok && stat.Changes != previous {
+		c.lastChange[stat.Database] = now
+	}
+}
+
+// postgresCatalogChanges represents number of system catalogs changes of a single database.
+type postgresCatalogChanges struct {
+	Database string  `column:"database"`
+	Changes  float64 `column:"changes"`
+}
+
+// parsePostgresCatalogChanges parses PGResult and returns structs with catalog changes.
+func parsePostgresCatalogChanges(r *model.PGResult) []postgresCatalogChanges {
+	log.Debug("parse postgres catalog changes")
+
+	stats := make([]postgresCatalogChanges, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresCatalogChanges{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPostgresDDLCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_ddl_catalog_changes_total",
+		},
+		optional: []string{
+			"postgres_ddl_last_change_timestamp_seconds",
+		},
+		collector: NewPostgresDDLCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func TestPostgresDDLCollector_update(t *testing.T) {
+	c, err := NewPostgresDDLCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	ddl := c.(*postgresDDLCollector)
+
+	t1 := time.Unix(1000, 0)
+	t2 := time.Unix(2000, 0)
+	t3 := time.Unix(3000, 0)
+
+	// The first update, time of changes is unknown.
+	ddl.update(postgresCatalogChanges{Database: "testdb", Changes: 10}, t1)
+	assert.NotContains(t, ddl.lastChange, "testdb")
+
+	// No changes.
+	ddl.update(postgresCatalogChanges{Database: "testdb", Changes: 10}, t2)
+	assert.NotContains(t, ddl.lastChange, "testdb")
+
+	// New changes.
+	ddl.update(postgresCatalogChanges{Database: "testdb", Changes: 15}, t3)
+	assert.Equal(t, t3, ddl.lastChange["testdb"])
+
+	ddl.update(postgresCatalogChanges{Database: "testdb", Changes: 15}, time.Unix(4000, 0))
+	assert.Equal(t, t3, ddl.lastChange["testdb"])
+}
+
+func Test_parsePostgresCatalogChanges(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    1,
+		Ncols:    2,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("database")}, {Name: []byte("changes")}},
+		Rows: [][]sql.NullString{
+			{{String: "testdb", Valid: true}, {String: "123", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []postgresCatalogChanges{{Database: "testdb", Changes: 123}}, parsePostgresCatalogChanges(res))
+}
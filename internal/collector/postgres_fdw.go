@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// foreignServersQuery defines query for querying foreign servers of the database, number of their user mappings,
+	// foreign tables, and a foreign table which could be used for probing the server.
+	foreignServersQuery = "SELECT current_database() AS database, s.srvname AS server, w.fdwname AS fdw, " +
+		"(SELECT count(*) FROM pg_user_mappings um WHERE um.srvid = s.oid) AS user_mappings, " +
+		"(SELECT count(*) FROM pg_foreign_table ft WHERE ft.ftserver = s.oid) AS foreign_tables, " +
+		"(SELECT quote_ident(n.nspname) || '.' || quote_ident(c.relname) FROM pg_foreign_table ft " +
+		"JOIN pg_class c ON c.oid = ft.ftrelid JOIN pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE ft.ftserver = s.oid AND has_table_privilege(c.oid, 'SELECT') ORDER BY c.oid LIMIT 1) AS probe_table " +
+		"FROM pg_foreign_server s JOIN pg_foreign_data_wrapper w ON w.oid = s.srvfdw"
+
+	// foreignServerProbeQuery defines query used for checking the foreign server is reachable. Reading a single row
+	// of a foreign table requires connection to the foreign server.
+	foreignServerProbeQuery = "SELECT 1 FROM %s LIMIT 1"
+)
+
+// postgresFdwCollector defines metric descriptors of foreign servers.
+type postgresFdwCollector struct {
+	servers       typedDesc
+	userMappings  typedDesc
+	foreignTables typedDesc
+	up            typedDesc
+	filters       filter.Filters
+}
+
+// NewPostgresFdwCollector returns a new Collector exposing foreign servers, their user mappings, foreign tables and
+// reachability. Reachability is checked by reading a single row of a foreign table of the server, servers without
+// readable foreign tables are not checked.
+// For details see https://www.postgresql.org/docs/current/ddl-foreign-data.html
+func NewPostgresFdwCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresFdwCollector{
+		filters: settings.Filters,
+		servers: newBuiltinTypedDesc(
+			descOpts{"postgres", "fdw", "server_info", "Labeled information about foreign server.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "server", "fdw"}, constLabels,
+			settings.Filters,
+		),
+		userMappings: newBuiltinTypedDesc(
+			descOpts{"postgres", "fdw", "user_mappings", "Number of user mappings defined for the foreign server.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "server"}, constLabels,
+			settings.Filters,
+		),
+		foreignTables: newBuiltinTypedDesc(
+			descOpts{"postgres", "fdw", "foreign_tables", "Number of foreign tables defined for the foreign server.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "server"}, constLabels,
+			settings.Filters,
+		),
+		up: newBuiltinTypedDesc(
+			descOpts{"postgres", "fdw", "server_up", "State of the foreign server checked through its foreign table: 0 is unreachable, 1 is reachable.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "server"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresFdwCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.servers,
+		c.userMappings,
+		c.foreignTables,
+		c.up,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresFdwCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
+	if err != nil {
+		return err
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if it is rejected by collector's filters, avoid connecting to it.
+		if !c.filters.Pass("database", d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfig(pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.Query(foreignServersQuery)
+		if err != nil {
+			conn.Close()
+			log.Warnf("get foreign servers of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresForeignServers(res) {
+			ch <- c.servers.newConstMetric(1, stat.Database, stat.Server, stat.Fdw)
+			ch <- c.userMappings.newConstMetric(stat.UserMappings, stat.Database, stat.Server)
+			ch <- c.foreignTables.newConstMetric(stat.ForeignTables, stat.Database, stat.Server)
+
+			if stat.ProbeTable == "" {
+				continue
+			}
+
+			var up float64 = 1
+			_, err := conn.Query(fmt.Sprintf(foreignServerProbeQuery, stat.ProbeTable))
+			if err != nil {
+				log.Debugf("probe foreign server '%s' of database '%s' failed: %s", stat.Server, d, err)
+				up = 0
+			}
+
+			ch <- c.up.newConstMetric(up, stat.Database, stat.Server)
+		}
+
+		conn.Close()
+	}
+
+	return nil
+}
+
+// postgresForeignServer represents a single foreign server.
+type postgresForeignServer struct {
+	Database      string  `column:"database"`
+	Server        string  `column:"server"`
+	Fdw           string  `column:"fdw"`
+	UserMappings  float64 `column:"user_mappings"`
+	ForeignTables float64 `column:"foreign_tables"`
+	ProbeTable    string  `column:"probe_table"`
+}
+
+// parsePostgresForeignServers parses PGResult and returns structs with foreign servers.
+func parsePostgresForeignServers(r *model.PGResult) []postgresForeignServer {
+	log.Debug("parse postgres foreign servers")
+
+	servers := make([]postgresForeignServer, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		server := postgresForeignServer{}
+		if err := scanRow(r.Colnames, row, &server); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		servers = append(servers, server)
+	}
+
+	return servers
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresFdwCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_fdw_server_info",
+			"postgres_fdw_user_mappings",
+			"postgres_fdw_foreign_tables",
+			"postgres_fdw_server_up",
+		},
+		collector: NewPostgresFdwCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresForeignServers(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 6,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("server")}, {Name: []byte("fdw")},
+			{Name: []byte("user_mappings")}, {Name: []byte("foreign_tables")}, {Name: []byte("probe_table")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "remote1", Valid: true}, {String: "postgres_fdw", Valid: true},
+				{String: "2", Valid: true}, {String: "5", Valid: true}, {String: "public.orders", Valid: true},
+			},
+			{
+				{String: "testdb", Valid: true}, {String: "remote2", Valid: true}, {String: "file_fdw", Valid: true},
+				{String: "0", Valid: true}, {String: "0", Valid: true}, {Valid: false},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresForeignServer{
+		{Database: "testdb", Server: "remote1", Fdw: "postgres_fdw", UserMappings: 2, ForeignTables: 5, ProbeTable: "public.orders"},
+		{Database: "testdb", Server: "remote2", Fdw: "file_fdw"},
+	}, parsePostgresForeignServers(res))
+}
@@ -47,31 +47,28 @@ func NewPostgresFunctionsCollector(constLabels labels, settings model.CollectorS
 	}, nil
 }
 
-// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
-	if err != nil {
-		return err
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresFunctionsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.calls,
+		c.totaltime,
+		c.selftime,
 	}
+}
 
-	databases, err := listDatabases(conn)
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
 	if err != nil {
 		return err
 	}
 
-	conn.Close()
-
 	pgconfig, err := pgx.ParseConfig(config.ConnString)
 	if err != nil {
 		return err
 	}
 
 	for _, d := range databases {
-		// Skip database if not matched to allowed.
-		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
-			continue
-		}
-
 		pgconfig.Database = d
 		conn, err := store.NewWithConfig(pgconfig)
 		if err != nil {
@@ -2,11 +2,11 @@ package collector
 
 import (
 	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/filter"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/lesovsky/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
-	"strconv"
 	"strings"
 )
 
@@ -21,10 +21,14 @@ const (
 
 // postgresIndexesCollector defines metric descriptors and stats store.
 type postgresIndexesCollector struct {
-	indexes typedDesc
-	tuples  typedDesc
-	io      typedDesc
-	sizes   typedDesc
+	indexes   typedDesc
+	tuples    typedDesc
+	io        typedDesc
+	sizes     typedDesc
+	filters   filter.Filters
+	rowsLimit int
+	relations int
+	truncated typedDesc
 }
 
 // NewPostgresIndexesCollector returns a new Collector exposing postgres indexes stats.
@@ -33,6 +37,9 @@ type postgresIndexesCollector struct {
 // https://www.postgresql.org/docs/current/monitoring-stats.html#PG-STATIO-ALL-INDEXES-VIEW
 func NewPostgresIndexesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	return &postgresIndexesCollector{
+		filters:   settings.Filters,
+		rowsLimit: settings.RowsLimit,
+		truncated: newRowsTruncatedDesc(constLabels),
 		indexes: newBuiltinTypedDesc(
 			descOpts{"postgres", "index", "scans_total", "Total number of index scans initiated.", 0},
 			prometheus.CounterValue,
@@ -60,28 +67,35 @@ func NewPostgresIndexesCollector(constLabels labels, settings model.CollectorSet
 	}, nil
 }
 
-// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
-	if err != nil {
-		return err
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresIndexesCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.indexes,
+		c.tuples,
+		c.io,
+		c.sizes,
+		c.truncated,
 	}
+}
 
-	databases, err := listDatabases(conn)
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
 	if err != nil {
 		return err
 	}
 
-	conn.Close()
-
 	pgconfig, err := pgx.ParseConfig(config.ConnString)
 	if err != nil {
 		return err
 	}
 
+	var truncated float64
+	c.relations = 0
+
 	for _, d := range databases {
-		// Skip database if not matched to allowed.
-		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
+		// Skip database if it is rejected by collector's filters, avoid connecting to it.
+		if !c.filters.Pass("database", d) {
 			continue
 		}
 
@@ -91,123 +105,86 @@ func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Me
 			return err
 		}
 
-		res, err := conn.Query(userIndexesQuery)
+		res, err := conn.Query(limitRowsQuery(userIndexesQuery, "size_bytes", c.rowsLimit))
 		conn.Close()
 		if err != nil {
 			log.Warnf("get indexes stat of database %s failed: %s", d, err)
 			continue
 		}
 
-		stats := parsePostgresIndexStats(res, c.indexes.labelNames)
+		if truncateResult(res, c.rowsLimit) {
+			truncated = 1
+		}
+
+		c.relations += res.Nrows
+
+		stats := parsePostgresIndexStats(res)
 
 		for _, stat := range stats {
 			// always send idx scan metrics and indexes size
-			ch <- c.indexes.newConstMetric(stat.idxscan, stat.database, stat.schema, stat.table, stat.index, stat.key)
-			ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database, stat.schema, stat.table, stat.index)
+			ch <- c.indexes.newConstMetric(stat.Idxscan, stat.Database, stat.Schema, stat.Table, stat.Index, stat.Key)
+			ch <- c.sizes.newConstMetric(stat.Sizebytes, stat.Database, stat.Schema, stat.Table, stat.Index)
 
 			// avoid metrics spamming and send metrics only if they greater than zero.
-			if stat.idxtupread > 0 {
-				ch <- c.tuples.newConstMetric(stat.idxread, stat.database, stat.schema, stat.table, stat.index, "read")
+			if stat.Idxtupread > 0 {
+				ch <- c.tuples.newConstMetric(stat.Idxread, stat.Database, stat.Schema, stat.Table, stat.Index, "read")
 			}
-			if stat.idxtupfetch > 0 {
-				ch <- c.tuples.newConstMetric(stat.idxtupfetch, stat.database, stat.schema, stat.table, stat.index, "fetched")
+			if stat.Idxtupfetch > 0 {
+				ch <- c.tuples.newConstMetric(stat.Idxtupfetch, stat.Database, stat.Schema, stat.Table, stat.Index, "fetched")
 			}
-			if stat.idxread > 0 {
-				ch <- c.io.newConstMetric(stat.idxread, stat.database, stat.schema, stat.table, stat.index, "read")
+			if stat.Idxread > 0 {
+				ch <- c.io.newConstMetric(stat.Idxread, stat.Database, stat.Schema, stat.Table, stat.Index, "read")
 			}
-			if stat.idxhit > 0 {
-				ch <- c.io.newConstMetric(stat.idxhit, stat.database, stat.schema, stat.table, stat.index, "hit")
+			if stat.Idxhit > 0 {
+				ch <- c.io.newConstMetric(stat.Idxhit, stat.Database, stat.Schema, stat.Table, stat.Index, "hit")
 			}
 		}
 	}
 
+	if c.rowsLimit > 0 {
+		ch <- c.truncated.newConstMetric(truncated, "postgres/indexes")
+	}
+
 	return nil
 }
 
+// relationsCount returns number of indexes processed during the last update.
+func (c *postgresIndexesCollector) relationsCount() int {
+	return c.relations
+}
+
 // postgresIndexStat is per-index store for metrics related to how indexes are accessed.
 type postgresIndexStat struct {
-	database    string
-	schema      string
-	table       string
-	index       string
-	key         string
-	idxscan     float64
-	idxtupread  float64
-	idxtupfetch float64
-	idxread     float64
-	idxhit      float64
-	sizebytes   float64
+	Database    string  `column:"database"`
+	Schema      string  `column:"schema"`
+	Table       string  `column:"table"`
+	Index       string  `column:"index"`
+	Key         string  `column:"key"`
+	Idxscan     float64 `column:"idx_scan"`
+	Idxtupread  float64 `column:"idx_tup_read"`
+	Idxtupfetch float64 `column:"idx_tup_fetch"`
+	Idxread     float64 `column:"idx_blks_read"`
+	Idxhit      float64 `column:"idx_blks_hit"`
+	Sizebytes   float64 `column:"size_bytes"`
 }
 
 // parsePostgresIndexStats parses PGResult and returns structs with stats values.
-func parsePostgresIndexStats(r *model.PGResult, labelNames []string) map[string]postgresIndexStat {
+func parsePostgresIndexStats(r *model.PGResult) map[string]postgresIndexStat {
 	log.Debug("parse postgres indexes stats")
 
 	var stats = make(map[string]postgresIndexStat)
 
-	var indexname string
-
 	for _, row := range r.Rows {
 		index := postgresIndexStat{}
-		for i, colname := range r.Colnames {
-			switch string(colname.Name) {
-			case "database":
-				index.database = row[i].String
-			case "schema":
-				index.schema = row[i].String
-			case "table":
-				index.table = row[i].String
-			case "index":
-				index.index = row[i].String
-			case "key":
-				index.key = row[i].String
-			}
+		if err := scanRow(r.Colnames, row, &index); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
 		}
 
 		// create a index name consisting of quartet database/schema/table/index
-		indexname = strings.Join([]string{index.database, index.schema, index.table, index.index}, "/")
+		indexname := strings.Join([]string{index.Database, index.Schema, index.Table, index.Index}, "/")
 
 		stats[indexname] = index
-
-		for i, colname := range r.Colnames {
-			// skip columns if its value used as a label
-			if stringsContains(labelNames, string(colname.Name)) {
-				continue
-			}
-
-			// Skip empty (NULL) values.
-			if !row[i].Valid {
-				continue
-			}
-
-			// Get data value and convert it to float64 used by Prometheus.
-			v, err := strconv.ParseFloat(row[i].String, 64)
-			if err != nil {
-				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
-				continue
-			}
-
-			s := stats[indexname]
-
-			switch string(colname.Name) {
-			case "idx_scan":
-				s.idxscan = v
-			case "idx_tup_read":
-				s.idxtupread = v
-			case "idx_tup_fetch":
-				s.idxtupfetch = v
-			case "idx_blks_read":
-				s.idxread = v
-			case "idx_blks_hit":
-				s.idxhit = v
-			case "size_bytes":
-				s.sizebytes = v
-			default:
-				continue
-			}
-
-			stats[indexname] = s
-		}
 	}
 
 	return stats
@@ -48,8 +48,8 @@ func Test_parsePostgresIndexStats(t *testing.T) {
 			},
 			want: map[string]postgresIndexStat{
 				"testdb/testschema/testrelname/testindex": {
-					database: "testdb", schema: "testschema", table: "testrelname", index: "testindex",
-					idxscan: 5842, idxtupread: 84572, idxtupfetch: 485, idxread: 4128, idxhit: 847,
+					Database: "testdb", Schema: "testschema", Table: "testrelname", Index: "testindex",
+					Idxscan: 5842, Idxtupread: 84572, Idxtupfetch: 485, Idxread: 4128, Idxhit: 847,
 				},
 			},
 		},
@@ -57,7 +57,7 @@ func Test_parsePostgresIndexStats(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := parsePostgresIndexStats(tc.res, []string{"datname", "schemaname", "relname", "indexrelname"})
+			got := parsePostgresIndexStats(tc.res)
 			assert.EqualValues(t, tc.want, got)
 		})
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/lesovsky/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 	"strconv"
+	"time"
 )
 
 const (
@@ -21,6 +22,28 @@ const (
 		"count(*) FILTER (WHERE not granted) AS not_granted, " +
 		"count(*) AS total " +
 		"FROM pg_locks"
+
+	// lockWaitersQuery defines query for querying backends waiting for locks, for Postgres 13 and older. Start of the
+	// wait is not tracked, the last change of backend's state is used instead. This is an approximation: for a backend
+	// waiting in the middle of a query it is the start of the query, hence wait time includes time spent on executing
+	// the query before the wait and sequential waits of the same query are accounted as a single wait.
+	lockWaitersQuery = "SELECT datname AS database, pid, state_change::text AS wait_start, " +
+		"extract(epoch FROM clock_timestamp() - state_change) AS wait_seconds " +
+		"FROM pg_stat_activity WHERE wait_event_type = 'Lock' AND datname IS NOT NULL AND state_change IS NOT NULL"
+
+	// lockWaitersQuery14 defines query for querying backends waiting for locks, for Postgres 14 and newer.
+	lockWaitersQuery14 = "SELECT DISTINCT ON (l.pid) a.datname AS database, l.pid, l.waitstart::text AS wait_start, " +
+		"extract(epoch FROM clock_timestamp() - l.waitstart) AS wait_seconds " +
+		"FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid " +
+		"WHERE NOT l.granted AND l.waitstart IS NOT NULL AND a.datname IS NOT NULL ORDER BY l.pid, l.waitstart"
+
+	// locksByTypeQuery defines query for querying number of locks by type, mode and state. Locks of pgSCV are skipped.
+	locksByTypeQuery = "SELECT locktype, mode, CASE WHEN granted THEN 'granted' ELSE 'waiting' END AS state, count(*) AS count " +
+		"FROM pg_locks WHERE pid IS DISTINCT FROM pg_backend_pid() GROUP BY 1, 2, 3"
+
+	// blockedSessionsQuery defines query for querying number of sessions waiting for locks and sessions blocking them.
+	blockedSessionsQuery = "SELECT count(DISTINCT a.pid) AS blocked, count(DISTINCT b.pid) AS blocking " +
+		"FROM pg_stat_activity a CROSS JOIN LATERAL unnest(pg_blocking_pids(a.pid)) AS b(pid) WHERE a.wait_event_type = 'Lock'"
 )
 
 // postgresLocksCollector is a collector with locks related metrics descriptors.
@@ -28,6 +51,12 @@ type postgresLocksCollector struct {
 	locks      typedDesc
 	locksAll   typedDesc
 	notgranted typedDesc
+	waitTime   typedDesc
+	waitMaxAge typedDesc
+	byType     typedDesc
+	blocked    typedDesc
+	blocking   typedDesc
+	waits      lockWaitsState
 }
 
 // NewPostgresLocksCollector creates new postgresLocksCollector.
@@ -51,9 +80,54 @@ func NewPostgresLocksCollector(constLabels labels, settings model.CollectorSetti
 			nil, constLabels,
 			settings.Filters,
 		),
+		waitTime: newBuiltinTypedDesc(
+			descOpts{"postgres", "locks", "wait_seconds_total", "Total time spent by processes waiting for locks, sampled at each collection, in seconds.", 0},
+			prometheus.CounterValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		waitMaxAge: newBuiltinTypedDesc(
+			descOpts{"postgres", "locks", "wait_max_age_seconds", "Age of the longest wait for lock among processes currently waiting, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		byType: newBuiltinTypedDesc(
+			descOpts{"postgres", "locks", "by_type_in_flight", "Number of in-flight locks by lock type, mode and state (granted, waiting).", 0},
+			prometheus.GaugeValue,
+			[]string{"locktype", "mode", "state"}, constLabels,
+			settings.Filters,
+		),
+		blocked: newBuiltinTypedDesc(
+			descOpts{"postgres", "locks", "blocked_sessions", "Number of sessions waiting for locks held by other sessions.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		blocking: newBuiltinTypedDesc(
+			descOpts{"postgres", "locks", "blocking_sessions", "Number of sessions holding locks other sessions are waiting for.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		waits: newLockWaitsState(),
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresLocksCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.locks,
+		c.locksAll,
+		c.notgranted,
+		c.waitTime,
+		c.waitMaxAge,
+		c.byType,
+		c.blocked,
+		c.blocking,
+	}
+}
+
 // Update method collects locks metrics.
 func (c *postgresLocksCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -82,9 +156,173 @@ func (c *postgresLocksCollector) Update(config Config, ch chan<- prometheus.Metr
 	ch <- c.notgranted.newConstMetric(stats.notGranted)
 	ch <- c.locksAll.newConstMetric(stats.total)
 
+	// Wait event types and pg_blocking_pids() are available since Postgres 9.6.
+	if config.serverVersionNum < PostgresV96 {
+		return nil
+	}
+
+	query := lockWaitersQuery
+	if config.serverVersionNum >= PostgresV14 {
+		query = lockWaitersQuery14
+	}
+
+	res, err = conn.Query(query)
+	if err != nil {
+		return err
+	}
+
+	maxAges := c.waits.update(parsePostgresLockWaiters(res), time.Now())
+
+	for database, value := range c.waits.totals {
+		ch <- c.waitTime.newConstMetric(value, database)
+		ch <- c.waitMaxAge.newConstMetric(maxAges[database], database)
+	}
+
+	res, err = conn.Query(locksByTypeQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresLocksByType(res) {
+		ch <- c.byType.newConstMetric(stat.Count, stat.Locktype, stat.Mode, stat.State)
+	}
+
+	res, err = conn.Query(blockedSessionsQuery)
+	if err != nil {
+		return err
+	}
+
+	sessions := parsePostgresBlockedSessions(res)
+	ch <- c.blocked.newConstMetric(sessions.Blocked)
+	ch <- c.blocking.newConstMetric(sessions.Blocking)
+
 	return nil
 }
 
+// locksByTypeStat describes number of locks of specific type, mode and state.
+type locksByTypeStat struct {
+	Locktype string  `column:"locktype"`
+	Mode     string  `column:"mode"`
+	State    string  `column:"state"`
+	Count    float64 `column:"count"`
+}
+
+// parsePostgresLocksByType parses PGResult and returns number of locks by type, mode and state.
+func parsePostgresLocksByType(r *model.PGResult) []locksByTypeStat {
+	log.Debug("parse postgres locks by type")
+
+	stats := make([]locksByTypeStat, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := locksByTypeStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// blockedSessionsStat describes number of blocked and blocking sessions.
+type blockedSessionsStat struct {
+	Blocked  float64 `column:"blocked"`
+	Blocking float64 `column:"blocking"`
+}
+
+// parsePostgresBlockedSessions parses PGResult and returns number of blocked and blocking sessions.
+func parsePostgresBlockedSessions(r *model.PGResult) blockedSessionsStat {
+	log.Debug("parse postgres blocked sessions")
+
+	stat := blockedSessionsStat{}
+	for _, row := range r.Rows {
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			return blockedSessionsStat{}
+		}
+	}
+
+	return stat
+}
+
+// lockWaiter describes a single process waiting for lock.
+type lockWaiter struct {
+	Database  string  `column:"database"`
+	Pid       string  `column:"pid"`
+	WaitStart string  `column:"wait_start"`
+	WaitTime  float64 `column:"wait_seconds"`
+}
+
+// parsePostgresLockWaiters parses PGResult and returns processes waiting for locks.
+func parsePostgresLockWaiters(r *model.PGResult) []lockWaiter {
+	log.Debug("parse postgres lock waiters")
+
+	waiters := make([]lockWaiter, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		waiter := lockWaiter{}
+		if err := scanRow(r.Colnames, row, &waiter); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		waiters = append(waiters, waiter)
+	}
+
+	return waiters
+}
+
+// lockWaitsRetention defines how long accumulated wait time of database is kept since its last observed wait.
+const lockWaitsRetention = time.Hour
+
+// lockWaitsState accumulates time spent waiting for locks per database using samples of waiting processes.
+type lockWaitsState struct {
+	// totals defines accumulated wait time per database.
+	totals map[string]float64
+	// lastSeen defines time of the last update when waiting processes of database have been observed.
+	lastSeen map[string]time.Time
+	// waits defines wait time of processes observed during the previous update, by pid and start of the wait.
+	waits map[string]float64
+}
+
+// newLockWaitsState creates new lockWaitsState.
+func newLockWaitsState() lockWaitsState {
+	return lockWaitsState{
+		totals:   map[string]float64{},
+		lastSeen: map[string]time.Time{},
+		waits:    map[string]float64{},
+	}
+}
+
+// update accounts wait time of waiting processes elapsed since the previous update and returns age of the longest
+// wait per database. Waits finished between updates are accounted up to the last update they have been observed.
+// Databases without waits during lockWaitsRetention are evicted, hence dropped databases are not kept forever.
+func (s *lockWaitsState) update(waiters []lockWaiter, now time.Time) map[string]float64 {
+	maxAges := map[string]float64{}
+	waits := make(map[string]float64, len(waiters))
+
+	for _, w := range waiters {
+		key := w.Pid + "/" + w.WaitStart
+		waits[key] = w.WaitTime
+
+		s.totals[w.Database] += w.WaitTime - s.waits[key]
+		s.lastSeen[w.Database] = now
+
+		if w.WaitTime > maxAges[w.Database] {
+			maxAges[w.Database] = w.WaitTime
+		}
+	}
+
+	s.waits = waits
+
+	for database, seen := range s.lastSeen {
+		if now.Sub(seen) > lockWaitsRetention {
+			delete(s.totals, database)
+			delete(s.lastSeen, database)
+		}
+	}
+
+	return maxAges
+}
+
 // locksStat describes locks statistics.
 type locksStat struct {
 	accessShareLock          float64
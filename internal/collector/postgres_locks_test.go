@@ -6,6 +6,7 @@ import (
 	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestPostgresLocksCollector_Update(t *testing.T) {
@@ -15,6 +16,13 @@ func TestPostgresLocksCollector_Update(t *testing.T) {
 			"postgres_locks_all_in_flight",
 			"postgres_locks_not_granted_in_flight",
 		},
+		optional: []string{
+			"postgres_locks_wait_seconds_total",
+			"postgres_locks_wait_max_age_seconds",
+			"postgres_locks_by_type_in_flight",
+			"postgres_locks_blocked_sessions",
+			"postgres_locks_blocking_sessions",
+		},
 		collector: NewPostgresLocksCollector,
 		service:   model.ServiceTypePostgresql,
 	}
@@ -65,3 +73,81 @@ func Test_parsePostgresLocksStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePostgresLockWaiters(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("pid")}, {Name: []byte("wait_start")}, {Name: []byte("wait_seconds")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "testdb", Valid: true}, {String: "1234", Valid: true}, {String: "2021-01-01 10:00:00+00", Valid: true}, {String: "2.5", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []lockWaiter{
+		{Database: "testdb", Pid: "1234", WaitStart: "2021-01-01 10:00:00+00", WaitTime: 2.5},
+	}, parsePostgresLockWaiters(res))
+}
+
+func Test_lockWaitsState_update(t *testing.T) {
+	s := newLockWaitsState()
+	now := time.Unix(1000, 0)
+
+	maxAges := s.update([]lockWaiter{
+		{Database: "db1", Pid: "1", WaitStart: "t1", WaitTime: 5},
+		{Database: "db1", Pid: "2", WaitStart: "t1", WaitTime: 2},
+		{Database: "db2", Pid: "3", WaitStart: "t1", WaitTime: 1},
+	}, now)
+	assert.Equal(t, map[string]float64{"db1": 5, "db2": 1}, maxAges)
+	assert.Equal(t, map[string]float64{"db1": 7, "db2": 1}, s.totals)
+
+	// Process 1 keeps waiting, process 2 waits for another lock, process 3 finished waiting.
+	maxAges = s.update([]lockWaiter{
+		{Database: "db1", Pid: "1", WaitStart: "t1", WaitTime: 15},
+		{Database: "db1", Pid: "2", WaitStart: "t2", WaitTime: 3},
+	}, now.Add(time.Minute))
+	assert.Equal(t, map[string]float64{"db1": 15}, maxAges)
+	assert.Equal(t, map[string]float64{"db1": 20, "db2": 1}, s.totals)
+
+	// No waiters.
+	maxAges = s.update(nil, now.Add(2*time.Minute))
+	assert.Equal(t, map[string]float64{}, maxAges)
+	assert.Equal(t, map[string]float64{"db1": 20, "db2": 1}, s.totals)
+
+	// Databases without waits during retention period are evicted.
+	_ = s.update(nil, now.Add(lockWaitsRetention+time.Second))
+	assert.Equal(t, map[string]float64{"db1": 20}, s.totals)
+	assert.NotContains(t, s.lastSeen, "db2")
+}
+
+func Test_parsePostgresLocksByType(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("locktype")}, {Name: []byte("mode")}, {Name: []byte("state")}, {Name: []byte("count")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "relation", Valid: true}, {String: "AccessShareLock", Valid: true}, {String: "granted", Valid: true}, {String: "12", Valid: true}},
+			{{String: "transactionid", Valid: true}, {String: "ShareLock", Valid: true}, {String: "waiting", Valid: true}, {String: "3", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []locksByTypeStat{
+		{Locktype: "relation", Mode: "AccessShareLock", State: "granted", Count: 12},
+		{Locktype: "transactionid", Mode: "ShareLock", State: "waiting", Count: 3},
+	}, parsePostgresLocksByType(res))
+}
+
+func Test_parsePostgresBlockedSessions(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    1,
+		Ncols:    2,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("blocked")}, {Name: []byte("blocking")}},
+		Rows:     [][]sql.NullString{{{String: "5", Valid: true}, {String: "1", Valid: true}}},
+	}
+
+	assert.Equal(t, blockedSessionsStat{Blocked: 5, Blocking: 1}, parsePostgresBlockedSessions(res))
+}
@@ -0,0 +1,198 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// replicationOriginsQuery defines query for querying replication progress of origins.
+	replicationOriginsQuery = "SELECT external_id AS origin, remote_lsn - '0/0' AS remote_lsn, local_lsn - '0/0' AS local_lsn " +
+		"FROM pg_replication_origin_status"
+
+	// logicalSlotsQuery defines query for querying logical slots and distance between the current WAL location and
+	// location confirmed by the slot consumer, for Postgres 13 and older.
+	logicalSlotsQuery = "SELECT database, slot_name, " +
+		"(CASE WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END) - confirmed_flush_lsn AS confirmed_lag_bytes " +
+		"FROM pg_replication_slots WHERE slot_type = 'logical' AND confirmed_flush_lsn IS NOT NULL"
+
+	// logicalSlotsQuery14 defines query for querying logical slots with their decoding statistics, for Postgres 14
+	// and newer.
+	logicalSlotsQuery14 = "SELECT s.database, s.slot_name, " +
+		"(CASE WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END) - s.confirmed_flush_lsn AS confirmed_lag_bytes, " +
+		"st.total_txns, st.total_bytes, st.spill_txns, st.spill_count, st.spill_bytes, st.stream_txns, st.stream_count, st.stream_bytes " +
+		"FROM pg_replication_slots s LEFT JOIN pg_stat_replication_slots st ON st.slot_name = s.slot_name " +
+		"WHERE s.slot_type = 'logical' AND s.confirmed_flush_lsn IS NOT NULL"
+)
+
+// postgresLogicalCollector defines metric descriptors for logical replication and decoding.
+type postgresLogicalCollector struct {
+	origins      typedDesc
+	lag          typedDesc
+	transactions typedDesc
+	operations   typedDesc
+	bytes        typedDesc
+}
+
+// NewPostgresLogicalCollector returns a new Collector exposing replication progress of origins and lag and decoding
+// activity of logical replication slots. Remote and local positions of origins belong to different servers, hence
+// remote position should be compared with WAL location of the upstream server.
+// For details see https://www.postgresql.org/docs/current/view-pg-replication-origin-status.html and
+// https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-REPLICATION-SLOTS-VIEW
+func NewPostgresLogicalCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresLogicalCollector{
+		origins: newBuiltinTypedDesc(
+			descOpts{"postgres", "replication_origin", "lsn_bytes", "Replication progress of origin, location of the last replayed transaction on the upstream (remote) and local server, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"origin", "type"}, constLabels,
+			settings.Filters,
+		),
+		lag: newBuiltinTypedDesc(
+			descOpts{"postgres", "logical_slot", "confirmed_lag_bytes", "Amount of WAL between the current location and location confirmed by consumer of the logical slot, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "slot_name"}, constLabels,
+			settings.Filters,
+		),
+		transactions: newBuiltinTypedDesc(
+			descOpts{"postgres", "logical_slot", "transactions_total", "Total number of decoded transactions sent to consumer of the logical slot, by type (total, spill, stream).", 0},
+			prometheus.CounterValue,
+			[]string{"database", "slot_name", "type"}, constLabels,
+			settings.Filters,
+		),
+		operations: newBuiltinTypedDesc(
+			descOpts{"postgres", "logical_slot", "decoding_operations_total", "Total number of times decoded transactions of the logical slot have been spilled to disk or streamed to consumer, by type (spill, stream).", 0},
+			prometheus.CounterValue,
+			[]string{"database", "slot_name", "type"}, constLabels,
+			settings.Filters,
+		),
+		bytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "logical_slot", "decoded_bytes_total", "Total amount of decoded transactions data sent to consumer of the logical slot, by type (total, spill, stream), in bytes.", 0},
+			prometheus.CounterValue,
+			[]string{"database", "slot_name", "type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresLogicalCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.origins,
+		c.lag,
+		c.transactions,
+		c.operations,
+		c.bytes,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresLogicalCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV10 {
+		log.Debugln("[postgres logical collector]: some system functions are not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(replicationOriginsQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresReplicationOrigins(res) {
+		ch <- c.origins.newConstMetric(stat.RemoteLSN, stat.Origin, "remote")
+		ch <- c.origins.newConstMetric(stat.LocalLSN, stat.Origin, "local")
+	}
+
+	query := logicalSlotsQuery
+	if config.serverVersionNum >= PostgresV14 {
+		query = logicalSlotsQuery14
+	}
+
+	res, err = conn.Query(query)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresLogicalSlots(res) {
+		ch <- c.lag.newConstMetric(stat.Lag, stat.Database, stat.Slot)
+
+		// Decoding statistics is available since Postgres 14.
+		if config.serverVersionNum < PostgresV14 {
+			continue
+		}
+
+		ch <- c.transactions.newConstMetric(stat.TotalTxns, stat.Database, stat.Slot, "total")
+		ch <- c.transactions.newConstMetric(stat.SpillTxns, stat.Database, stat.Slot, "spill")
+		ch <- c.transactions.newConstMetric(stat.StreamTxns, stat.Database, stat.Slot, "stream")
+		ch <- c.operations.newConstMetric(stat.SpillCount, stat.Database, stat.Slot, "spill")
+		ch <- c.operations.newConstMetric(stat.StreamCount, stat.Database, stat.Slot, "stream")
+		ch <- c.bytes.newConstMetric(stat.TotalBytes, stat.Database, stat.Slot, "total")
+		ch <- c.bytes.newConstMetric(stat.SpillBytes, stat.Database, stat.Slot, "spill")
+		ch <- c.bytes.newConstMetric(stat.StreamBytes, stat.Database, stat.Slot, "stream")
+	}
+
+	return nil
+}
+
+// postgresReplicationOrigin represents replication progress of a single origin.
+type postgresReplicationOrigin struct {
+	Origin    string  `column:"origin"`
+	RemoteLSN float64 `column:"remote_lsn"`
+	LocalLSN  float64 `column:"local_lsn"`
+}
+
+// parsePostgresReplicationOrigins parses PGResult and returns structs with replication origins progress.
+func parsePostgresReplicationOrigins(r *model.PGResult) []postgresReplicationOrigin {
+	log.Debug("parse postgres replication origins")
+
+	origins := make([]postgresReplicationOrigin, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		origin := postgresReplicationOrigin{}
+		if err := scanRow(r.Colnames, row, &origin); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		origins = append(origins, origin)
+	}
+
+	return origins
+}
+
+// postgresLogicalSlotStat represents lag and decoding statistics of a single logical slot.
+type postgresLogicalSlotStat struct {
+	Database    string  `column:"database"`
+	Slot        string  `column:"slot_name"`
+	Lag         float64 `column:"confirmed_lag_bytes"`
+	TotalTxns   float64 `column:"total_txns"`
+	TotalBytes  float64 `column:"total_bytes"`
+	SpillTxns   float64 `column:"spill_txns"`
+	SpillCount  float64 `column:"spill_count"`
+	SpillBytes  float64 `column:"spill_bytes"`
+	StreamTxns  float64 `column:"stream_txns"`
+	StreamCount float64 `column:"stream_count"`
+	StreamBytes float64 `column:"stream_bytes"`
+}
+
+// parsePostgresLogicalSlots parses PGResult and returns structs with logical slots stats.
+func parsePostgresLogicalSlots(r *model.PGResult) []postgresLogicalSlotStat {
+	log.Debug("parse postgres logical slots stats")
+
+	stats := make([]postgresLogicalSlotStat, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresLogicalSlotStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresLogicalCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_replication_origin_lsn_bytes",
+			"postgres_logical_slot_confirmed_lag_bytes",
+			"postgres_logical_slot_transactions_total",
+			"postgres_logical_slot_decoding_operations_total",
+			"postgres_logical_slot_decoded_bytes_total",
+		},
+		collector: NewPostgresLogicalCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresReplicationOrigins(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("origin")}, {Name: []byte("remote_lsn")}, {Name: []byte("local_lsn")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "pg_16390", Valid: true}, {String: "50331648", Valid: true}, {String: "83886080", Valid: true}},
+			{{String: "pg_16391", Valid: true}, {String: "", Valid: false}, {String: "", Valid: false}},
+		},
+	}
+
+	assert.Equal(t, []postgresReplicationOrigin{
+		{Origin: "pg_16390", RemoteLSN: 50331648, LocalLSN: 83886080},
+		{Origin: "pg_16391"},
+	}, parsePostgresReplicationOrigins(res))
+}
+
+func Test_parsePostgresLogicalSlots(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 11,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("slot_name")}, {Name: []byte("confirmed_lag_bytes")},
+			{Name: []byte("total_txns")}, {Name: []byte("total_bytes")}, {Name: []byte("spill_txns")},
+			{Name: []byte("spill_count")}, {Name: []byte("spill_bytes")}, {Name: []byte("stream_txns")},
+			{Name: []byte("stream_count")}, {Name: []byte("stream_bytes")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "debezium", Valid: true}, {String: "1024", Valid: true},
+				{String: "100", Valid: true}, {String: "204800", Valid: true}, {String: "2", Valid: true},
+				{String: "5", Valid: true}, {String: "65536", Valid: true}, {String: "0", Valid: true},
+				{String: "0", Valid: true}, {String: "0", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresLogicalSlotStat{
+		{
+			Database: "testdb", Slot: "debezium", Lag: 1024, TotalTxns: 100, TotalBytes: 204800,
+			SpillTxns: 2, SpillCount: 5, SpillBytes: 65536,
+		},
+	}, parsePostgresLogicalSlots(res))
+}
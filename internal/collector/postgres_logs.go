@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -30,11 +31,14 @@ type postgresLogsCollector struct {
 	fatals          syncKV      // fatals contains all collected messages with FATAL severity.
 	errors          syncKV      // errors contains all collected messages with ERROR severity.
 	warnings        syncKV      // warnings contains all collected messages with WARNING severity.
+	memoryTotal     syncKV      // memoryTotal contains total bytes of top memory contexts from the last logged dump.
+	memoryUsed      syncKV      // memoryUsed contains used bytes of top memory contexts from the last logged dump.
 	messagesTotal   typedDesc
 	panicMessages   typedDesc
 	fatalMessages   typedDesc
 	errorMessages   typedDesc
 	warningMessages typedDesc
+	memoryContexts  typedDesc
 }
 
 // NewPostgresLogsCollector creates new collector for Postgres log messages.
@@ -67,6 +71,14 @@ func NewPostgresLogsCollector(constLabels labels, settings model.CollectorSettin
 			store: map[string]float64{},
 			mu:    sync.RWMutex{},
 		},
+		memoryTotal: syncKV{
+			store: map[string]float64{},
+			mu:    sync.RWMutex{},
+		},
+		memoryUsed: syncKV{
+			store: map[string]float64{},
+			mu:    sync.RWMutex{},
+		},
 		messagesTotal: newBuiltinTypedDesc(
 			descOpts{"postgres", "log", "messages_total", "Total number of log messages written by each level.", 0},
 			prometheus.CounterValue,
@@ -97,6 +109,12 @@ func NewPostgresLogsCollector(constLabels labels, settings model.CollectorSettin
 			[]string{"msg"}, constLabels,
 			settings.Filters,
 		),
+		memoryContexts: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "memory_context_bytes", "Memory of top-level contexts including their children, taken from the most recent memory contexts dump written to the log, by type (total, used), in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"context", "type"}, constLabels,
+			settings.Filters,
+		),
 	}
 
 	go runTailLoop(collector)
@@ -104,6 +122,18 @@ func NewPostgresLogsCollector(constLabels labels, settings model.CollectorSettin
 	return collector, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresLogsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.messagesTotal,
+		c.panicMessages,
+		c.fatalMessages,
+		c.errorMessages,
+		c.warningMessages,
+		c.memoryContexts,
+	}
+}
+
 // Update method generates metrics based on collected log messages.
 func (c *postgresLogsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	if !config.localService {
@@ -168,6 +198,19 @@ func (c *postgresLogsCollector) Update(config Config, ch chan<- prometheus.Metri
 	}
 	c.warnings.mu.RUnlock()
 
+	// Memory contexts.
+	c.memoryTotal.mu.RLock()
+	for context, value := range c.memoryTotal.store {
+		ch <- c.memoryContexts.newConstMetric(value, context, "total")
+	}
+	c.memoryTotal.mu.RUnlock()
+
+	c.memoryUsed.mu.RLock()
+	for context, value := range c.memoryUsed.store {
+		ch <- c.memoryContexts.newConstMetric(value, context, "used")
+	}
+	c.memoryUsed.mu.RUnlock()
+
 	return nil
 }
 
@@ -247,13 +290,13 @@ func queryCurrentLogfile(conninfo string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	defer conn.Close()
 
 	var datadir, logfile string
 	err = conn.Conn().QueryRow(context.TODO(), "SELECT current_setting('data_directory'),pg_current_logfile()").Scan(&datadir, &logfile)
 	if err != nil {
 		return "", err
 	}
-	conn.Close()
 
 	if !strings.HasPrefix(logfile, "/") {
 		logfile = datadir + "/" + logfile
@@ -267,6 +310,17 @@ type logParser struct {
 	reSeverity  map[string]*regexp.Regexp // regexp to determine messages severity.
 	reExtract   *regexp.Regexp            // regexp for extracting exact messages from the whole line (drop log_line_prefix stuff).
 	reNormalize []*regexp.Regexp          // regexp for normalizing log message.
+	reMemory    *regexp.Regexp            // regexp for parsing memory context lines of memory contexts dump.
+	reMemoryEnd *regexp.Regexp            // regexp for parsing the final line of memory contexts dump.
+	memory      memoryContextsDump        // memory contains memory contexts dump which is being parsed.
+}
+
+// memoryContextsDump contains memory of top-level contexts accumulated from lines of memory contexts dump.
+type memoryContextsDump struct {
+	root  string             // root defines name of the root context (TopMemoryContext).
+	top   string             // top defines top-level context the following lines belong to.
+	total map[string]float64 // total defines total bytes of top-level contexts.
+	used  map[string]float64 // used defines used bytes of top-level contexts.
 }
 
 // newLogParser creates a new logParser with necessary compiled regexp objects.
@@ -295,6 +349,10 @@ func newLogParser() *logParser {
 
 	p.reExtract = regexp.MustCompile(`\s?(PANIC|FATAL|ERROR|WARNING):\s+(.+)`)
 
+	// Memory contexts dump format, see MemoryContextStatsPrint() and MemoryContextStatsInternal() in Postgres sources.
+	p.reMemory = regexp.MustCompile(`\s?LOG:\s+level: (\d+); (?:(.+?): |\d+ more child contexts containing )(\d+) total in \d+ blocks; \d+ free \(\d+ chunks\); (\d+) used`)
+	p.reMemoryEnd = regexp.MustCompile(`\s?LOG:\s+Grand total: \d+ bytes in \d+ blocks; \d+ free \(\d+ chunks\); \d+ used`)
+
 	for i, pattern := range normalizePatterns {
 		p.reNormalize[i] = regexp.MustCompile(pattern)
 	}
@@ -315,6 +373,7 @@ func (p *logParser) updateMessagesStats(line string, c *postgresLogsCollector) {
 	c.totals.mu.Unlock()
 
 	if m == "log" {
+		p.updateMemoryContexts(line, c)
 		return
 	}
 
@@ -340,6 +399,70 @@ func (p *logParser) updateMessagesStats(line string, c *postgresLogsCollector) {
 	}
 }
 
+// updateMemoryContexts parses lines of memory contexts dump written by pg_log_backend_memory_contexts(). Memory of
+// contexts is accumulated into their top-level (children of TopMemoryContext) contexts. When dump is complete, its
+// values replace the previous ones. Dumps written concurrently by several backends might be mixed up.
+func (p *logParser) updateMemoryContexts(line string, c *postgresLogsCollector) {
+	if p.reMemoryEnd.MatchString(line) {
+		if p.memory.total == nil {
+			return
+		}
+
+		c.memoryTotal.mu.Lock()
+		c.memoryTotal.store = p.memory.total
+		c.memoryTotal.mu.Unlock()
+
+		c.memoryUsed.mu.Lock()
+		c.memoryUsed.store = p.memory.used
+		c.memoryUsed.mu.Unlock()
+
+		p.memory = memoryContextsDump{}
+		return
+	}
+
+	parts := p.reMemory.FindStringSubmatch(line)
+	if len(parts) < 5 {
+		return
+	}
+
+	level, name := parts[1], parts[2]
+
+	// Dump starts from TopMemoryContext at level 0.
+	if level == "0" {
+		p.memory = memoryContextsDump{root: name, total: map[string]float64{}, used: map[string]float64{}}
+	}
+
+	// Line doesn't belong to any dump, perhaps the beginning of dump has been missed.
+	if p.memory.total == nil {
+		return
+	}
+
+	switch {
+	case level == "0":
+		p.memory.top = name
+	case level == "1" && name != "":
+		p.memory.top = name
+	case level == "1":
+		// Summary of the rest of top-level contexts is accounted to TopMemoryContext.
+		p.memory.top = p.memory.root
+	}
+
+	total, err := strconv.ParseFloat(parts[3], 64)
+	if err != nil {
+		log.Errorf("invalid input, parse '%s' failed: %s; skip", parts[3], err)
+		return
+	}
+
+	used, err := strconv.ParseFloat(parts[4], 64)
+	if err != nil {
+		log.Errorf("invalid input, parse '%s' failed: %s; skip", parts[4], err)
+		return
+	}
+
+	p.memory.total[p.memory.top] += total
+	p.memory.used[p.memory.top] += used
+}
+
 // parseMessageSeverity accepts lines and parse it using patterns from logParser.
 func (p *logParser) parseMessageSeverity(line string) (string, bool) {
 	if line == "" {
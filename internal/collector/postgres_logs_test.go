@@ -255,3 +255,34 @@ func Test_logParser_normalizeMessage(t *testing.T) {
 		assert.Equal(t, tc.want, parser.normalizeMessage(tc.in))
 	}
 }
+
+func Test_logParser_updateMemoryContexts(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	lc := c.(*postgresLogsCollector)
+
+	lines := []string{
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  level: 1; CacheMemoryContext: 100 total in 1 blocks; 50 free (0 chunks); 50 used`,
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  logging memory contexts of PID 1402271`,
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  level: 0; TopMemoryContext: 1000 total in 2 blocks; 400 free (3 chunks); 600 used`,
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  level: 1; CacheMemoryContext: 2000 total in 7 blocks; 500 free (0 chunks); 1500 used`,
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  level: 2; index info: 100 total in 2 blocks; 40 free (1 chunks); 60 used: pg_class_oid_index`,
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  level: 2; 10 more child contexts containing 300 total in 10 blocks; 100 free (5 chunks); 200 used`,
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  level: 1; MessageContext: 500 total in 1 blocks; 100 free (0 chunks); 400 used`,
+		`2021-10-01 08:37:58.208 +05 1402271 LOG:  level: 1; 5 more child contexts containing 50 total in 5 blocks; 20 free (0 chunks); 30 used`,
+	}
+
+	p := newLogParser()
+	for _, line := range lines {
+		p.updateMessagesStats(line, lc)
+	}
+
+	// Dump is not complete yet.
+	assert.Equal(t, 0, len(lc.memoryTotal.store))
+
+	p.updateMessagesStats(`2021-10-01 08:37:58.208 +05 1402271 LOG:  Grand total: 3950 bytes in 28 blocks; 1160 free (9 chunks); 2790 used`, lc)
+
+	assert.Equal(t, map[string]float64{"TopMemoryContext": 1050, "CacheMemoryContext": 2400, "MessageContext": 500}, lc.memoryTotal.store)
+	assert.Equal(t, map[string]float64{"TopMemoryContext": 630, "CacheMemoryContext": 1760, "MessageContext": 400}, lc.memoryUsed.store)
+	assert.Equal(t, float64(9), lc.totals.store["log"])
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// memoryBackendsQuery defines query for querying client backends, the oldest backends go first.
+	memoryBackendsQuery = "SELECT pid FROM pg_stat_activity " +
+		"WHERE backend_type = 'client backend' AND pid <> pg_backend_pid() ORDER BY backend_start"
+
+	// memoryLogContextsQuery defines query which asks backend to log its memory contexts into Postgres log.
+	memoryLogContextsQuery = "SELECT pg_log_backend_memory_contexts(%d)"
+)
+
+// postgresMemoryCollector asks the largest backends to log their memory contexts.
+type postgresMemoryCollector struct {
+	limit int
+}
+
+// NewPostgresMemoryCollector returns a new Collector which asks the largest client backends to log their memory
+// contexts. pg_backend_memory_contexts view shows memory contexts of the current session only, hence contexts of
+// other backends are written into Postgres log using pg_log_backend_memory_contexts() and collected by postgres/logs
+// collector. The collector is opt-in, number of backends logged per update is defined by 'rows_limit' setting.
+// For details see https://www.postgresql.org/docs/current/functions-admin.html#FUNCTIONS-ADMIN-SIGNAL
+func NewPostgresMemoryCollector(_ labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresMemoryCollector{
+		limit: settings.RowsLimit,
+	}, nil
+}
+
+// descriptors returns nothing, the collector doesn't produce metrics.
+func (c *postgresMemoryCollector) descriptors() []typedDesc {
+	return nil
+}
+
+// Update method asks the largest backends to log their memory contexts. No metrics are produced.
+func (c *postgresMemoryCollector) Update(config Config, _ chan<- prometheus.Metric) error {
+	if c.limit == 0 {
+		return nil
+	}
+
+	if !config.localService || !config.loggingCollector || config.serverVersionNum < PostgresV14 {
+		log.Debugln("[postgres memory collector]: requirements are not satisfied, skip")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(memoryBackendsQuery)
+	if err != nil {
+		return err
+	}
+
+	pids := parsePostgresBackendsPids(res)
+
+	for _, pid := range selectLargestBackends(pids, readProcessResidentMemory, c.limit) {
+		_, err := conn.Query(fmt.Sprintf(memoryLogContextsQuery, pid))
+		if err != nil {
+			log.Warnf("log memory contexts of backend %d failed: %s; skip", pid, err)
+		}
+	}
+
+	return nil
+}
+
+// parsePostgresBackendsPids parses PGResult and returns backends' pids.
+func parsePostgresBackendsPids(r *model.PGResult) []int {
+	log.Debug("parse postgres backends pids")
+
+	pids := make([]int, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		pid, err := strconv.Atoi(row[0].String)
+		if err != nil {
+			log.Errorf("invalid input, parse '%s' failed: %s; skip", row[0].String, err)
+			continue
+		}
+		pids = append(pids, pid)
+	}
+
+	return pids
+}
+
+// selectLargestBackends returns up to limit pids of processes with the largest resident memory. Processes with
+// equal or unknown memory usage keep their original order.
+func selectLargestBackends(pids []int, rss func(int) int64, limit int) []int {
+	sizes := make(map[int]int64, len(pids))
+	for _, pid := range pids {
+		sizes[pid] = rss(pid)
+	}
+
+	selected := make([]int, len(pids))
+	copy(selected, pids)
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return sizes[selected[i]] > sizes[selected[j]]
+	})
+
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+
+	return selected
+}
+
+// readProcessResidentMemory returns resident memory of the process in bytes, or zero if it cannot be read.
+func readProcessResidentMemory(pid int) int64 {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return pages * int64(os.Getpagesize())
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func Test_parsePostgresBackendsPids(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    3,
+		Ncols:    1,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("pid")}},
+		Rows: [][]sql.NullString{
+			{{String: "101", Valid: true}},
+			{{String: "invalid", Valid: true}},
+			{{String: "102", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []int{101, 102}, parsePostgresBackendsPids(res))
+}
+
+func Test_selectLargestBackends(t *testing.T) {
+	sizes := map[int]int64{101: 100, 102: 300, 103: 0, 104: 200}
+	rss := func(pid int) int64 { return sizes[pid] }
+
+	assert.Equal(t, []int{102, 104}, selectLargestBackends([]int{101, 102, 103, 104}, rss, 2))
+	assert.Equal(t, []int{102, 104, 101, 103}, selectLargestBackends([]int{101, 102, 103, 104}, rss, 10))
+
+	// Unknown sizes, original order is kept.
+	assert.Equal(t, []int{105, 106}, selectLargestBackends([]int{105, 106, 107}, rss, 2))
+}
+
+func Test_readProcessResidentMemory(t *testing.T) {
+	assert.Greater(t, readProcessResidentMemory(os.Getpid()), int64(0))
+	assert.Equal(t, int64(0), readProcessResidentMemory(-1))
+}
@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+// postgresPlansQuery defines query for querying plans statistics from pg_store_plans.
+const postgresPlansQuery = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS user, p.queryid, p.planid, " +
+	"p.calls, p.total_time FROM %s.pg_store_plans p JOIN pg_database d ON d.oid=p.dbid"
+
+// postgresPlansCollector defines metric descriptors for plans statistics.
+type postgresPlansCollector struct {
+	calls typedDesc
+	times typedDesc
+}
+
+// NewPostgresPlansCollector returns a new Collector exposing statistics of statements' plans.
+// For details see https://github.com/ossc-db/pg_store_plans
+func NewPostgresPlansCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresPlansCollector{
+		calls: newBuiltinTypedDesc(
+			descOpts{"postgres", "plans", "calls_total", "Total number of times statement has been executed using the plan.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "queryid", "planid"}, constLabels,
+			settings.Filters,
+		),
+		times: newBuiltinTypedDesc(
+			descOpts{"postgres", "plans", "time_seconds_total", "Total time spent by the statement executed using the plan, in seconds.", .001},
+			prometheus.CounterValue,
+			[]string{"user", "database", "queryid", "planid"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresPlansCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.calls,
+		c.times,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresPlansCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	// nothing to do, pg_store_plans not found in shared_preload_libraries
+	if !config.pgStorePlans {
+		return nil
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	pgconfig.Database = config.pgStorePlansDatabase
+
+	conn, err := store.NewWithConfig(pgconfig)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	// Rows are processed one by one and not kept in memory.
+	return conn.QueryFunc(fmt.Sprintf(postgresPlansQuery, config.pgStorePlansSchema), func(colnames []pgproto3.FieldDescription, row []sql.NullString) error {
+		stat := parsePostgresPlanRow(colnames, row)
+
+		ch <- c.calls.newConstMetric(stat.calls, stat.user, stat.database, stat.queryid, stat.planid)
+		ch <- c.times.newConstMetric(stat.totalTime, stat.user, stat.database, stat.queryid, stat.planid)
+		return nil
+	})
+}
+
+// postgresPlanStat represents stats values for single plan based on pg_store_plans.
+type postgresPlanStat struct {
+	database  string
+	user      string
+	queryid   string
+	planid    string
+	calls     float64
+	totalTime float64
+}
+
+// parsePostgresPlanRow parses single row of pg_store_plans stats.
+func parsePostgresPlanRow(colnames []pgproto3.FieldDescription, row []sql.NullString) postgresPlanStat {
+	var stat postgresPlanStat
+
+	for i, colname := range colnames {
+		switch string(colname.Name) {
+		case "database":
+			stat.database = row[i].String
+		case "user":
+			stat.user = row[i].String
+		case "queryid":
+			stat.queryid = row[i].String
+		case "planid":
+			stat.planid = row[i].String
+		case "calls", "total_time":
+			// Skip empty (NULL) values.
+			if !row[i].Valid {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+				continue
+			}
+
+			if string(colname.Name) == "calls" {
+				stat.calls = v
+			} else {
+				stat.totalTime = v
+			}
+		}
+	}
+
+	return stat
+}
@@ -0,0 +1,37 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresPlansCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_plans_calls_total",
+			"postgres_plans_time_seconds_total",
+		},
+		collector: NewPostgresPlansCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresPlanRow(t *testing.T) {
+	colnames := []pgproto3.FieldDescription{
+		{Name: []byte("database")}, {Name: []byte("user")}, {Name: []byte("queryid")}, {Name: []byte("planid")},
+		{Name: []byte("calls")}, {Name: []byte("total_time")},
+	}
+	row := []sql.NullString{
+		{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "123", Valid: true}, {String: "456", Valid: true},
+		{String: "100", Valid: true}, {String: "2500.5", Valid: true},
+	}
+
+	assert.Equal(t, postgresPlanStat{
+		database: "testdb", user: "testuser", queryid: "123", planid: "456", calls: 100, totalTime: 2500.5,
+	}, parsePostgresPlanRow(colnames, row))
+}
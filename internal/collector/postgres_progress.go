@@ -0,0 +1,507 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// progressCreateIndexQuery defines query for querying progress of CREATE INDEX and REINDEX commands.
+var progressCreateIndexQuery = "SELECT p.pid, p.datname AS database, " + progressRelationName("p.relid") + " AS relation, " +
+	"CASE WHEN p.index_relid = 0 THEN '' ELSE " + progressRelationName("p.index_relid") + " END AS index, " +
+	"p.command, p.phase, p.lockers_total, p.lockers_done, p.blocks_total, p.blocks_done, p.tuples_total, p.tuples_done " +
+	"FROM pg_stat_progress_create_index p"
+
+// progressClusterQuery defines query for querying progress of CLUSTER and VACUUM FULL commands.
+var progressClusterQuery = "SELECT p.pid, p.datname AS database, " + progressRelationName("p.relid") + " AS relation, " +
+	"p.command, p.phase, p.heap_tuples_scanned, p.heap_tuples_written, p.heap_blks_total, p.heap_blks_scanned, p.index_rebuild_count " +
+	"FROM pg_stat_progress_cluster p"
+
+// progressAnalyzeQuery defines query for querying progress of ANALYZE commands.
+var progressAnalyzeQuery = "SELECT p.pid, p.datname AS database, " + progressRelationName("p.relid") + " AS relation, " +
+	"p.phase, p.sample_blks_total, p.sample_blks_scanned, p.ext_stats_total, p.ext_stats_computed, p.child_tables_total, p.child_tables_done " +
+	"FROM pg_stat_progress_analyze p"
+
+// progressCopyQuery defines query for querying progress of COPY commands. Relation is empty for COPY of query results.
+var progressCopyQuery = "SELECT p.pid, p.datname AS database, " +
+	"CASE WHEN p.relid = 0 THEN '' ELSE " + progressRelationName("p.relid") + " END AS relation, " +
+	"p.command, p.type AS io_type, p.bytes_processed, p.bytes_total, p.tuples_processed, p.tuples_excluded " +
+	"FROM pg_stat_progress_copy p"
+
+const (
+	// progressBasebackupQuery defines query for querying progress of base backups, for Postgres 13 and newer.
+	progressBasebackupQuery = "SELECT pid, phase, backup_total, backup_streamed, tablespaces_total, tablespaces_streamed " +
+		"FROM pg_stat_progress_basebackup"
+
+	// basebackupsQuery defines query for querying number of in-flight base backups and duration of the longest one.
+	basebackupsQuery = "SELECT count(*) AS count, coalesce(max(extract(epoch FROM clock_timestamp() - backend_start)), 0) AS max_seconds " +
+		"FROM pg_stat_replication WHERE state = 'backup'"
+)
+
+// progressRelationName returns expression for name of relation referenced by OID column of progress view. Relations
+// of other databases can't be resolved, their OIDs are used instead.
+func progressRelationName(column string) string {
+	return "CASE WHEN p.datid = (SELECT oid FROM pg_database WHERE datname = current_database()) " +
+		"THEN " + column + "::regclass::text ELSE " + column + "::text END"
+}
+
+// postgresProgressCollector defines metric descriptors for progress of long-running commands.
+type postgresProgressCollector struct {
+	createIndexInfo    typedDesc
+	createIndexLockers typedDesc
+	createIndexBlocks  typedDesc
+	createIndexTuples  typedDesc
+	basebackups        typedDesc
+	basebackupDuration typedDesc
+	basebackupInfo     typedDesc
+	basebackupBytes    typedDesc
+	basebackupSpaces   typedDesc
+	clusterInfo        typedDesc
+	clusterBlocks      typedDesc
+	clusterTuples      typedDesc
+	clusterIndexes     typedDesc
+	analyzeInfo        typedDesc
+	analyzeBlocks      typedDesc
+	analyzeExtStats    typedDesc
+	analyzeChildTables typedDesc
+	copyInfo           typedDesc
+	copyBytes          typedDesc
+	copyTuples         typedDesc
+}
+
+// NewPostgresProgressCollector returns a new Collector exposing progress of long-running commands.
+// For details see https://www.postgresql.org/docs/current/progress-reporting.html
+func NewPostgresProgressCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresProgressCollector{
+		createIndexInfo: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_create_index", "info", "Labeled information about in-flight CREATE INDEX or REINDEX command and its current phase.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "index", "command", "phase"}, constLabels,
+			settings.Filters,
+		),
+		createIndexLockers: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_create_index", "lockers", "Number of lockers to wait for when building index concurrently, by type (total, done).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "index", "type"}, constLabels,
+			settings.Filters,
+		),
+		createIndexBlocks: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_create_index", "blocks", "Number of blocks to be processed in the current phase, by type (total, done).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "index", "type"}, constLabels,
+			settings.Filters,
+		),
+		createIndexTuples: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_create_index", "tuples", "Number of tuples to be processed in the current phase, by type (total, done).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "index", "type"}, constLabels,
+			settings.Filters,
+		),
+		basebackups: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_basebackup", "in_flight", "Number of base backups in-flight.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		basebackupDuration: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_basebackup", "max_duration_seconds", "Duration of the longest in-flight base backup, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		basebackupInfo: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_basebackup", "info", "Labeled information about in-flight base backup and its current phase.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "phase"}, constLabels,
+			settings.Filters,
+		),
+		basebackupBytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_basebackup", "bytes", "Amount of data to be streamed by base backup, by type (total, streamed), in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "type"}, constLabels,
+			settings.Filters,
+		),
+		basebackupSpaces: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_basebackup", "tablespaces", "Number of tablespaces to be streamed by base backup, by type (total, streamed).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "type"}, constLabels,
+			settings.Filters,
+		),
+		clusterInfo: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_cluster", "info", "Labeled information about in-flight CLUSTER or VACUUM FULL command and its current phase.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "command", "phase"}, constLabels,
+			settings.Filters,
+		),
+		clusterBlocks: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_cluster", "heap_blocks", "Number of heap blocks of the table, by type (total, scanned).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		clusterTuples: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_cluster", "heap_tuples", "Number of heap tuples processed, by type (scanned, written).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		clusterIndexes: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_cluster", "index_rebuilds", "Number of indexes rebuilt.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation"}, constLabels,
+			settings.Filters,
+		),
+		analyzeInfo: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_analyze", "info", "Labeled information about in-flight ANALYZE command and its current phase.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "phase"}, constLabels,
+			settings.Filters,
+		),
+		analyzeBlocks: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_analyze", "sample_blocks", "Number of heap blocks to be sampled, by type (total, scanned).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		analyzeExtStats: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_analyze", "ext_stats", "Number of extended statistics to be computed, by type (total, computed).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		analyzeChildTables: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_analyze", "child_tables", "Number of child tables to be processed, by type (total, done).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		copyInfo: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_copy", "info", "Labeled information about in-flight COPY command, its direction and I/O type.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "command", "io_type"}, constLabels,
+			settings.Filters,
+		),
+		copyBytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_copy", "bytes", "Amount of data processed by COPY command, by type (processed, total), in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		copyTuples: newBuiltinTypedDesc(
+			descOpts{"postgres", "progress_copy", "tuples", "Number of tuples processed by COPY command, by type (processed, excluded).", 0},
+			prometheus.GaugeValue,
+			[]string{"pid", "database", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresProgressCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.createIndexInfo,
+		c.createIndexLockers,
+		c.createIndexBlocks,
+		c.createIndexTuples,
+		c.basebackups,
+		c.basebackupDuration,
+		c.basebackupInfo,
+		c.basebackupBytes,
+		c.basebackupSpaces,
+		c.clusterInfo,
+		c.clusterBlocks,
+		c.clusterTuples,
+		c.clusterIndexes,
+		c.analyzeInfo,
+		c.analyzeBlocks,
+		c.analyzeExtStats,
+		c.analyzeChildTables,
+		c.copyInfo,
+		c.copyBytes,
+		c.copyTuples,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresProgressCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// CREATE INDEX, CLUSTER and VACUUM FULL progress reporting is available since Postgres 12.
+	if config.serverVersionNum >= PostgresV12 {
+		res, err := conn.Query(progressCreateIndexQuery)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parsePostgresProgressCreateIndex(res) {
+			ch <- c.createIndexInfo.newConstMetric(1, p.Pid, p.Database, p.Relation, p.Index, p.Command, p.Phase)
+			ch <- c.createIndexLockers.newConstMetric(p.LockersTotal, p.Pid, p.Database, p.Relation, p.Index, "total")
+			ch <- c.createIndexLockers.newConstMetric(p.LockersDone, p.Pid, p.Database, p.Relation, p.Index, "done")
+			ch <- c.createIndexBlocks.newConstMetric(p.BlocksTotal, p.Pid, p.Database, p.Relation, p.Index, "total")
+			ch <- c.createIndexBlocks.newConstMetric(p.BlocksDone, p.Pid, p.Database, p.Relation, p.Index, "done")
+			ch <- c.createIndexTuples.newConstMetric(p.TuplesTotal, p.Pid, p.Database, p.Relation, p.Index, "total")
+			ch <- c.createIndexTuples.newConstMetric(p.TuplesDone, p.Pid, p.Database, p.Relation, p.Index, "done")
+		}
+
+		res, err = conn.Query(progressClusterQuery)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parsePostgresProgressCluster(res) {
+			ch <- c.clusterInfo.newConstMetric(1, p.Pid, p.Database, p.Relation, p.Command, p.Phase)
+			ch <- c.clusterBlocks.newConstMetric(p.BlocksTotal, p.Pid, p.Database, p.Relation, "total")
+			ch <- c.clusterBlocks.newConstMetric(p.BlocksScanned, p.Pid, p.Database, p.Relation, "scanned")
+			ch <- c.clusterTuples.newConstMetric(p.TuplesScanned, p.Pid, p.Database, p.Relation, "scanned")
+			ch <- c.clusterTuples.newConstMetric(p.TuplesWritten, p.Pid, p.Database, p.Relation, "written")
+			ch <- c.clusterIndexes.newConstMetric(p.IndexRebuilds, p.Pid, p.Database, p.Relation)
+		}
+	}
+
+	// ANALYZE progress reporting is available since Postgres 13.
+	if config.serverVersionNum >= PostgresV13 {
+		res, err := conn.Query(progressAnalyzeQuery)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parsePostgresProgressAnalyze(res) {
+			ch <- c.analyzeInfo.newConstMetric(1, p.Pid, p.Database, p.Relation, p.Phase)
+			ch <- c.analyzeBlocks.newConstMetric(p.BlocksTotal, p.Pid, p.Database, p.Relation, "total")
+			ch <- c.analyzeBlocks.newConstMetric(p.BlocksScanned, p.Pid, p.Database, p.Relation, "scanned")
+			ch <- c.analyzeExtStats.newConstMetric(p.ExtStatsTotal, p.Pid, p.Database, p.Relation, "total")
+			ch <- c.analyzeExtStats.newConstMetric(p.ExtStatsComputed, p.Pid, p.Database, p.Relation, "computed")
+			ch <- c.analyzeChildTables.newConstMetric(p.ChildTablesTotal, p.Pid, p.Database, p.Relation, "total")
+			ch <- c.analyzeChildTables.newConstMetric(p.ChildTablesDone, p.Pid, p.Database, p.Relation, "done")
+		}
+	}
+
+	// COPY progress reporting is available since Postgres 14.
+	if config.serverVersionNum >= PostgresV14 {
+		res, err := conn.Query(progressCopyQuery)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parsePostgresProgressCopy(res) {
+			ch <- c.copyInfo.newConstMetric(1, p.Pid, p.Database, p.Relation, p.Command, p.IoType)
+			ch <- c.copyBytes.newConstMetric(p.BytesProcessed, p.Pid, p.Database, p.Relation, "processed")
+			ch <- c.copyTuples.newConstMetric(p.TuplesProcessed, p.Pid, p.Database, p.Relation, "processed")
+			ch <- c.copyTuples.newConstMetric(p.TuplesExcluded, p.Pid, p.Database, p.Relation, "excluded")
+
+			// Total size is unknown when data is not read from file.
+			if p.BytesTotal > 0 {
+				ch <- c.copyBytes.newConstMetric(p.BytesTotal, p.Pid, p.Database, p.Relation, "total")
+			}
+		}
+	}
+
+	res, err := conn.Query(basebackupsQuery)
+	if err != nil {
+		return err
+	}
+
+	backups := parsePostgresBasebackups(res)
+	ch <- c.basebackups.newConstMetric(backups.Count)
+	ch <- c.basebackupDuration.newConstMetric(backups.MaxSeconds)
+
+	// Base backup progress reporting is available since Postgres 13.
+	if config.serverVersionNum >= PostgresV13 {
+		res, err := conn.Query(progressBasebackupQuery)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parsePostgresProgressBasebackup(res) {
+			ch <- c.basebackupInfo.newConstMetric(1, p.Pid, p.Phase)
+			ch <- c.basebackupBytes.newConstMetric(p.Streamed, p.Pid, "streamed")
+			ch <- c.basebackupSpaces.newConstMetric(p.TablespacesTotal, p.Pid, "total")
+			ch <- c.basebackupSpaces.newConstMetric(p.TablespacesStreamed, p.Pid, "streamed")
+
+			// Total size is unknown when progress estimation is disabled or until the backup is started.
+			if p.Total > 0 {
+				ch <- c.basebackupBytes.newConstMetric(p.Total, p.Pid, "total")
+			}
+		}
+	}
+
+	return nil
+}
+
+// postgresProgressCreateIndex represents progress of a single CREATE INDEX or REINDEX command.
+type postgresProgressCreateIndex struct {
+	Pid          string  `column:"pid"`
+	Database     string  `column:"database"`
+	Relation     string  `column:"relation"`
+	Index        string  `column:"index"`
+	Command      string  `column:"command"`
+	Phase        string  `column:"phase"`
+	LockersTotal float64 `column:"lockers_total"`
+	LockersDone  float64 `column:"lockers_done"`
+	BlocksTotal  float64 `column:"blocks_total"`
+	BlocksDone   float64 `column:"blocks_done"`
+	TuplesTotal  float64 `column:"tuples_total"`
+	TuplesDone   float64 `column:"tuples_done"`
+}
+
+// parsePostgresProgressCreateIndex parses PGResult and returns structs with CREATE INDEX progress.
+func parsePostgresProgressCreateIndex(r *model.PGResult) []postgresProgressCreateIndex {
+	log.Debug("parse postgres create index progress")
+
+	stats := make([]postgresProgressCreateIndex, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresProgressCreateIndex{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// postgresBasebackups represents number of in-flight base backups and duration of the longest one.
+type postgresBasebackups struct {
+	Count      float64 `column:"count"`
+	MaxSeconds float64 `column:"max_seconds"`
+}
+
+// parsePostgresBasebackups parses PGResult and returns struct with in-flight base backups.
+func parsePostgresBasebackups(r *model.PGResult) postgresBasebackups {
+	log.Debug("parse postgres base backups")
+
+	stat := postgresBasebackups{}
+	for _, row := range r.Rows {
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			return postgresBasebackups{}
+		}
+	}
+
+	return stat
+}
+
+// postgresProgressBasebackup represents progress of a single base backup.
+type postgresProgressBasebackup struct {
+	Pid                 string  `column:"pid"`
+	Phase               string  `column:"phase"`
+	Total               float64 `column:"backup_total"`
+	Streamed            float64 `column:"backup_streamed"`
+	TablespacesTotal    float64 `column:"tablespaces_total"`
+	TablespacesStreamed float64 `column:"tablespaces_streamed"`
+}
+
+// parsePostgresProgressBasebackup parses PGResult and returns structs with base backups progress.
+func parsePostgresProgressBasebackup(r *model.PGResult) []postgresProgressBasebackup {
+	log.Debug("parse postgres base backup progress")
+
+	stats := make([]postgresProgressBasebackup, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresProgressBasebackup{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// postgresProgressCluster represents progress of a single CLUSTER or VACUUM FULL command.
+type postgresProgressCluster struct {
+	Pid           string  `column:"pid"`
+	Database      string  `column:"database"`
+	Relation      string  `column:"relation"`
+	Command       string  `column:"command"`
+	Phase         string  `column:"phase"`
+	TuplesScanned float64 `column:"heap_tuples_scanned"`
+	TuplesWritten float64 `column:"heap_tuples_written"`
+	BlocksTotal   float64 `column:"heap_blks_total"`
+	BlocksScanned float64 `column:"heap_blks_scanned"`
+	IndexRebuilds float64 `column:"index_rebuild_count"`
+}
+
+// parsePostgresProgressCluster parses PGResult and returns structs with CLUSTER progress.
+func parsePostgresProgressCluster(r *model.PGResult) []postgresProgressCluster {
+	log.Debug("parse postgres cluster progress")
+
+	stats := make([]postgresProgressCluster, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresProgressCluster{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// postgresProgressAnalyze represents progress of a single ANALYZE command.
+type postgresProgressAnalyze struct {
+	Pid              string  `column:"pid"`
+	Database         string  `column:"database"`
+	Relation         string  `column:"relation"`
+	Phase            string  `column:"phase"`
+	BlocksTotal      float64 `column:"sample_blks_total"`
+	BlocksScanned    float64 `column:"sample_blks_scanned"`
+	ExtStatsTotal    float64 `column:"ext_stats_total"`
+	ExtStatsComputed float64 `column:"ext_stats_computed"`
+	ChildTablesTotal float64 `column:"child_tables_total"`
+	ChildTablesDone  float64 `column:"child_tables_done"`
+}
+
+// parsePostgresProgressAnalyze parses PGResult and returns structs with ANALYZE progress.
+func parsePostgresProgressAnalyze(r *model.PGResult) []postgresProgressAnalyze {
+	log.Debug("parse postgres analyze progress")
+
+	stats := make([]postgresProgressAnalyze, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresProgressAnalyze{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// postgresProgressCopy represents progress of a single COPY command.
+type postgresProgressCopy struct {
+	Pid             string  `column:"pid"`
+	Database        string  `column:"database"`
+	Relation        string  `column:"relation"`
+	Command         string  `column:"command"`
+	IoType          string  `column:"io_type"`
+	BytesProcessed  float64 `column:"bytes_processed"`
+	BytesTotal      float64 `column:"bytes_total"`
+	TuplesProcessed float64 `column:"tuples_processed"`
+	TuplesExcluded  float64 `column:"tuples_excluded"`
+}
+
+// parsePostgresProgressCopy parses PGResult and returns structs with COPY progress.
+func parsePostgresProgressCopy(r *model.PGResult) []postgresProgressCopy {
+	log.Debug("parse postgres copy progress")
+
+	stats := make([]postgresProgressCopy, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresProgressCopy{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
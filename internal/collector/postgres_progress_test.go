@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresProgressCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_progress_basebackup_in_flight",
+			"postgres_progress_basebackup_max_duration_seconds",
+		},
+		optional: []string{
+			"postgres_progress_create_index_info",
+			"postgres_progress_create_index_lockers",
+			"postgres_progress_create_index_blocks",
+			"postgres_progress_create_index_tuples",
+			"postgres_progress_basebackup_info",
+			"postgres_progress_basebackup_bytes",
+			"postgres_progress_basebackup_tablespaces",
+			"postgres_progress_cluster_info",
+			"postgres_progress_cluster_heap_blocks",
+			"postgres_progress_cluster_heap_tuples",
+			"postgres_progress_cluster_index_rebuilds",
+			"postgres_progress_analyze_info",
+			"postgres_progress_analyze_sample_blocks",
+			"postgres_progress_analyze_ext_stats",
+			"postgres_progress_analyze_child_tables",
+			"postgres_progress_copy_info",
+			"postgres_progress_copy_bytes",
+			"postgres_progress_copy_tuples",
+		},
+		collector: NewPostgresProgressCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_progressRelationName(t *testing.T) {
+	assert.Equal(t,
+		"CASE WHEN p.datid = (SELECT oid FROM pg_database WHERE datname = current_database()) THEN p.relid::regclass::text ELSE p.relid::text END",
+		progressRelationName("p.relid"),
+	)
+}
+
+func Test_parsePostgresProgressCreateIndex(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 12,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("pid")}, {Name: []byte("database")}, {Name: []byte("relation")}, {Name: []byte("index")},
+			{Name: []byte("command")}, {Name: []byte("phase")}, {Name: []byte("lockers_total")}, {Name: []byte("lockers_done")},
+			{Name: []byte("blocks_total")}, {Name: []byte("blocks_done")}, {Name: []byte("tuples_total")}, {Name: []byte("tuples_done")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1234", Valid: true}, {String: "testdb", Valid: true}, {String: "orders", Valid: true}, {String: "orders_created_idx", Valid: true},
+				{String: "CREATE INDEX CONCURRENTLY", Valid: true}, {String: "building index: scanning table", Valid: true},
+				{String: "0", Valid: true}, {String: "0", Valid: true}, {String: "10000", Valid: true}, {String: "2500", Valid: true},
+				{String: "0", Valid: true}, {String: "0", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresProgressCreateIndex{
+		{
+			Pid: "1234", Database: "testdb", Relation: "orders", Index: "orders_created_idx",
+			Command: "CREATE INDEX CONCURRENTLY", Phase: "building index: scanning table",
+			BlocksTotal: 10000, BlocksDone: 2500,
+		},
+	}, parsePostgresProgressCreateIndex(res))
+}
+
+func Test_parsePostgresBasebackups(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    1,
+		Ncols:    2,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("count")}, {Name: []byte("max_seconds")}},
+		Rows: [][]sql.NullString{
+			{{String: "2", Valid: true}, {String: "125.5", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, postgresBasebackups{Count: 2, MaxSeconds: 125.5}, parsePostgresBasebackups(res))
+}
+
+func Test_parsePostgresProgressBasebackup(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 6,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("pid")}, {Name: []byte("phase")}, {Name: []byte("backup_total")},
+			{Name: []byte("backup_streamed")}, {Name: []byte("tablespaces_total")}, {Name: []byte("tablespaces_streamed")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1234", Valid: true}, {String: "streaming database files", Valid: true}, {String: "104857600", Valid: true},
+				{String: "52428800", Valid: true}, {String: "2", Valid: true}, {String: "1", Valid: true},
+			},
+			{
+				{String: "1235", Valid: true}, {String: "waiting for checkpoint to finish", Valid: true}, {String: "", Valid: false},
+				{String: "0", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresProgressBasebackup{
+		{Pid: "1234", Phase: "streaming database files", Total: 104857600, Streamed: 52428800, TablespacesTotal: 2, TablespacesStreamed: 1},
+		{Pid: "1235", Phase: "waiting for checkpoint to finish"},
+	}, parsePostgresProgressBasebackup(res))
+}
+
+func Test_parsePostgresProgressCluster(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 10,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("pid")}, {Name: []byte("database")}, {Name: []byte("relation")}, {Name: []byte("command")},
+			{Name: []byte("phase")}, {Name: []byte("heap_tuples_scanned")}, {Name: []byte("heap_tuples_written")},
+			{Name: []byte("heap_blks_total")}, {Name: []byte("heap_blks_scanned")}, {Name: []byte("index_rebuild_count")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1234", Valid: true}, {String: "testdb", Valid: true}, {String: "orders", Valid: true},
+				{String: "VACUUM FULL", Valid: true}, {String: "seq scanning heap", Valid: true}, {String: "1000", Valid: true},
+				{String: "900", Valid: true}, {String: "500", Valid: true}, {String: "120", Valid: true}, {String: "0", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresProgressCluster{
+		{
+			Pid: "1234", Database: "testdb", Relation: "orders", Command: "VACUUM FULL", Phase: "seq scanning heap",
+			TuplesScanned: 1000, TuplesWritten: 900, BlocksTotal: 500, BlocksScanned: 120,
+		},
+	}, parsePostgresProgressCluster(res))
+}
+
+func Test_parsePostgresProgressAnalyze(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 10,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("pid")}, {Name: []byte("database")}, {Name: []byte("relation")}, {Name: []byte("phase")},
+			{Name: []byte("sample_blks_total")}, {Name: []byte("sample_blks_scanned")}, {Name: []byte("ext_stats_total")},
+			{Name: []byte("ext_stats_computed")}, {Name: []byte("child_tables_total")}, {Name: []byte("child_tables_done")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1234", Valid: true}, {String: "testdb", Valid: true}, {String: "orders", Valid: true},
+				{String: "acquiring sample rows", Valid: true}, {String: "30000", Valid: true}, {String: "15000", Valid: true},
+				{String: "1", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresProgressAnalyze{
+		{
+			Pid: "1234", Database: "testdb", Relation: "orders", Phase: "acquiring sample rows",
+			BlocksTotal: 30000, BlocksScanned: 15000, ExtStatsTotal: 1,
+		},
+	}, parsePostgresProgressAnalyze(res))
+}
+
+func Test_parsePostgresProgressCopy(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 9,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("pid")}, {Name: []byte("database")}, {Name: []byte("relation")}, {Name: []byte("command")},
+			{Name: []byte("io_type")}, {Name: []byte("bytes_processed")}, {Name: []byte("bytes_total")},
+			{Name: []byte("tuples_processed")}, {Name: []byte("tuples_excluded")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1234", Valid: true}, {String: "testdb", Valid: true}, {String: "orders", Valid: true},
+				{String: "COPY FROM", Valid: true}, {String: "FILE", Valid: true}, {String: "1048576", Valid: true},
+				{String: "4194304", Valid: true}, {String: "10000", Valid: true}, {String: "5", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresProgressCopy{
+		{
+			Pid: "1234", Database: "testdb", Relation: "orders", Command: "COPY FROM", IoType: "FILE",
+			BytesProcessed: 1048576, BytesTotal: 4194304, TuplesProcessed: 10000, TuplesExcluded: 5,
+		},
+	}, parsePostgresProgressCopy(res))
+}
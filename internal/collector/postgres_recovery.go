@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// recoveryPrefetchQuery defines query for querying statistics of blocks prefetched during recovery.
+const recoveryPrefetchQuery = "SELECT prefetch, hit, skip_init, skip_new, skip_fpw, skip_rep, wal_distance, block_distance, io_depth " +
+	"FROM pg_stat_recovery_prefetch WHERE pg_is_in_recovery()"
+
+// postgresRecoveryCollector defines metric descriptors for recovery statistics.
+type postgresRecoveryCollector struct {
+	blocks        typedDesc
+	walDistance   typedDesc
+	blockDistance typedDesc
+	ioDepth       typedDesc
+}
+
+// NewPostgresRecoveryCollector returns a new Collector exposing statistics of blocks prefetched during recovery on
+// standbys, Postgres 15 and newer.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-RECOVERY-PREFETCH
+func NewPostgresRecoveryCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresRecoveryCollector{
+		blocks: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "blocks_total", "Total number of blocks referenced by WAL during recovery, by result (prefetch, hit, skip_init, skip_new, skip_fpw, skip_rep).", 0},
+			prometheus.CounterValue,
+			[]string{"result"}, constLabels,
+			settings.Filters,
+		),
+		walDistance: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "wal_distance_bytes", "Amount of WAL the prefetcher is looking ahead, in bytes.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		blockDistance: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "block_distance", "Number of blocks the prefetcher is looking ahead.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		ioDepth: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "io_depth", "Number of prefetches initiated but not yet known to be completed.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresRecoveryCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.blocks,
+		c.walDistance,
+		c.blockDistance,
+		c.ioDepth,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresRecoveryCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV15 {
+		log.Debugln("[postgres recovery collector]: pg_stat_recovery_prefetch view is not available, required Postgres 15 or newer")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(recoveryPrefetchQuery)
+	if err != nil {
+		return err
+	}
+
+	// Prefetch statistics is not relevant for primary.
+	if len(res.Rows) == 0 {
+		return nil
+	}
+
+	stat := parsePostgresRecoveryPrefetch(res)
+
+	ch <- c.blocks.newConstMetric(stat.Prefetch, "prefetch")
+	ch <- c.blocks.newConstMetric(stat.Hit, "hit")
+	ch <- c.blocks.newConstMetric(stat.SkipInit, "skip_init")
+	ch <- c.blocks.newConstMetric(stat.SkipNew, "skip_new")
+	ch <- c.blocks.newConstMetric(stat.SkipFpw, "skip_fpw")
+	ch <- c.blocks.newConstMetric(stat.SkipRep, "skip_rep")
+	ch <- c.walDistance.newConstMetric(stat.WalDistance)
+	ch <- c.blockDistance.newConstMetric(stat.BlockDistance)
+	ch <- c.ioDepth.newConstMetric(stat.IoDepth)
+
+	return nil
+}
+
+// postgresRecoveryPrefetchStat represents statistics of blocks prefetched during recovery.
+type postgresRecoveryPrefetchStat struct {
+	Prefetch      float64 `column:"prefetch"`
+	Hit           float64 `column:"hit"`
+	SkipInit      float64 `column:"skip_init"`
+	SkipNew       float64 `column:"skip_new"`
+	SkipFpw       float64 `column:"skip_fpw"`
+	SkipRep       float64 `column:"skip_rep"`
+	WalDistance   float64 `column:"wal_distance"`
+	BlockDistance float64 `column:"block_distance"`
+	IoDepth       float64 `column:"io_depth"`
+}
+
+// parsePostgresRecoveryPrefetch parses PGResult and returns struct with recovery prefetch statistics.
+func parsePostgresRecoveryPrefetch(r *model.PGResult) postgresRecoveryPrefetchStat {
+	log.Debug("parse postgres recovery prefetch stats")
+
+	stat := postgresRecoveryPrefetchStat{}
+	for _, row := range r.Rows {
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			return postgresRecoveryPrefetchStat{}
+		}
+	}
+
+	return stat
+}
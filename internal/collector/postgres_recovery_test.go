@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresRecoveryCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_recovery_prefetch_blocks_total",
+			"postgres_recovery_prefetch_wal_distance_bytes",
+			"postgres_recovery_prefetch_block_distance",
+			"postgres_recovery_prefetch_io_depth",
+		},
+		collector: NewPostgresRecoveryCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresRecoveryPrefetch(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 9,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("prefetch")}, {Name: []byte("hit")}, {Name: []byte("skip_init")}, {Name: []byte("skip_new")},
+			{Name: []byte("skip_fpw")}, {Name: []byte("skip_rep")}, {Name: []byte("wal_distance")},
+			{Name: []byte("block_distance")}, {Name: []byte("io_depth")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1000", Valid: true}, {String: "5000", Valid: true}, {String: "10", Valid: true}, {String: "20", Valid: true},
+				{String: "300", Valid: true}, {String: "40", Valid: true}, {String: "65536", Valid: true},
+				{String: "12", Valid: true}, {String: "3", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, postgresRecoveryPrefetchStat{
+		Prefetch: 1000, Hit: 5000, SkipInit: 10, SkipNew: 20, SkipFpw: 300, SkipRep: 40,
+		WalDistance: 65536, BlockDistance: 12, IoDepth: 3,
+	}, parsePostgresRecoveryPrefetch(res))
+}
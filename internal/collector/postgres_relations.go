@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// largestRelationsQuery defines query for querying sizes of the largest relations of the database.
+	largestRelationsQuery = "SELECT current_database() AS database, n.nspname AS schema, c.relname AS relation, " +
+		"pg_relation_size(c.oid) AS heap_bytes, pg_indexes_size(c.oid) AS indexes_bytes, " +
+		"coalesce(pg_total_relation_size(nullif(c.reltoastrelid, 0)), 0) AS toast_bytes " +
+		"FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE c.relkind IN ('r', 'm') AND n.nspname NOT IN ('pg_catalog', 'information_schema') " +
+		"AND pg_total_relation_size(c.oid) >= %d " +
+		"ORDER BY pg_total_relation_size(c.oid) DESC LIMIT %d"
+
+	// defaultLargestRelationsLimit defines default number of the largest relations reported per database.
+	defaultLargestRelationsLimit = 10
+)
+
+// postgresRelationsCollector defines metric descriptors of the largest relations.
+type postgresRelationsCollector struct {
+	sizes     typedDesc
+	filters   filter.Filters
+	limit     int
+	threshold int64
+}
+
+// NewPostgresRelationsCollector returns a new Collector exposing sizes of the largest relations of every database.
+// Number of relations is limited by 'rows_limit' setting, relations smaller than 'size_threshold' are not reported.
+// For details see https://www.postgresql.org/docs/current/functions-admin.html#FUNCTIONS-ADMIN-DBSIZE
+func NewPostgresRelationsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	limit := settings.RowsLimit
+	if limit == 0 {
+		limit = defaultLargestRelationsLimit
+	}
+
+	return &postgresRelationsCollector{
+		filters:   settings.Filters,
+		limit:     limit,
+		threshold: settings.SizeThreshold,
+		sizes: newBuiltinTypedDesc(
+			descOpts{"postgres", "relation", "size_bytes", "Size of the largest relations by type of data (heap, indexes, toast), in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresRelationsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.sizes,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresRelationsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
+	if err != nil {
+		return err
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if it is rejected by collector's filters, avoid connecting to it.
+		if !c.filters.Pass("database", d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfig(pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.Query(fmt.Sprintf(largestRelationsQuery, c.threshold, c.limit))
+		conn.Close()
+		if err != nil {
+			log.Warnf("get relations sizes of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresRelationsStats(res) {
+			ch <- c.sizes.newConstMetric(stat.Heap, stat.Database, stat.Schema, stat.Relation, "heap")
+			ch <- c.sizes.newConstMetric(stat.Indexes, stat.Database, stat.Schema, stat.Relation, "indexes")
+			if stat.Toast > 0 {
+				ch <- c.sizes.newConstMetric(stat.Toast, stat.Database, stat.Schema, stat.Relation, "toast")
+			}
+		}
+	}
+
+	return nil
+}
+
+// postgresRelationStat represents sizes of a single relation.
+type postgresRelationStat struct {
+	Database string  `column:"database"`
+	Schema   string  `column:"schema"`
+	Relation string  `column:"relation"`
+	Heap     float64 `column:"heap_bytes"`
+	Indexes  float64 `column:"indexes_bytes"`
+	Toast    float64 `column:"toast_bytes"`
+}
+
+// parsePostgresRelationsStats parses PGResult and returns structs with relations sizes.
+func parsePostgresRelationsStats(r *model.PGResult) []postgresRelationStat {
+	log.Debug("parse postgres relations sizes")
+
+	stats := make([]postgresRelationStat, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresRelationStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
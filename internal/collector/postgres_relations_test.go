@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresRelationsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_relation_size_bytes",
+		},
+		collector: NewPostgresRelationsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresRelationsStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 6,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("relation")},
+			{Name: []byte("heap_bytes")}, {Name: []byte("indexes_bytes")}, {Name: []byte("toast_bytes")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "orders", Valid: true},
+				{String: "1000", Valid: true}, {String: "500", Valid: true}, {String: "100", Valid: true},
+			},
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "clients", Valid: true},
+				{String: "200", Valid: true}, {String: "100", Valid: true}, {String: "0", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresRelationStat{
+		{Database: "testdb", Schema: "public", Relation: "orders", Heap: 1000, Indexes: 500, Toast: 100},
+		{Database: "testdb", Schema: "public", Relation: "clients", Heap: 200, Indexes: 100},
+	}, parsePostgresRelationsStats(res))
+}
@@ -53,28 +53,38 @@ func NewPostgresReplicationCollector(constLabels labels, settings model.Collecto
 			prometheus.GaugeValue,
 			labelNames, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		lagseconds: newBuiltinTypedDesc(
 			descOpts{"postgres", "replication", "lag_seconds", "Number of seconds standby is behind than primary in each WAL processing phase.", 0},
 			prometheus.GaugeValue,
 			labelNames, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		lagtotalbytes: newBuiltinTypedDesc(
 			descOpts{"postgres", "replication", "lag_all_bytes", "Number of bytes standby is behind than primary including all phases.", 0},
 			prometheus.GaugeValue,
 			[]string{"client_addr", "user", "application_name", "state"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		lagtotalseconds: newBuiltinTypedDesc(
 			descOpts{"postgres", "replication", "lag_all_seconds", "Number of seconds standby is behind than primary including all phases.", 0},
 			prometheus.GaugeValue,
 			[]string{"client_addr", "user", "application_name", "state"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresReplicationCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.lagbytes,
+		c.lagseconds,
+		c.lagtotalbytes,
+		c.lagtotalseconds,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresReplicationCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -93,33 +103,21 @@ func (c *postgresReplicationCollector) Update(config Config, ch chan<- prometheu
 	stats := parsePostgresReplicationStats(res, c.labelNames)
 
 	for _, stat := range stats {
-		if value, ok := stat.values["pending_lag_bytes"]; ok {
-			ch <- c.lagbytes.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state, "pending")
-		}
-		if value, ok := stat.values["write_lag_bytes"]; ok {
-			ch <- c.lagbytes.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state, "write")
-		}
-		if value, ok := stat.values["flush_lag_bytes"]; ok {
-			ch <- c.lagbytes.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state, "flush")
-		}
-		if value, ok := stat.values["replay_lag_bytes"]; ok {
-			ch <- c.lagbytes.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state, "replay")
+		// NULL values are absent in stats and handled according to collector's settings.
+		for _, lag := range []string{"pending", "write", "flush", "replay"} {
+			value, ok := stat.values[lag+"_lag_bytes"]
+			c.lagbytes.sendNullable(ch, value, ok, stat.clientaddr, stat.user, stat.applicationName, stat.state, lag)
 		}
-		if value, ok := stat.values["write_lag_seconds"]; ok {
-			ch <- c.lagseconds.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state, "write")
-		}
-		if value, ok := stat.values["flush_lag_seconds"]; ok {
-			ch <- c.lagseconds.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state, "flush")
-		}
-		if value, ok := stat.values["replay_lag_seconds"]; ok {
-			ch <- c.lagseconds.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state, "replay")
-		}
-		if value, ok := stat.values["total_lag_bytes"]; ok {
-			ch <- c.lagtotalbytes.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state)
-		}
-		if value, ok := stat.values["total_lag_seconds"]; ok {
-			ch <- c.lagtotalseconds.newConstMetric(value, stat.clientaddr, stat.user, stat.applicationName, stat.state)
+		for _, lag := range []string{"write", "flush", "replay"} {
+			value, ok := stat.values[lag+"_lag_seconds"]
+			c.lagseconds.sendNullable(ch, value, ok, stat.clientaddr, stat.user, stat.applicationName, stat.state, lag)
 		}
+
+		value, ok := stat.values["total_lag_bytes"]
+		c.lagtotalbytes.sendNullable(ch, value, ok, stat.clientaddr, stat.user, stat.applicationName, stat.state)
+
+		value, ok = stat.values["total_lag_seconds"]
+		c.lagtotalseconds.sendNullable(ch, value, ok, stat.clientaddr, stat.user, stat.applicationName, stat.state)
 	}
 
 	return nil
@@ -35,6 +35,13 @@ func NewPostgresReplicationSlotsCollector(constLabels labels, settings model.Col
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresReplicationSlotCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.restart,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresReplicationSlotCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
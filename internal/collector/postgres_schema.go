@@ -70,20 +70,26 @@ func NewPostgresSchemasCollector(constLabels labels, settings model.CollectorSet
 	}, nil
 }
 
-// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
-	if err != nil {
-		return err
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresSchemaCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.syscatalog,
+		c.nonpktables,
+		c.invalididx,
+		c.nonidxfkey,
+		c.redundantidx,
+		c.sequences,
+		c.difftypefkey,
 	}
+}
 
-	databases, err := listDatabases(conn)
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
 	if err != nil {
 		return err
 	}
 
-	conn.Close()
-
 	pgconfig, err := pgx.ParseConfig(config.ConnString)
 	if err != nil {
 		return err
@@ -91,11 +97,6 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 
 	// walk through all databases, connect to it and collect schema-specific stats
 	for _, d := range databases {
-		// Skip database if not matched to allowed.
-		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
-			continue
-		}
-
 		pgconfig.Database = d
 		conn, err := store.NewWithConfig(pgconfig)
 		if err != nil {
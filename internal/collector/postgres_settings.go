@@ -12,10 +12,15 @@ import (
 	"strings"
 )
 
+// settingsBaselineQuery defines query for querying raw values and values with units of baseline settings.
+const settingsBaselineQuery = "SELECT name, setting, current_setting(name) AS display FROM pg_settings WHERE name IN (%s)"
+
 // postgresSettingsCollector defines metric descriptors and stats store.
 type postgresSettingsCollector struct {
 	settings typedDesc
 	files    typedDesc
+	drift    typedDesc
+	baseline map[string]string
 }
 
 // NewPostgresSettingsCollector returns a new Collector exposing postgres settings stats.
@@ -23,6 +28,7 @@ type postgresSettingsCollector struct {
 // and https://www.postgresql.org/docs/current/view-pg-file-settings.html
 func NewPostgresSettingsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	return &postgresSettingsCollector{
+		baseline: settings.Baseline,
 		settings: newBuiltinTypedDesc(
 			descOpts{"postgres", "service", "settings_info", "Labeled information about Postgres configuration settings.", 0},
 			prometheus.GaugeValue,
@@ -35,9 +41,24 @@ func NewPostgresSettingsCollector(constLabels labels, settings model.CollectorSe
 			[]string{"guc", "mode", "path"}, constLabels,
 			settings.Filters,
 		),
+		drift: newBuiltinTypedDesc(
+			descOpts{"postgres", "settings", "drift", "Setting which value differs from the value defined in baseline.", 0},
+			prometheus.GaugeValue,
+			[]string{"name", "expected", "actual"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresSettingsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.settings,
+		c.files,
+		c.drift,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -47,34 +68,49 @@ func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.M
 	defer conn.Close()
 
 	// For complete list of displayable names of GUC's sources types check guc.c (see GucSource_Names[]).
-	query := "SELECT name, setting, unit, vartype FROM pg_show_all_settings() " +
-		"WHERE source IN ('default','configuration file','override','environment variable','command line','global')"
-	res, err := conn.Query(query)
+	queries := []string{
+		"SELECT name, setting, unit, vartype FROM pg_show_all_settings() " +
+			"WHERE source IN ('default','configuration file','override','environment variable','command line','global')",
+	}
+
+	// Collecting metrics about filesystem attributes of configuration files, requires
+	// direct access to filesystem, which is impossible for remote services. Hence, files
+	// are requested only for local services, in the same round trip with settings.
+	if config.localService {
+		queries = append(queries, `SELECT name, setting FROM pg_show_all_settings() WHERE name IN ('config_file','hba_file','ident_file','data_directory')`)
+	}
+
+	// Baseline settings are requested regardless of their source. Names are validated when config is loaded.
+	if len(c.baseline) > 0 {
+		names := make([]string, 0, len(c.baseline))
+		for name := range c.baseline {
+			names = append(names, "'"+name+"'")
+		}
+		queries = append(queries, fmt.Sprintf(settingsBaselineQuery, strings.Join(names, ",")))
+	}
+
+	res, err := conn.QueryBatch(queries...)
 	if err != nil {
 		return err
 	}
 
-	settings := parsePostgresSettings(res)
+	settings := parsePostgresSettings(res[0])
 
 	for _, s := range settings {
 		ch <- c.settings.newConstMetric(s.value, s.name, s.setting, s.unit, s.vartype, "main")
 	}
 
-	// Collecting metrics about filesystem attributes of configuration files, requires
-	// direct access to filesystem, which is impossible for remote services. If service
-	// is remote, stop here and return.
+	if len(c.baseline) > 0 {
+		for _, d := range settingsDrift(c.baseline, parsePostgresBaselineSettings(res[len(res)-1])) {
+			ch <- c.drift.newConstMetric(1, d.name, d.expected, d.actual)
+		}
+	}
 
 	if !config.localService {
 		return nil
 	}
 
-	query = `SELECT name, setting FROM pg_show_all_settings() WHERE name IN ('config_file','hba_file','ident_file','data_directory')`
-	res, err = conn.Query(query)
-	if err != nil {
-		return err
-	}
-
-	files := parsePostgresFiles(res)
+	files := parsePostgresFiles(res[1])
 
 	for _, f := range files {
 		ch <- c.files.newConstMetric(1, f.guc, f.mode, f.path)
@@ -83,6 +119,59 @@ func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.M
 	return nil
 }
 
+// postgresBaselineSetting represents actual value of a setting defined in baseline.
+type postgresBaselineSetting struct {
+	Name    string `column:"name"`
+	Setting string `column:"setting"`
+	Display string `column:"display"`
+}
+
+// parsePostgresBaselineSettings parses PGResult and returns actual values of baseline settings.
+func parsePostgresBaselineSettings(r *model.PGResult) map[string]postgresBaselineSetting {
+	log.Debug("parse postgres baseline settings")
+
+	settings := make(map[string]postgresBaselineSetting, len(r.Rows))
+	for _, row := range r.Rows {
+		setting := postgresBaselineSetting{}
+		if err := scanRow(r.Colnames, row, &setting); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		settings[setting.Name] = setting
+	}
+
+	return settings
+}
+
+// postgresSettingDrift represents a setting which value differs from the baseline.
+type postgresSettingDrift struct {
+	name     string
+	expected string
+	actual   string
+}
+
+// settingsDrift compares baseline with actual settings and returns drifted settings. Settings which are not found
+// (e.g. unknown or defined by not loaded extension) are reported with empty actual value.
+func settingsDrift(baseline map[string]string, actual map[string]postgresBaselineSetting) []postgresSettingDrift {
+	var drift []postgresSettingDrift
+
+	for name, expected := range baseline {
+		s, ok := actual[name]
+		if !ok {
+			drift = append(drift, postgresSettingDrift{name: name, expected: expected})
+			continue
+		}
+
+		if strings.EqualFold(expected, s.Setting) || strings.EqualFold(expected, s.Display) {
+			continue
+		}
+
+		drift = append(drift, postgresSettingDrift{name: name, expected: expected, actual: s.Display})
+	}
+
+	return drift
+}
+
 // postgresSetting is per-setting store for metrics related to postgres settings.
 type postgresSetting struct {
 	name    string  // pg_settings.name
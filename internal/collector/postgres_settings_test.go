@@ -307,3 +307,43 @@ func Test_parseUnit(t *testing.T) {
 	_, _, err = parseUnit("8k8k")
 	assert.Error(t, err)
 }
+
+func Test_parsePostgresBaselineSettings(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    1,
+		Ncols:    3,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("name")}, {Name: []byte("setting")}, {Name: []byte("display")}},
+		Rows: [][]sql.NullString{
+			{{String: "shared_buffers", Valid: true}, {String: "16384", Valid: true}, {String: "128MB", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, map[string]postgresBaselineSetting{
+		"shared_buffers": {Name: "shared_buffers", Setting: "16384", Display: "128MB"},
+	}, parsePostgresBaselineSettings(res))
+}
+
+func Test_settingsDrift(t *testing.T) {
+	actual := map[string]postgresBaselineSetting{
+		"shared_buffers":  {Name: "shared_buffers", Setting: "16384", Display: "128MB"},
+		"work_mem":        {Name: "work_mem", Setting: "4096", Display: "4MB"},
+		"fsync":           {Name: "fsync", Setting: "on", Display: "on"},
+		"max_connections": {Name: "max_connections", Setting: "100", Display: "100"},
+	}
+
+	baseline := map[string]string{
+		"shared_buffers":         "128mb",
+		"work_mem":               "4096",
+		"fsync":                  "on",
+		"max_connections":        "200",
+		"pg_stat_statements.max": "5000",
+	}
+
+	got := settingsDrift(baseline, actual)
+	assert.ElementsMatch(t, []postgresSettingDrift{
+		{name: "max_connections", expected: "200", actual: "100"},
+		{name: "pg_stat_statements.max", expected: "5000"},
+	}, got)
+
+	assert.Len(t, settingsDrift(nil, actual), 0)
+}
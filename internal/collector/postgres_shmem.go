@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// shmemAllocationsQuery defines query for querying shared memory allocations. Anonymous allocations have no name and
+// are summed together.
+const shmemAllocationsQuery = "SELECT coalesce(name, '<anonymous>') AS name, sum(size) AS size, " +
+	"sum(allocated_size) AS allocated_size FROM pg_shmem_allocations GROUP BY 1"
+
+// postgresShmemCollector defines metric descriptors for shared memory allocations.
+type postgresShmemCollector struct {
+	allocations typedDesc
+}
+
+// NewPostgresShmemCollector returns a new Collector exposing shared memory allocations by components, Postgres 13 and
+// newer. Unused shared memory is reported as '<free>' component.
+// For details see https://www.postgresql.org/docs/current/view-pg-shmem-allocations.html
+func NewPostgresShmemCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresShmemCollector{
+		allocations: newBuiltinTypedDesc(
+			descOpts{"postgres", "shmem", "allocation_bytes", "Shared memory allocated by component, by type (used is the requested size, allocated includes padding), in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"name", "type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresShmemCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.allocations,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresShmemCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV13 {
+		log.Debugln("[postgres shmem collector]: pg_shmem_allocations view is not available, required Postgres 13 or newer")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(shmemAllocationsQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresShmemAllocations(res) {
+		ch <- c.allocations.newConstMetric(stat.Size, stat.Name, "used")
+		ch <- c.allocations.newConstMetric(stat.AllocatedSize, stat.Name, "allocated")
+	}
+
+	return nil
+}
+
+// postgresShmemAllocation represents a single shared memory allocation.
+type postgresShmemAllocation struct {
+	Name          string  `column:"name"`
+	Size          float64 `column:"size"`
+	AllocatedSize float64 `column:"allocated_size"`
+}
+
+// parsePostgresShmemAllocations parses PGResult and returns structs with shared memory allocations.
+func parsePostgresShmemAllocations(r *model.PGResult) []postgresShmemAllocation {
+	log.Debug("parse postgres shmem allocations")
+
+	allocations := make([]postgresShmemAllocation, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		allocation := postgresShmemAllocation{}
+		if err := scanRow(r.Colnames, row, &allocation); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		allocations = append(allocations, allocation)
+	}
+
+	return allocations
+}
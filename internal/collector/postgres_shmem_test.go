@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresShmemCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_shmem_allocation_bytes",
+		},
+		collector: NewPostgresShmemCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresShmemAllocations(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("name")}, {Name: []byte("size")}, {Name: []byte("allocated_size")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "Buffer Blocks", Valid: true}, {String: "134221824", Valid: true}, {String: "134221824", Valid: true}},
+			{{String: "<anonymous>", Valid: true}, {String: "4189952", Valid: true}, {String: "4190080", Valid: true}},
+			{{String: "<free>", Valid: true}, {String: "1996928", Valid: true}, {String: "1996928", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []postgresShmemAllocation{
+		{Name: "Buffer Blocks", Size: 134221824, AllocatedSize: 134221824},
+		{Name: "<anonymous>", Size: 4189952, AllocatedSize: 4190080},
+		{Name: "<free>", Size: 1996928, AllocatedSize: 1996928},
+	}, parsePostgresShmemAllocations(res))
+}
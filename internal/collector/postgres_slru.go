@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// slruQuery defines query for querying statistics of SLRU caches.
+const slruQuery = "SELECT name, blks_zeroed, blks_hit, blks_read, blks_written, blks_exists, flushes, truncates FROM pg_stat_slru"
+
+// postgresSlruCollector defines metric descriptors for SLRU caches statistics.
+type postgresSlruCollector struct {
+	blocks    typedDesc
+	flushes   typedDesc
+	truncates typedDesc
+}
+
+// NewPostgresSlruCollector returns a new Collector exposing statistics of SLRU (simple least-recently-used) caches,
+// Postgres 13 and newer.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-SLRU-VIEW
+func NewPostgresSlruCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresSlruCollector{
+		blocks: newBuiltinTypedDesc(
+			descOpts{"postgres", "slru", "blocks_total", "Total number of SLRU cache blocks accessed, by type of access (zeroed, hit, read, written, exists).", 0},
+			prometheus.CounterValue,
+			[]string{"name", "access"}, constLabels,
+			settings.Filters,
+		),
+		flushes: newBuiltinTypedDesc(
+			descOpts{"postgres", "slru", "flushes_total", "Total number of flushes of dirty data of SLRU cache.", 0},
+			prometheus.CounterValue,
+			[]string{"name"}, constLabels,
+			settings.Filters,
+		),
+		truncates: newBuiltinTypedDesc(
+			descOpts{"postgres", "slru", "truncates_total", "Total number of truncates of SLRU cache.", 0},
+			prometheus.CounterValue,
+			[]string{"name"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresSlruCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.blocks,
+		c.flushes,
+		c.truncates,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresSlruCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV13 {
+		log.Debugln("[postgres slru collector]: pg_stat_slru view is not available, required Postgres 13 or newer")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(slruQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresSlruStats(res) {
+		ch <- c.blocks.newConstMetric(stat.Zeroed, stat.Name, "zeroed")
+		ch <- c.blocks.newConstMetric(stat.Hit, stat.Name, "hit")
+		ch <- c.blocks.newConstMetric(stat.Read, stat.Name, "read")
+		ch <- c.blocks.newConstMetric(stat.Written, stat.Name, "written")
+		ch <- c.blocks.newConstMetric(stat.Exists, stat.Name, "exists")
+		ch <- c.flushes.newConstMetric(stat.Flushes, stat.Name)
+		ch <- c.truncates.newConstMetric(stat.Truncates, stat.Name)
+	}
+
+	return nil
+}
+
+// postgresSlruStat represents statistics of a single SLRU cache.
+type postgresSlruStat struct {
+	Name      string  `column:"name"`
+	Zeroed    float64 `column:"blks_zeroed"`
+	Hit       float64 `column:"blks_hit"`
+	Read      float64 `column:"blks_read"`
+	Written   float64 `column:"blks_written"`
+	Exists    float64 `column:"blks_exists"`
+	Flushes   float64 `column:"flushes"`
+	Truncates float64 `column:"truncates"`
+}
+
+// parsePostgresSlruStats parses PGResult and returns structs with SLRU caches statistics.
+func parsePostgresSlruStats(r *model.PGResult) []postgresSlruStat {
+	log.Debug("parse postgres slru stats")
+
+	stats := make([]postgresSlruStat, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresSlruStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresSlruCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_slru_blocks_total",
+			"postgres_slru_flushes_total",
+			"postgres_slru_truncates_total",
+		},
+		collector: NewPostgresSlruCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresSlruStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 8,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("name")}, {Name: []byte("blks_zeroed")}, {Name: []byte("blks_hit")}, {Name: []byte("blks_read")},
+			{Name: []byte("blks_written")}, {Name: []byte("blks_exists")}, {Name: []byte("flushes")}, {Name: []byte("truncates")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "Subtrans", Valid: true}, {String: "10", Valid: true}, {String: "5000", Valid: true}, {String: "120", Valid: true},
+				{String: "40", Valid: true}, {String: "0", Valid: true}, {String: "15", Valid: true}, {String: "3", Valid: true},
+			},
+			{
+				{String: "MultiXactMember", Valid: true}, {String: "0", Valid: true}, {String: "100", Valid: true}, {String: "0", Valid: true},
+				{String: "0", Valid: true}, {String: "0", Valid: true}, {String: "15", Valid: true}, {String: "0", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresSlruStat{
+		{Name: "Subtrans", Zeroed: 10, Hit: 5000, Read: 120, Written: 40, Flushes: 15, Truncates: 3},
+		{Name: "MultiXactMember", Hit: 100, Flushes: 15},
+	}, parsePostgresSlruStats(res))
+}
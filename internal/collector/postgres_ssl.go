@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// sslConnectionsQuery defines query for querying number of client connections by SSL status, TLS version and
+	// cipher. Connections over Unix-domain sockets have no client address.
+	sslConnectionsQuery = "SELECT CASE WHEN a.client_addr IS NULL THEN 'socket' ELSE 'tcp' END AS transport, " +
+		"s.ssl::text AS ssl, coalesce(s.version, '') AS version, coalesce(s.cipher, '') AS cipher, count(*) AS count " +
+		"FROM pg_stat_ssl s JOIN pg_stat_activity a ON a.pid = s.pid " +
+		"WHERE a.backend_type IN ('client backend', 'walsender') GROUP BY 1, 2, 3, 4"
+
+	// gssapiConnectionsQuery defines query for querying number of client connections by GSSAPI authentication and
+	// encryption status, for Postgres 12 and newer.
+	gssapiConnectionsQuery = "SELECT g.gss_authenticated::text AS authenticated, g.encrypted::text AS encrypted, count(*) AS count " +
+		"FROM pg_stat_gssapi g JOIN pg_stat_activity a ON a.pid = g.pid " +
+		"WHERE a.backend_type IN ('client backend', 'walsender') GROUP BY 1, 2"
+)
+
+// postgresSSLCollector defines metric descriptors for connections security.
+type postgresSSLCollector struct {
+	ssl    typedDesc
+	gssapi typedDesc
+}
+
+// NewPostgresSSLCollector returns a new Collector exposing number of client connections by SSL and GSSAPI status.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-SSL-VIEW and
+// https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-GSSAPI-VIEW
+func NewPostgresSSLCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresSSLCollector{
+		ssl: newBuiltinTypedDesc(
+			descOpts{"postgres", "connections", "ssl", "Number of client connections by transport, SSL status, TLS version and cipher.", 0},
+			prometheus.GaugeValue,
+			[]string{"transport", "ssl", "version", "cipher"}, constLabels,
+			settings.Filters,
+		),
+		gssapi: newBuiltinTypedDesc(
+			descOpts{"postgres", "connections", "gssapi", "Number of client connections by GSSAPI authentication and encryption status.", 0},
+			prometheus.GaugeValue,
+			[]string{"authenticated", "encrypted"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresSSLCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.ssl,
+		c.gssapi,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresSSLCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV10 {
+		log.Debugln("[postgres ssl collector]: backend types are not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(sslConnectionsQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresSSLConnections(res) {
+		ch <- c.ssl.newConstMetric(stat.Count, stat.Transport, stat.Ssl, stat.Version, stat.Cipher)
+	}
+
+	// GSSAPI encryption is available since Postgres 12.
+	if config.serverVersionNum < PostgresV12 {
+		return nil
+	}
+
+	res, err = conn.Query(gssapiConnectionsQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresGSSAPIConnections(res) {
+		ch <- c.gssapi.newConstMetric(stat.Count, stat.Authenticated, stat.Encrypted)
+	}
+
+	return nil
+}
+
+// postgresSSLConnections represents number of connections with specific SSL properties.
+type postgresSSLConnections struct {
+	Transport string  `column:"transport"`
+	Ssl       string  `column:"ssl"`
+	Version   string  `column:"version"`
+	Cipher    string  `column:"cipher"`
+	Count     float64 `column:"count"`
+}
+
+// parsePostgresSSLConnections parses PGResult and returns structs with number of connections by SSL properties.
+func parsePostgresSSLConnections(r *model.PGResult) []postgresSSLConnections {
+	log.Debug("parse postgres ssl connections")
+
+	stats := make([]postgresSSLConnections, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresSSLConnections{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// postgresGSSAPIConnections represents number of connections with specific GSSAPI properties.
+type postgresGSSAPIConnections struct {
+	Authenticated string  `column:"authenticated"`
+	Encrypted     string  `column:"encrypted"`
+	Count         float64 `column:"count"`
+}
+
+// parsePostgresGSSAPIConnections parses PGResult and returns structs with number of connections by GSSAPI properties.
+func parsePostgresGSSAPIConnections(r *model.PGResult) []postgresGSSAPIConnections {
+	log.Debug("parse postgres gssapi connections")
+
+	stats := make([]postgresGSSAPIConnections, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresGSSAPIConnections{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
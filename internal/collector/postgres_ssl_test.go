@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresSSLCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_connections_ssl",
+		},
+		optional: []string{
+			"postgres_connections_gssapi",
+		},
+		collector: NewPostgresSSLCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresSSLConnections(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 5,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("transport")}, {Name: []byte("ssl")}, {Name: []byte("version")}, {Name: []byte("cipher")}, {Name: []byte("count")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "tcp", Valid: true}, {String: "true", Valid: true}, {String: "TLSv1.3", Valid: true}, {String: "TLS_AES_256_GCM_SHA384", Valid: true}, {String: "10", Valid: true}},
+			{{String: "socket", Valid: true}, {String: "false", Valid: true}, {String: "", Valid: true}, {String: "", Valid: true}, {String: "2", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []postgresSSLConnections{
+		{Transport: "tcp", Ssl: "true", Version: "TLSv1.3", Cipher: "TLS_AES_256_GCM_SHA384", Count: 10},
+		{Transport: "socket", Ssl: "false", Count: 2},
+	}, parsePostgresSSLConnections(res))
+}
+
+func Test_parsePostgresGSSAPIConnections(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    1,
+		Ncols:    3,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("authenticated")}, {Name: []byte("encrypted")}, {Name: []byte("count")}},
+		Rows: [][]sql.NullString{
+			{{String: "false", Valid: true}, {String: "false", Valid: true}, {String: "12", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []postgresGSSAPIConnections{
+		{Authenticated: "false", Encrypted: "false", Count: 12},
+	}, parsePostgresGSSAPIConnections(res))
+}
@@ -1,7 +1,10 @@
 package collector
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgx/v4"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
@@ -9,6 +12,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -24,6 +28,7 @@ const (
 
 	// postgresStatementsQueryLatest defines query for querying statements metrics.
 	// 1. use nullif(value, 0) to nullify zero values, NULL are skipped by stats method and metrics wil not be generated.
+	// 2. plans and plan times are zero when pg_stat_statements.track_planning is disabled.
 	postgresStatementsQueryLatest = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS user, p.queryid, " +
 		"p.query, p.calls, p.rows, p.total_exec_time, p.total_plan_time, p.blk_read_time, p.blk_write_time, " +
 		"nullif(p.shared_blks_hit, 0) AS shared_blks_hit, nullif(p.shared_blks_read, 0) AS shared_blks_read, " +
@@ -31,10 +36,20 @@ const (
 		"nullif(p.local_blks_hit, 0) AS local_blks_hit, nullif(p.local_blks_read, 0) AS local_blks_read, " +
 		"nullif(p.local_blks_dirtied, 0) AS local_blks_dirtied, nullif(p.local_blks_written, 0) AS local_blks_written, " +
 		"nullif(p.temp_blks_read, 0) AS temp_blks_read, nullif(p.temp_blks_written, 0) AS temp_blks_written, " +
-		"nullif(p.wal_records, 0) AS wal_records, nullif(p.wal_fpi, 0) AS wal_fpi, nullif(p.wal_bytes, 0) AS wal_bytes " +
+		"nullif(p.wal_records, 0) AS wal_records, nullif(p.wal_fpi, 0) AS wal_fpi, nullif(p.wal_bytes, 0) AS wal_bytes, " +
+		"nullif(p.plans, 0) AS plans, nullif(p.min_plan_time, 0) AS min_plan_time, nullif(p.max_plan_time, 0) AS max_plan_time " +
 		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid"
+
+	// postgresStatementsResetTimeQuery defines query for querying time of the last statements reset, PG14 and newer.
+	postgresStatementsResetTimeQuery = "SELECT extract(epoch FROM stats_reset) FROM %s.pg_stat_statements_info"
+
+	// postgresStatementsResetQuery defines query for resetting statements statistics.
+	postgresStatementsResetQuery = "SELECT %s.pg_stat_statements_reset()"
 )
 
+// defaultStatementsLatencyBuckets defines default upper bounds of statements latency buckets, in seconds.
+var defaultStatementsLatencyBuckets = []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10}
+
 // postgresStatementsCollector ...
 type postgresStatementsCollector struct {
 	query         typedDesc
@@ -53,14 +68,71 @@ type postgresStatementsCollector struct {
 	tempRead      typedDesc
 	tempWritten   typedDesc
 	walRecords    typedDesc
+	walFPI        typedDesc
 	walAllBytes   typedDesc
 	walBytes      typedDesc
+	plans         typedDesc
+	planTimes     typedDesc
+	rowsLimit     int
+	truncated     typedDesc
+	resetTime     typedDesc
+	latency       typedDesc
+	// latencyBuckets defines upper bounds of statements latency buckets, in seconds.
+	latencyBuckets []float64
+	clientCalls    typedDesc
+	errors         typedDesc
+	responseTime   typedDesc
+	// monitor keeps statistics accumulated from pg_stat_monitor, nil if pg_stat_monitor is not used.
+	monitor *statementsMonitorState
+	// resetInterval defines how often statistics is reset, zero means statistics is never reset.
+	resetInterval time.Duration
+	// lastReset defines time of the last statistics reset.
+	lastReset time.Time
 }
 
 // NewPostgresStatementsCollector returns a new Collector exposing postgres statements stats.
 // For details see https://www.postgresql.org/docs/current/pgstatstatements.html
 func NewPostgresStatementsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	latencyBuckets := settings.LatencyBuckets
+	if len(latencyBuckets) == 0 {
+		latencyBuckets = defaultStatementsLatencyBuckets
+	}
+
 	return &postgresStatementsCollector{
+		rowsLimit:      settings.RowsLimit,
+		truncated:      newRowsTruncatedDesc(constLabels),
+		resetInterval:  settings.ResetInterval,
+		latencyBuckets: latencyBuckets,
+		latency: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "latency_seconds", "Distribution of statements calls by mean execution time of the statement, in seconds.", .001},
+			prometheus.UntypedValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		clientCalls: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "client_calls_total", "Total number of times statements have been executed by the client.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "client_ip"}, constLabels,
+			settings.Filters,
+		),
+		errors: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "errors_total", "Total number of times statements have been finished with error, by SQLSTATE code.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "sqlcode"}, constLabels,
+			settings.Filters,
+		),
+		responseTime: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "response_time_seconds", "Distribution of statements calls by response time, in seconds.", .001},
+			prometheus.UntypedValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		resetTime: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "stats_reset_time", "Time at which statements statistics were last reset, in unixtime.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 		query: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "query_info", "Labeled info about statements has been executed.", 0},
 			prometheus.GaugeValue,
@@ -84,7 +156,7 @@ func NewPostgresStatementsCollector(constLabels labels, settings model.Collector
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid", "mode"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		allTimes: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "time_seconds_all_total", "Total time spent by the statement, in seconds.", .001},
 			prometheus.CounterValue,
@@ -96,67 +168,73 @@ func NewPostgresStatementsCollector(constLabels labels, settings model.Collector
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		sharedRead: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "shared_buffers_read_bytes_total", "Total number of bytes read from disk or OS page cache by the statement when block not found in shared buffers.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		sharedDirtied: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "shared_buffers_dirtied_total", "Total number of blocks have been dirtied in shared buffers by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		sharedWritten: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "shared_buffers_written_bytes_total", "Total number of bytes written from shared buffers to disk by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		localHit: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_hit_total", "Total number of blocks have been found in local buffers by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		localRead: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_read_bytes_total", "Total number of bytes read from disk or OS page cache by the statement when block not found in local buffers.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		localDirtied: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_dirtied_total", "Total number of blocks have been dirtied in local buffers by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		localWritten: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_written_bytes_total", "Total number of bytes written from local buffers to disk by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		tempRead: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "temp_read_bytes_total", "Total number of bytes read from temporary files by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		tempWritten: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "temp_written_bytes_total", "Total number of bytes written to temporary files by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
-		),
+		).withNullValues(settings.NullValues, constLabels),
 		walRecords: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "wal_records_total", "Total number of WAL records generated by the statement.", 0},
 			prometheus.CounterValue,
 			[]string{"user", "database", "queryid"}, constLabels,
 			settings.Filters,
 		),
+		walFPI: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "wal_fpi_total", "Total number of WAL full page images generated by the statement.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "queryid"}, constLabels,
+			settings.Filters,
+		),
 		walAllBytes: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "wal_bytes_all_total", "Total number of WAL generated by the statement, in bytes.", 0},
 			prometheus.CounterValue,
@@ -169,11 +247,61 @@ func NewPostgresStatementsCollector(constLabels labels, settings model.Collector
 			[]string{"user", "database", "queryid", "wal"}, constLabels,
 			settings.Filters,
 		),
+		plans: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "plans_total", "Total number of times statement has been planned.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "queryid"}, constLabels,
+			settings.Filters,
+		),
+		planTimes: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "plan_time_seconds", "Time spent planning the statement by type (min, max, mean), in seconds.", .001},
+			prometheus.GaugeValue,
+			[]string{"user", "database", "queryid", "type"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresStatementsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.query,
+		c.calls,
+		c.rows,
+		c.times,
+		c.allTimes,
+		c.sharedHit,
+		c.sharedRead,
+		c.sharedDirtied,
+		c.sharedWritten,
+		c.localHit,
+		c.localRead,
+		c.localDirtied,
+		c.localWritten,
+		c.tempRead,
+		c.tempWritten,
+		c.walRecords,
+		c.walFPI,
+		c.walAllBytes,
+		c.walBytes,
+		c.plans,
+		c.planTimes,
+		c.truncated,
+		c.resetTime,
+		c.latency,
+		c.clientCalls,
+		c.errors,
+		c.responseTime,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	// pg_stat_monitor is preferred over pg_stat_statements when available.
+	if config.pgStatMonitor {
+		return c.updateMonitor(config, ch)
+	}
+
 	// nothing to do, pg_stat_statements not found in shared_preload_libraries
 	if !config.pgStatStatements {
 		return nil
@@ -198,14 +326,51 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 
 	defer conn.Close()
 
-	// get pg_stat_statements stats
-	res, err := conn.Query(selectStatementsQuery(config.serverVersionNum, config.pgStatStatementsSchema))
+	// get and parse pg_stat_statements stats, rows are processed one by one and not kept in memory
+	labelNames := []string{"user", "database", "queryid", "query"}
+	stats := make(map[string]postgresStatementStat)
+
+	var nrows int
+	var truncated float64
+	statementsQuery := limitRowsQuery(
+		selectStatementsQuery(config.serverVersionNum, config.pgStatStatementsSchema),
+		selectStatementsOrderBy(config.serverVersionNum), c.rowsLimit,
+	)
+
+	err = conn.QueryFunc(statementsQuery, func(colnames []pgproto3.FieldDescription, row []sql.NullString) error {
+		// Query returns one extra row when rows limit is exceeded, skip it.
+		nrows++
+		if c.rowsLimit > 0 && nrows > c.rowsLimit {
+			truncated = 1
+			return nil
+		}
+
+		parsePostgresStatementsRow(stats, colnames, row, labelNames)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// parse pg_stat_statements stats
-	stats := parsePostgresStatementsStats(res, []string{"user", "database", "queryid", "query"})
+	if c.rowsLimit > 0 {
+		ch <- c.truncated.newConstMetric(truncated, "postgres/statements")
+	}
+
+	c.sendStats(config, stats, ch)
+
+	// Metrics sent above are the final values of statistics before reset.
+	if c.resetInterval > 0 {
+		return c.reset(conn, config, ch)
+	}
+
+	return nil
+}
+
+// sendStats sends metrics of passed statements stats.
+func (c *postgresStatementsCollector) sendStats(config Config, stats map[string]postgresStatementStat, ch chan<- prometheus.Metric) {
+	// Latency distribution of all statements, without per-statement labels.
+	count, sum, buckets := statementsLatencyHistogram(stats, c.latencyBuckets)
+	ch <- c.latency.newConstHistogram(count, sum, buckets)
 
 	blockSize := float64(config.blockSize)
 
@@ -232,47 +397,29 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 		// execution time = execution - io times.
 		ch <- c.times.newConstMetric(stat.totalExecTime-(stat.blkReadTime+stat.blkWriteTime), stat.user, stat.database, stat.queryid, "executing")
 
-		// avoid metrics spamming and send metrics only if they greater than zero.
-		if stat.blkReadTime > 0 {
-			ch <- c.times.newConstMetric(stat.blkReadTime, stat.user, stat.database, stat.queryid, "ioread")
-		}
-		if stat.blkWriteTime > 0 {
-			ch <- c.times.newConstMetric(stat.blkWriteTime, stat.user, stat.database, stat.queryid, "iowrite")
-		}
-		if stat.sharedBlksHit > 0 {
-			ch <- c.sharedHit.newConstMetric(stat.sharedBlksHit, stat.user, stat.database, stat.queryid)
-		}
-		if stat.sharedBlksRead > 0 {
-			ch <- c.sharedRead.newConstMetric(stat.sharedBlksRead*blockSize, stat.user, stat.database, stat.queryid)
-		}
-		if stat.sharedBlksDirtied > 0 {
-			ch <- c.sharedDirtied.newConstMetric(stat.sharedBlksDirtied, stat.user, stat.database, stat.queryid)
-		}
-		if stat.sharedBlksWritten > 0 {
-			ch <- c.sharedWritten.newConstMetric(stat.sharedBlksWritten*blockSize, stat.user, stat.database, stat.queryid)
-		}
-		if stat.localBlksHit > 0 {
-			ch <- c.localHit.newConstMetric(stat.localBlksHit, stat.user, stat.database, stat.queryid)
-		}
-		if stat.localBlksRead > 0 {
-			ch <- c.localRead.newConstMetric(stat.localBlksRead*blockSize, stat.user, stat.database, stat.queryid)
-		}
-		if stat.localBlksDirtied > 0 {
-			ch <- c.localDirtied.newConstMetric(stat.localBlksDirtied, stat.user, stat.database, stat.queryid)
-		}
-		if stat.localBlksWritten > 0 {
-			ch <- c.localWritten.newConstMetric(stat.localBlksWritten*blockSize, stat.user, stat.database, stat.queryid)
-		}
-		if stat.tempBlksRead > 0 {
-			ch <- c.tempRead.newConstMetric(stat.tempBlksRead*blockSize, stat.user, stat.database, stat.queryid)
-		}
-		if stat.tempBlksWritten > 0 {
-			ch <- c.tempWritten.newConstMetric(stat.tempBlksWritten*blockSize, stat.user, stat.database, stat.queryid)
-		}
+		// avoid metrics spamming and send metrics only if they greater than zero. Zero values are NULL in the query or
+		// mean the time is not tracked (track_io_timing is off), they are handled according to NULL values policy.
+		c.times.sendNullable(ch, stat.blkReadTime, stat.blkReadTime > 0, stat.user, stat.database, stat.queryid, "ioread")
+		c.times.sendNullable(ch, stat.blkWriteTime, stat.blkWriteTime > 0, stat.user, stat.database, stat.queryid, "iowrite")
+		c.sharedHit.sendNullable(ch, stat.sharedBlksHit, stat.sharedBlksHit > 0, stat.user, stat.database, stat.queryid)
+		c.sharedRead.sendNullable(ch, stat.sharedBlksRead*blockSize, stat.sharedBlksRead > 0, stat.user, stat.database, stat.queryid)
+		c.sharedDirtied.sendNullable(ch, stat.sharedBlksDirtied, stat.sharedBlksDirtied > 0, stat.user, stat.database, stat.queryid)
+		c.sharedWritten.sendNullable(ch, stat.sharedBlksWritten*blockSize, stat.sharedBlksWritten > 0, stat.user, stat.database, stat.queryid)
+		c.localHit.sendNullable(ch, stat.localBlksHit, stat.localBlksHit > 0, stat.user, stat.database, stat.queryid)
+		c.localRead.sendNullable(ch, stat.localBlksRead*blockSize, stat.localBlksRead > 0, stat.user, stat.database, stat.queryid)
+		c.localDirtied.sendNullable(ch, stat.localBlksDirtied, stat.localBlksDirtied > 0, stat.user, stat.database, stat.queryid)
+		c.localWritten.sendNullable(ch, stat.localBlksWritten*blockSize, stat.localBlksWritten > 0, stat.user, stat.database, stat.queryid)
+		c.tempRead.sendNullable(ch, stat.tempBlksRead*blockSize, stat.tempBlksRead > 0, stat.user, stat.database, stat.queryid)
+		c.tempWritten.sendNullable(ch, stat.tempBlksWritten*blockSize, stat.tempBlksWritten > 0, stat.user, stat.database, stat.queryid)
 		if stat.walRecords > 0 {
 			// WAL records
 			ch <- c.walRecords.newConstMetric(stat.walRecords, stat.user, stat.database, stat.queryid)
 
+			// WAL full page images
+			if stat.walFPI > 0 {
+				ch <- c.walFPI.newConstMetric(stat.walFPI, stat.user, stat.database, stat.queryid)
+			}
+
 			// WAL total bytes
 			ch <- c.walAllBytes.newConstMetric((stat.walFPI*blockSize)+stat.walBytes, stat.user, stat.database, stat.queryid)
 
@@ -280,8 +427,48 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 			ch <- c.walBytes.newConstMetric(stat.walFPI*blockSize, stat.user, stat.database, stat.queryid, "fpi")
 			ch <- c.walBytes.newConstMetric(stat.walBytes, stat.user, stat.database, stat.queryid, "regular")
 		}
+		if stat.plans > 0 {
+			ch <- c.plans.newConstMetric(stat.plans, stat.user, stat.database, stat.queryid)
+			ch <- c.planTimes.newConstMetric(stat.minPlanTime, stat.user, stat.database, stat.queryid, "min")
+			ch <- c.planTimes.newConstMetric(stat.maxPlanTime, stat.user, stat.database, stat.queryid, "max")
+			ch <- c.planTimes.newConstMetric(stat.totalPlanTime/stat.plans, stat.user, stat.database, stat.queryid, "mean")
+		}
+	}
+}
+
+// reset resets statements statistics when reset interval is elapsed since the last reset, and sends time of the
+// last reset.
+func (c *postgresStatementsCollector) reset(conn *store.DB, config Config, ch chan<- prometheus.Metric) error {
+	// Since Postgres 14 time of the last reset is tracked by pg_stat_statements, hence resets made by others or
+	// before pgSCV restart are taken into account.
+	if config.serverVersionNum >= PostgresV14 {
+		var epoch float64
+		query := fmt.Sprintf(postgresStatementsResetTimeQuery, config.pgStatStatementsSchema)
+		err := conn.Conn().QueryRow(context.Background(), query).Scan(&epoch)
+		if err != nil {
+			return fmt.Errorf("get statements reset time failed: %s", err)
+		}
+		c.lastReset = time.Unix(int64(epoch), 0)
+	}
+
+	// Time of the last reset is unknown, start counting reset interval from now.
+	if c.lastReset.IsZero() {
+		c.lastReset = time.Now()
 	}
 
+	if time.Since(c.lastReset) >= c.resetInterval {
+		query := fmt.Sprintf(postgresStatementsResetQuery, config.pgStatStatementsSchema)
+		_, err := conn.Conn().Exec(context.Background(), query)
+		if err != nil {
+			return fmt.Errorf("reset statements failed: %s", err)
+		}
+
+		log.Infof("statements statistics reset, the next reset in %s", c.resetInterval)
+		c.lastReset = time.Now()
+	}
+
+	ch <- c.resetTime.newConstMetric(float64(c.lastReset.Unix()))
+
 	return nil
 }
 
@@ -310,6 +497,9 @@ type postgresStatementStat struct {
 	walRecords        float64
 	walFPI            float64
 	walBytes          float64
+	plans             float64
+	minPlanTime       float64
+	maxPlanTime       float64
 }
 
 // parsePostgresStatementsStats parses PGResult and return structs with stats values.
@@ -321,100 +511,155 @@ func parsePostgresStatementsStats(r *model.PGResult, labelNames []string) map[st
 	// process row by row - on every row construct 'statement' using database/user/queryHash trio. Next process other row's
 	// fields and collect stats for constructed 'statement'.
 	for _, row := range r.Rows {
-		var database, user, queryid, query string
-
-		// collect label values
-		for i, colname := range r.Colnames {
-			switch string(colname.Name) {
-			case "database":
-				database = row[i].String
-			case "user":
-				user = row[i].String
-			case "queryid":
-				queryid = row[i].String
-			case "query":
-				query = row[i].String
-			}
+		parsePostgresStatementsRow(stats, r.Colnames, row, labelNames)
+	}
+
+	return stats
+}
+
+// parsePostgresStatementsRow parses single row of pg_stat_statements stats and accumulates values into stats.
+func parsePostgresStatementsRow(stats map[string]postgresStatementStat, colnames []pgproto3.FieldDescription, row []sql.NullString, labelNames []string) {
+	var database, user, queryid, query string
+
+	// collect label values
+	for i, colname := range colnames {
+		switch string(colname.Name) {
+		case "database":
+			database = row[i].String
+		case "user":
+			user = row[i].String
+		case "queryid":
+			queryid = row[i].String
+		case "query":
+			query = row[i].String
 		}
+	}
+
+	// Create a statement name consisting of trio database/user/queryHash
+	statement := strings.Join([]string{database, user, queryid}, "/")
 
-		// Create a statement name consisting of trio database/user/queryHash
-		statement := strings.Join([]string{database, user, queryid}, "/")
+	// Put stats with labels (but with no data values yet) into stats store.
+	if _, ok := stats[statement]; !ok {
+		stats[statement] = postgresStatementStat{database: database, user: user, queryid: queryid, query: query}
+	}
 
-		// Put stats with labels (but with no data values yet) into stats store.
-		if _, ok := stats[statement]; !ok {
-			stats[statement] = postgresStatementStat{database: database, user: user, queryid: queryid, query: query}
+	// fetch data values from columns
+	for i, colname := range colnames {
+		// skip columns if its value used as a label
+		if stringsContains(labelNames, string(colname.Name)) {
+			continue
 		}
 
-		// fetch data values from columns
-		for i, colname := range r.Colnames {
-			// skip columns if its value used as a label
-			if stringsContains(labelNames, string(colname.Name)) {
-				continue
-			}
+		// Skip empty (NULL) values.
+		if !row[i].Valid {
+			continue
+		}
 
-			// Skip empty (NULL) values.
-			if !row[i].Valid {
-				continue
-			}
+		// Get data value and convert it to float64 used by Prometheus.
+		v, err := strconv.ParseFloat(row[i].String, 64)
+		if err != nil {
+			log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+			continue
+		}
 
-			// Get data value and convert it to float64 used by Prometheus.
-			v, err := strconv.ParseFloat(row[i].String, 64)
-			if err != nil {
-				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
-				continue
+		s := stats[statement]
+
+		// Run column-specific logic
+		switch string(colname.Name) {
+		case "calls":
+			s.calls += v
+		case "rows":
+			s.rows += v
+		case "total_time", "total_exec_time":
+			s.totalExecTime += v
+		case "total_plan_time":
+			s.totalPlanTime += v
+		case "blk_read_time":
+			s.blkReadTime += v
+		case "blk_write_time":
+			s.blkWriteTime += v
+		case "shared_blks_hit":
+			s.sharedBlksHit += v
+		case "shared_blks_read":
+			s.sharedBlksRead += v
+		case "shared_blks_dirtied":
+			s.sharedBlksDirtied += v
+		case "shared_blks_written":
+			s.sharedBlksWritten += v
+		case "local_blks_hit":
+			s.localBlksHit += v
+		case "local_blks_read":
+			s.localBlksRead += v
+		case "local_blks_dirtied":
+			s.localBlksDirtied += v
+		case "local_blks_written":
+			s.localBlksWritten += v
+		case "temp_blks_read":
+			s.tempBlksRead += v
+		case "temp_blks_written":
+			s.tempBlksWritten += v
+		case "wal_records":
+			s.walRecords += v
+		case "wal_fpi":
+			s.walFPI += v
+		case "wal_bytes":
+			s.walBytes += v
+		case "plans":
+			s.plans += v
+		case "min_plan_time":
+			// Statement could be represented by several rows (e.g. top-level and nested), keep the extreme values.
+			if s.minPlanTime == 0 || v < s.minPlanTime {
+				s.minPlanTime = v
 			}
-
-			s := stats[statement]
-
-			// Run column-specific logic
-			switch string(colname.Name) {
-			case "calls":
-				s.calls += v
-			case "rows":
-				s.rows += v
-			case "total_time", "total_exec_time":
-				s.totalExecTime += v
-			case "total_plan_time":
-				s.totalPlanTime += v
-			case "blk_read_time":
-				s.blkReadTime += v
-			case "blk_write_time":
-				s.blkWriteTime += v
-			case "shared_blks_hit":
-				s.sharedBlksHit += v
-			case "shared_blks_read":
-				s.sharedBlksRead += v
-			case "shared_blks_dirtied":
-				s.sharedBlksDirtied += v
-			case "shared_blks_written":
-				s.sharedBlksWritten += v
-			case "local_blks_hit":
-				s.localBlksHit += v
-			case "local_blks_read":
-				s.localBlksRead += v
-			case "local_blks_dirtied":
-				s.localBlksDirtied += v
-			case "local_blks_written":
-				s.localBlksWritten += v
-			case "temp_blks_read":
-				s.tempBlksRead += v
-			case "temp_blks_written":
-				s.tempBlksWritten += v
-			case "wal_records":
-				s.walRecords += v
-			case "wal_fpi":
-				s.walFPI += v
-			case "wal_bytes":
-				s.walBytes += v
-			default:
-				continue
+		case "max_plan_time":
+			if v > s.maxPlanTime {
+				s.maxPlanTime = v
 			}
+		default:
+			continue
+		}
 
-			stats[statement] = s
+		stats[statement] = s
+	}
+}
+
+// statementsLatencyHistogram distributes calls of statements into buckets by mean execution time of the statements.
+// Returns total number of calls, total execution time in milliseconds and cumulative number of calls per bucket.
+func statementsLatencyHistogram(stats map[string]postgresStatementStat, bounds []float64) (uint64, float64, map[float64]uint64) {
+	var count uint64
+	var sum float64
+	buckets := make(map[float64]uint64, len(bounds))
+	for _, b := range bounds {
+		buckets[b] = 0
+	}
+
+	for _, stat := range stats {
+		if stat.calls <= 0 {
+			continue
+		}
+
+		calls := uint64(stat.calls)
+		count += calls
+		sum += stat.totalExecTime
+
+		// Mean execution time is in milliseconds, bounds are in seconds.
+		mean := stat.totalExecTime / stat.calls / 1000
+		for _, b := range bounds {
+			if mean <= b {
+				buckets[b] += calls
+			}
 		}
 	}
 
-	return stats
+	return count, sum, buckets
+}
+
+// selectStatementsOrderBy returns column used for ordering statements depending on passed version.
+func selectStatementsOrderBy(version int) string {
+	if version < PostgresV13 {
+		return "total_time"
+	}
+	return "total_exec_time"
 }
 
 // selectStatementsQuery returns suitable statements query depending on passed version.
@@ -0,0 +1,271 @@
+package collector
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// postgresStatementsMonitorQuery defines query for querying statements metrics from completed buckets of
+	// pg_stat_monitor 2.0 and newer. The current bucket is skipped until it is completed.
+	postgresStatementsMonitorQuery = "SELECT extract(epoch FROM p.bucket_start_time) AS bucket_start, " +
+		"p.datname AS database, p.username AS user, p.client_ip, p.queryid, p.query, p.elevel, p.sqlcode, " +
+		"array_to_string(p.resp_calls, ',') AS resp_calls, p.calls, p.rows, p.total_exec_time, p.total_plan_time, " +
+		"p.blk_read_time, p.blk_write_time, " +
+		"nullif(p.shared_blks_hit, 0) AS shared_blks_hit, nullif(p.shared_blks_read, 0) AS shared_blks_read, " +
+		"nullif(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, nullif(p.shared_blks_written, 0) AS shared_blks_written, " +
+		"nullif(p.local_blks_hit, 0) AS local_blks_hit, nullif(p.local_blks_read, 0) AS local_blks_read, " +
+		"nullif(p.local_blks_dirtied, 0) AS local_blks_dirtied, nullif(p.local_blks_written, 0) AS local_blks_written, " +
+		"nullif(p.temp_blks_read, 0) AS temp_blks_read, nullif(p.temp_blks_written, 0) AS temp_blks_written, " +
+		"nullif(p.wal_records, 0) AS wal_records, nullif(p.wal_fpi, 0) AS wal_fpi, nullif(p.wal_bytes, 0) AS wal_bytes " +
+		"FROM %s.pg_stat_monitor p " +
+		"WHERE p.bucket_start_time < (SELECT max(bucket_start_time) FROM %s.pg_stat_monitor) " +
+		"AND extract(epoch FROM p.bucket_start_time) > %f"
+
+	// postgresStatementsMonitorRangesQuery defines query for querying response time ranges of pg_stat_monitor histograms.
+	postgresStatementsMonitorRangesQuery = "SELECT unnest(%s.range()) AS range"
+)
+
+// statementsMonitorLabels defines columns of pg_stat_monitor query which are not accumulated as statements stats.
+var statementsMonitorLabels = []string{
+	"user", "database", "queryid", "query", "bucket_start", "client_ip", "elevel", "sqlcode", "resp_calls",
+}
+
+// statementsClient identifies statements executed by a client.
+type statementsClient struct {
+	user     string
+	database string
+	clientIP string
+}
+
+// statementsError identifies statements finished with an error.
+type statementsError struct {
+	user     string
+	database string
+	sqlcode  string
+}
+
+// statementsResponses accounts statements response time distribution.
+type statementsResponses struct {
+	// calls defines number of calls per response time range.
+	calls []uint64
+	// sum defines total execution time of statements, in milliseconds.
+	sum float64
+}
+
+// statementsMonitorState keeps statistics accumulated from pg_stat_monitor buckets. pg_stat_monitor keeps statistics
+// of limited number of time buckets only, hence statistics of completed buckets is accumulated and exposed as
+// counters which are monotonic regardless of buckets rotation.
+type statementsMonitorState struct {
+	// lastBucket defines start time of the last processed bucket, in unixtime.
+	lastBucket float64
+	// bounds defines upper bounds of response time ranges, in seconds. Nil if ranges are not requested yet.
+	bounds    []float64
+	stats     map[string]postgresStatementStat
+	clients   map[statementsClient]float64
+	errors    map[statementsError]float64
+	responses map[string]*statementsResponses
+}
+
+// newStatementsMonitorState creates new empty statementsMonitorState.
+func newStatementsMonitorState() *statementsMonitorState {
+	return &statementsMonitorState{
+		stats:     map[string]postgresStatementStat{},
+		clients:   map[statementsClient]float64{},
+		errors:    map[statementsError]float64{},
+		responses: map[string]*statementsResponses{},
+	}
+}
+
+// updateMonitor collects statements statistics from pg_stat_monitor.
+func (c *postgresStatementsCollector) updateMonitor(config Config, ch chan<- prometheus.Metric) error {
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	pgconfig.Database = config.pgStatMonitorDatabase
+
+	conn, err := store.NewWithConfig(pgconfig)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if c.monitor == nil {
+		c.monitor = newStatementsMonitorState()
+	}
+
+	// Response time ranges are configured at Postgres start, hence they are requested once.
+	if c.monitor.bounds == nil {
+		res, err := conn.Query(fmt.Sprintf(postgresStatementsMonitorRangesQuery, config.pgStatMonitorSchema))
+		if err != nil {
+			return err
+		}
+
+		c.monitor.bounds = parseStatementsMonitorRanges(res)
+	}
+
+	schema := config.pgStatMonitorSchema
+	res, err := conn.Query(fmt.Sprintf(postgresStatementsMonitorQuery, schema, schema, c.monitor.lastBucket))
+	if err != nil {
+		return err
+	}
+
+	c.monitor.update(res)
+
+	c.sendStats(config, c.monitor.stats, ch)
+
+	for k, v := range c.monitor.clients {
+		ch <- c.clientCalls.newConstMetric(v, k.user, k.database, k.clientIP)
+	}
+
+	for k, v := range c.monitor.errors {
+		ch <- c.errors.newConstMetric(v, k.user, k.database, k.sqlcode)
+	}
+
+	for database, r := range c.monitor.responses {
+		count, buckets := r.histogram(c.monitor.bounds)
+		ch <- c.responseTime.newConstHistogram(count, r.sum, buckets, database)
+	}
+
+	return nil
+}
+
+// update accumulates statistics of buckets from passed query result.
+func (s *statementsMonitorState) update(r *model.PGResult) {
+	log.Debug("parse postgres statements monitor stats")
+
+	lastBucket := s.lastBucket
+
+	// NULL and invalid values are considered as zero.
+	toFloat := func(v sql.NullString) float64 {
+		f, _ := strconv.ParseFloat(v.String, 64)
+		return f
+	}
+
+	for _, row := range r.Rows {
+		parsePostgresStatementsRow(s.stats, r.Colnames, row, statementsMonitorLabels)
+
+		var database, user, clientIP, sqlcode, respCalls string
+		var bucket, elevel, calls, execTime float64
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				database = row[i].String
+			case "user":
+				user = row[i].String
+			case "client_ip":
+				clientIP = row[i].String
+			case "sqlcode":
+				sqlcode = row[i].String
+			case "resp_calls":
+				respCalls = row[i].String
+			case "bucket_start":
+				bucket = toFloat(row[i])
+			case "elevel":
+				elevel = toFloat(row[i])
+			case "calls":
+				calls = toFloat(row[i])
+			case "total_exec_time":
+				execTime = toFloat(row[i])
+			}
+		}
+
+		if bucket > lastBucket {
+			lastBucket = bucket
+		}
+
+		s.clients[statementsClient{user: user, database: database, clientIP: clientIP}] += calls
+
+		if elevel > 0 {
+			s.errors[statementsError{user: user, database: database, sqlcode: sqlcode}] += calls
+		}
+
+		resp, ok := s.responses[database]
+		if !ok {
+			resp = &statementsResponses{}
+			s.responses[database] = resp
+		}
+
+		resp.sum += execTime
+		for i, v := range strings.Split(respCalls, ",") {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			for len(resp.calls) <= i {
+				resp.calls = append(resp.calls, 0)
+			}
+			resp.calls[i] += n
+		}
+	}
+
+	s.lastBucket = lastBucket
+}
+
+// histogram returns total number of calls and cumulative number of calls per bucket with passed upper bounds. Calls
+// of ranges without known upper bound are accounted in total number only.
+func (r *statementsResponses) histogram(bounds []float64) (uint64, map[float64]uint64) {
+	var count, cumulative uint64
+	buckets := make(map[float64]uint64, len(bounds))
+
+	for i, n := range r.calls {
+		count += n
+		if i < len(bounds) {
+			cumulative += n
+			buckets[bounds[i]] = cumulative
+		}
+	}
+
+	for _, b := range bounds {
+		if _, ok := buckets[b]; !ok {
+			buckets[b] = cumulative
+		}
+	}
+
+	return count, buckets
+}
+
+// rangeBoundRE matches numbers in response time range description.
+var rangeBoundRE = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+
+// parseStatementsMonitorRanges parses response time ranges of pg_stat_monitor histograms and returns their upper
+// bounds in seconds. Parsing is stopped at the first range without finite increasing upper bound.
+func parseStatementsMonitorRanges(r *model.PGResult) []float64 {
+	bounds := []float64{}
+
+	for _, row := range r.Rows {
+		// Upper bound is the last number in the range, in milliseconds.
+		numbers := rangeBoundRE.FindAllString(row[0].String, -1)
+		if len(numbers) == 0 {
+			break
+		}
+
+		v, err := strconv.ParseFloat(numbers[len(numbers)-1], 64)
+		if err != nil || math.IsInf(v, 0) {
+			break
+		}
+
+		v = v / 1000
+		if len(bounds) > 0 && v <= bounds[len(bounds)-1] {
+			break
+		}
+
+		bounds = append(bounds, v)
+	}
+
+	return bounds
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_statementsMonitorState_update(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 11,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("bucket_start")}, {Name: []byte("database")}, {Name: []byte("user")}, {Name: []byte("client_ip")},
+			{Name: []byte("queryid")}, {Name: []byte("query")}, {Name: []byte("elevel")}, {Name: []byte("sqlcode")},
+			{Name: []byte("resp_calls")}, {Name: []byte("calls")}, {Name: []byte("total_exec_time")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1000", Valid: true}, {String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "10.0.0.1", Valid: true},
+				{String: "1", Valid: true}, {String: "SELECT test", Valid: true}, {String: "0", Valid: true}, {String: "", Valid: true},
+				{String: "8,2,0", Valid: true}, {String: "10", Valid: true}, {String: "100", Valid: true},
+			},
+			{
+				{String: "1060", Valid: true}, {String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "10.0.0.2", Valid: true},
+				{String: "1", Valid: true}, {String: "SELECT test", Valid: true}, {String: "0", Valid: true}, {String: "", Valid: true},
+				{String: "1,1,1", Valid: true}, {String: "3", Valid: true}, {String: "300", Valid: true},
+			},
+			{
+				{String: "1060", Valid: true}, {String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "10.0.0.2", Valid: true},
+				{String: "2", Valid: true}, {String: "SELECT invalid", Valid: true}, {String: "21", Valid: true}, {String: "42601", Valid: true},
+				{String: "1,0,0", Valid: true}, {String: "1", Valid: true}, {String: "1", Valid: true},
+			},
+		},
+	}
+
+	s := newStatementsMonitorState()
+	s.update(res)
+
+	assert.Equal(t, float64(1060), s.lastBucket)
+	assert.Equal(t, float64(13), s.stats["testdb/testuser/1"].calls)
+	assert.Equal(t, float64(400), s.stats["testdb/testuser/1"].totalExecTime)
+	assert.Equal(t, map[statementsClient]float64{
+		{user: "testuser", database: "testdb", clientIP: "10.0.0.1"}: 10,
+		{user: "testuser", database: "testdb", clientIP: "10.0.0.2"}: 4,
+	}, s.clients)
+	assert.Equal(t, map[statementsError]float64{
+		{user: "testuser", database: "testdb", sqlcode: "42601"}: 1,
+	}, s.errors)
+	assert.Equal(t, []uint64{10, 3, 1}, s.responses["testdb"].calls)
+	assert.Equal(t, float64(401), s.responses["testdb"].sum)
+
+	// Counters are accumulated over updates.
+	s.update(&model.PGResult{Colnames: res.Colnames, Rows: res.Rows[:1]})
+	assert.Equal(t, float64(23), s.stats["testdb/testuser/1"].calls)
+	assert.Equal(t, []uint64{18, 5, 1}, s.responses["testdb"].calls)
+}
+
+func Test_statementsResponses_histogram(t *testing.T) {
+	r := &statementsResponses{calls: []uint64{10, 3, 1}}
+
+	count, buckets := r.histogram([]float64{.001, .01})
+	assert.Equal(t, uint64(14), count)
+	assert.Equal(t, map[float64]uint64{.001: 10, .01: 13}, buckets)
+
+	// No calls yet.
+	count, buckets = (&statementsResponses{}).histogram([]float64{.001, .01})
+	assert.Equal(t, uint64(0), count)
+	assert.Equal(t, map[float64]uint64{.001: 0, .01: 0}, buckets)
+}
+
+func Test_parseStatementsMonitorRanges(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    4,
+		Ncols:    1,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("range")}},
+		Rows: [][]sql.NullString{
+			{{String: "{0.000 - 1.000}", Valid: true}},
+			{{String: "{1.000 - 10.000}", Valid: true}},
+			{{String: "{10.000 - 100.500}", Valid: true}},
+			{{String: "{100.500 - ...}", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []float64{.001, .01, .1005}, parseStatementsMonitorRanges(res))
+	assert.Equal(t, []float64{}, parseStatementsMonitorRanges(&model.PGResult{}))
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -17,6 +19,7 @@ func TestPostgresStatementsCollector_Update(t *testing.T) {
 			"postgres_statements_rows_total",
 			"postgres_statements_time_seconds_total",
 			"postgres_statements_time_seconds_all_total",
+			"postgres_statements_latency_seconds",
 		},
 		optional: []string{
 			"postgres_statements_shared_buffers_hit_total",
@@ -30,8 +33,15 @@ func TestPostgresStatementsCollector_Update(t *testing.T) {
 			"postgres_statements_temp_read_bytes_total",
 			"postgres_statements_temp_written_bytes_total",
 			"postgres_statements_wal_records_total",
+			"postgres_statements_wal_fpi_total",
 			"postgres_statements_wal_bytes_all_total",
 			"postgres_statements_wal_bytes_total",
+			"postgres_statements_plans_total",
+			"postgres_statements_plan_time_seconds",
+			"postgres_statements_stats_reset_time",
+			"postgres_statements_client_calls_total",
+			"postgres_statements_errors_total",
+			"postgres_statements_response_time_seconds",
 		},
 		collector: NewPostgresStatementsCollector,
 		service:   model.ServiceTypePostgresql,
@@ -118,6 +128,37 @@ func Test_parsePostgresStatementsStats(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "planning stats of top-level and nested rows",
+			res: &model.PGResult{
+				Nrows: 2,
+				Ncols: 11,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("database")}, {Name: []byte("user")}, {Name: []byte("queryid")}, {Name: []byte("query")},
+					{Name: []byte("calls")}, {Name: []byte("total_exec_time")}, {Name: []byte("total_plan_time")},
+					{Name: []byte("plans")}, {Name: []byte("min_plan_time")}, {Name: []byte("max_plan_time")}, {Name: []byte("wal_records")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "example_queryid", Valid: true}, {String: "SELECT test", Valid: true},
+						{String: "1000", Valid: true}, {String: "30000", Valid: true}, {String: "100", Valid: true},
+						{String: "900", Valid: true}, {String: "0.05", Valid: true}, {String: "2", Valid: true}, {},
+					},
+					{
+						{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "example_queryid", Valid: true}, {String: "SELECT test", Valid: true},
+						{String: "100", Valid: true}, {String: "3000", Valid: true}, {String: "20", Valid: true},
+						{String: "100", Valid: true}, {String: "0.02", Valid: true}, {String: "1", Valid: true}, {},
+					},
+				},
+			},
+			want: map[string]postgresStatementStat{
+				"testdb/testuser/example_queryid": {
+					database: "testdb", user: "testuser", queryid: "example_queryid", query: "SELECT test",
+					calls: 1100, totalExecTime: 33000, totalPlanTime: 120,
+					plans: 1000, minPlanTime: 0.02, maxPlanTime: 2,
+				},
+			},
+		},
 		{
 			name: "lot of nulls and unknown columns",
 			res: &model.PGResult{
@@ -174,3 +215,55 @@ func Test_selectStatementsQuery(t *testing.T) {
 		assert.Equal(t, tc.want, selectStatementsQuery(tc.version, "example"))
 	}
 }
+
+func Test_statementsLatencyHistogram(t *testing.T) {
+	stats := map[string]postgresStatementStat{
+		"db/user/1": {calls: 100, totalExecTime: 200}, // 2ms mean
+		"db/user/2": {calls: 10, totalExecTime: 2000}, // 200ms mean
+		"db/user/3": {calls: 1, totalExecTime: 20000}, // 20s mean
+		"db/user/4": {calls: 0, totalExecTime: 0},     // never called
+	}
+
+	count, sum, buckets := statementsLatencyHistogram(stats, []float64{.001, .01, 1})
+	assert.Equal(t, uint64(111), count)
+	assert.Equal(t, float64(22200), sum)
+	assert.Equal(t, map[float64]uint64{.001: 0, .01: 100, 1: 110}, buckets)
+}
+
+func TestPostgresStatementsCollector_sendStats_nullValues(t *testing.T) {
+	stats := map[string]postgresStatementStat{
+		"testdb/testuser/1": {database: "testdb", user: "testuser", queryid: "1", query: "SELECT 1", calls: 10, totalExecTime: 100},
+	}
+
+	testcases := []struct {
+		policy string
+		want   int
+	}{
+		{policy: model.NullValuesSkip, want: 0},
+		{policy: model.NullValuesZero, want: 2},
+		{policy: model.NullValuesFlag, want: 2},
+	}
+
+	for _, tc := range testcases {
+		c, err := NewPostgresStatementsCollector(labels{}, model.CollectorSettings{NullValues: tc.policy})
+		assert.NoError(t, err)
+
+		ch := make(chan prometheus.Metric, 100)
+		c.(*postgresStatementsCollector).sendStats(Config{postgresServiceConfig: postgresServiceConfig{blockSize: 8192}}, stats, ch)
+		close(ch)
+
+		// Count series of not tracked IO time, labeled with 'ioread' and 'iowrite' modes.
+		var got int
+		for m := range ch {
+			var metric dto.Metric
+			assert.NoError(t, m.Write(&metric))
+			for _, l := range metric.GetLabel() {
+				if l.GetName() == "mode" && (l.GetValue() == "ioread" || l.GetValue() == "iowrite") {
+					got++
+				}
+			}
+		}
+
+		assert.Equal(t, tc.want, got, tc.policy)
+	}
+}
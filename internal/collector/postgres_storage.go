@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 )
@@ -100,6 +101,22 @@ func NewPostgresStorageCollector(constLabels labels, settings model.CollectorSet
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresStorageCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.tempFiles,
+		c.tempBytes,
+		c.tempFilesMaxAge,
+		c.datadirBytes,
+		c.tblspcBytes,
+		c.waldirBytes,
+		c.waldirFiles,
+		c.logdirBytes,
+		c.logdirFiles,
+		c.tmpfilesBytes,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics.
 func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	// Following directory listing functions are available since:
@@ -140,6 +157,12 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 		return nil
 	}
 
+	// Directories metrics rely on /proc/mounts, which is available on Linux only.
+	if runtime.GOOS != "linux" {
+		log.Debugln("[postgres storage collector]: skip collecting directories metrics on non-Linux platform")
+		return nil
+	}
+
 	// Collecting other server-directories stats (DATADIR and tablespaces, WALDIR, LOGDIR, TEMPDIR).
 	dirstats, tblspcStats, err := newPostgresDirStat(conn, config.dataDirectory, config.loggingCollector, config.serverVersionNum)
 	if err != nil {
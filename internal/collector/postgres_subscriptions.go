@@ -0,0 +1,166 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// subscriptionWorkersQuery defines query for querying running workers of logical replication subscriptions.
+	// Relations of other databases can't be resolved, their OIDs are used instead.
+	subscriptionWorkersQuery = "SELECT s.subname AS subscription, " +
+		"CASE WHEN s.relid IS NULL THEN 'apply' ELSE 'sync' END AS worker, " +
+		"CASE WHEN s.relid IS NULL THEN '' " +
+		"WHEN su.subdbid = (SELECT oid FROM pg_database WHERE datname = current_database()) THEN s.relid::regclass::text " +
+		"ELSE s.relid::text END AS relation, " +
+		"s.received_lsn - '0/0' AS received_lsn, s.latest_end_lsn - '0/0' AS reported_lsn, " +
+		"extract(epoch FROM clock_timestamp() - s.last_msg_send_time) AS send_age_seconds, " +
+		"extract(epoch FROM clock_timestamp() - s.last_msg_receipt_time) AS receipt_age_seconds, " +
+		"extract(epoch FROM clock_timestamp() - s.latest_end_time) AS reported_age_seconds " +
+		"FROM pg_stat_subscription s JOIN pg_subscription su ON su.oid = s.subid WHERE s.pid IS NOT NULL"
+
+	// subscriptionErrorsQuery defines query for querying errors of logical replication subscriptions, for Postgres
+	// 15 and newer.
+	subscriptionErrorsQuery = "SELECT subname AS subscription, apply_error_count, sync_error_count FROM pg_stat_subscription_stats"
+)
+
+// postgresSubscriptionsCollector defines metric descriptors for logical replication subscriptions.
+type postgresSubscriptionsCollector struct {
+	lsn    typedDesc
+	age    typedDesc
+	errors typedDesc
+}
+
+// NewPostgresSubscriptionsCollector returns a new Collector exposing stats of logical replication subscriptions
+// workers and their errors.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-SUBSCRIPTION
+func NewPostgresSubscriptionsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresSubscriptionsCollector{
+		lsn: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "lsn_bytes", "WAL location of the upstream server received by worker and reported to the upstream, by type (received, reported), in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"subscription", "worker", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		age: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "message_age_seconds", "Time since the last message has been sent by the upstream, received by worker and reported back to the upstream, by type (send, receipt, report), in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"subscription", "worker", "relation", "type"}, constLabels,
+			settings.Filters,
+		),
+		errors: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "errors_total", "Total number of errors occurred while applying changes or during initial table synchronization, by type (apply, sync).", 0},
+			prometheus.CounterValue,
+			[]string{"subscription", "type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresSubscriptionsCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.lsn,
+		c.age,
+		c.errors,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresSubscriptionsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV10 {
+		log.Debugln("[postgres subscriptions collector]: logical replication is not supported, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(subscriptionWorkersQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range parsePostgresSubscriptionWorkers(res) {
+		ch <- c.lsn.newConstMetric(w.ReceivedLSN, w.Subscription, w.Worker, w.Relation, "received")
+		ch <- c.lsn.newConstMetric(w.ReportedLSN, w.Subscription, w.Worker, w.Relation, "reported")
+		ch <- c.age.newConstMetric(w.SendAge, w.Subscription, w.Worker, w.Relation, "send")
+		ch <- c.age.newConstMetric(w.ReceiptAge, w.Subscription, w.Worker, w.Relation, "receipt")
+		ch <- c.age.newConstMetric(w.ReportedAge, w.Subscription, w.Worker, w.Relation, "report")
+	}
+
+	// Subscriptions errors statistics is available since Postgres 15.
+	if config.serverVersionNum < PostgresV15 {
+		return nil
+	}
+
+	res, err = conn.Query(subscriptionErrorsQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresSubscriptionErrors(res) {
+		ch <- c.errors.newConstMetric(stat.ApplyErrors, stat.Subscription, "apply")
+		ch <- c.errors.newConstMetric(stat.SyncErrors, stat.Subscription, "sync")
+	}
+
+	return nil
+}
+
+// postgresSubscriptionWorker represents stats of a single subscription worker.
+type postgresSubscriptionWorker struct {
+	Subscription string  `column:"subscription"`
+	Worker       string  `column:"worker"`
+	Relation     string  `column:"relation"`
+	ReceivedLSN  float64 `column:"received_lsn"`
+	ReportedLSN  float64 `column:"reported_lsn"`
+	SendAge      float64 `column:"send_age_seconds"`
+	ReceiptAge   float64 `column:"receipt_age_seconds"`
+	ReportedAge  float64 `column:"reported_age_seconds"`
+}
+
+// parsePostgresSubscriptionWorkers parses PGResult and returns structs with subscription workers stats.
+func parsePostgresSubscriptionWorkers(r *model.PGResult) []postgresSubscriptionWorker {
+	log.Debug("parse postgres subscription workers")
+
+	workers := make([]postgresSubscriptionWorker, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		worker := postgresSubscriptionWorker{}
+		if err := scanRow(r.Colnames, row, &worker); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		workers = append(workers, worker)
+	}
+
+	return workers
+}
+
+// postgresSubscriptionErrors represents errors of a single subscription.
+type postgresSubscriptionErrors struct {
+	Subscription string  `column:"subscription"`
+	ApplyErrors  float64 `column:"apply_error_count"`
+	SyncErrors   float64 `column:"sync_error_count"`
+}
+
+// parsePostgresSubscriptionErrors parses PGResult and returns structs with subscription errors.
+func parsePostgresSubscriptionErrors(r *model.PGResult) []postgresSubscriptionErrors {
+	log.Debug("parse postgres subscription errors")
+
+	stats := make([]postgresSubscriptionErrors, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresSubscriptionErrors{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
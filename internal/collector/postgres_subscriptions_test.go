@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresSubscriptionsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_subscription_lsn_bytes",
+			"postgres_subscription_message_age_seconds",
+			"postgres_subscription_errors_total",
+		},
+		collector: NewPostgresSubscriptionsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresSubscriptionWorkers(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 8,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("subscription")}, {Name: []byte("worker")}, {Name: []byte("relation")},
+			{Name: []byte("received_lsn")}, {Name: []byte("reported_lsn")}, {Name: []byte("send_age_seconds")},
+			{Name: []byte("receipt_age_seconds")}, {Name: []byte("reported_age_seconds")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "sub1", Valid: true}, {String: "apply", Valid: true}, {String: "", Valid: true},
+				{String: "83886080", Valid: true}, {String: "83886000", Valid: true}, {String: "1.5", Valid: true},
+				{String: "1.2", Valid: true}, {String: "10", Valid: true},
+			},
+			{
+				{String: "sub1", Valid: true}, {String: "sync", Valid: true}, {String: "orders", Valid: true},
+				{String: "", Valid: false}, {String: "", Valid: false}, {String: "", Valid: false},
+				{String: "", Valid: false}, {String: "", Valid: false},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresSubscriptionWorker{
+		{
+			Subscription: "sub1", Worker: "apply", ReceivedLSN: 83886080, ReportedLSN: 83886000,
+			SendAge: 1.5, ReceiptAge: 1.2, ReportedAge: 10,
+		},
+		{Subscription: "sub1", Worker: "sync", Relation: "orders"},
+	}, parsePostgresSubscriptionWorkers(res))
+}
+
+func Test_parsePostgresSubscriptionErrors(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("subscription")}, {Name: []byte("apply_error_count")}, {Name: []byte("sync_error_count")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "sub1", Valid: true}, {String: "3", Valid: true}, {String: "1", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, []postgresSubscriptionErrors{
+		{Subscription: "sub1", ApplyErrors: 3, SyncErrors: 1},
+	}, parsePostgresSubscriptionErrors(res))
+}
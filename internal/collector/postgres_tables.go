@@ -1,12 +1,13 @@
 package collector
 
 import (
+	"fmt"
 	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/filter"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
 	"github.com/lesovsky/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
-	"strconv"
 	"strings"
 )
 
@@ -20,9 +21,15 @@ const (
 		"extract('epoch' from greatest(last_analyze, last_autoanalyze)) AS last_analyze_time," +
 		"vacuum_count, autovacuum_count, analyze_count, autoanalyze_count, heap_blks_read, heap_blks_hit, idx_blks_read, " +
 		"idx_blks_hit, toast_blks_read, toast_blks_hit, tidx_blks_read, tidx_blks_hit, " +
-		"pg_table_size(s1.relid) AS size_bytes, reltuples " +
-		"FROM pg_stat_user_tables s1 JOIN pg_statio_user_tables s2 USING (schemaname, relname) JOIN pg_class c ON s1.relid = c.oid " +
+		"pg_table_size(s1.relid) AS size_bytes, reltuples%s " +
+		"FROM pg_stat_user_tables s1 JOIN pg_statio_user_tables s2 USING (schemaname, relname) JOIN pg_class c ON s1.relid = c.oid%s " +
 		"WHERE NOT EXISTS (SELECT 1 FROM pg_locks WHERE relation = s1.relid AND mode = 'AccessExclusiveLock' AND granted)"
+
+	// userTablesPartitionsColumns and userTablesPartitionsJoin extend tables query with root tables of partitions,
+	// supported by Postgres 12 and newer.
+	userTablesPartitionsColumns = ", pn.nspname AS parent_schema, p.relname AS parent_table"
+	userTablesPartitionsJoin    = " LEFT JOIN pg_class p ON p.oid = pg_partition_root(s1.relid) AND p.oid <> s1.relid " +
+		"LEFT JOIN pg_namespace pn ON pn.oid = p.relnamespace"
 )
 
 // postgresTablesCollector defines metric descriptors and stats store.
@@ -47,6 +54,12 @@ type postgresTablesCollector struct {
 	sizes                typedDesc
 	reltuples            typedDesc
 	labelNames           []string
+	filters              filter.Filters
+	rowsLimit            int
+	relations            int
+	truncated            typedDesc
+	// aggregatePartitions defines stats of partitions are aggregated into stats of their root tables.
+	aggregatePartitions bool
 }
 
 // NewPostgresTablesCollector returns a new Collector exposing postgres tables stats.
@@ -58,6 +71,11 @@ func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSett
 
 	return &postgresTablesCollector{
 		labelNames: labels,
+		filters:    settings.Filters,
+		rowsLimit:  settings.RowsLimit,
+		truncated:  newRowsTruncatedDesc(constLabels),
+
+		aggregatePartitions: settings.AggregatePartitions,
 		seqscan: newBuiltinTypedDesc(
 			descOpts{"postgres", "table", "seq_scan_total", "The total number of sequential scans have been done.", 0},
 			prometheus.CounterValue,
@@ -138,13 +156,13 @@ func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSett
 		),
 		maintLastVacuumTime: newBuiltinTypedDesc(
 			descOpts{"postgres", "table", "last_vacuum_time", "Time of last vacuum or autovacuum has been done (not counting VACUUM FULL), in unixtime.", 0},
-			prometheus.CounterValue,
+			prometheus.GaugeValue,
 			labels, constLabels,
 			settings.Filters,
 		),
 		maintLastAnalyzeTime: newBuiltinTypedDesc(
 			descOpts{"postgres", "table", "last_analyze_time", "Time of last analyze or autoanalyze has been done, in unixtime.", 0},
-			prometheus.CounterValue,
+			prometheus.GaugeValue,
 			labels, constLabels,
 			settings.Filters,
 		),
@@ -175,28 +193,53 @@ func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSett
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresTablesCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.seqscan,
+		c.seqtupread,
+		c.idxscan,
+		c.idxtupfetch,
+		c.tupInserted,
+		c.tupUpdated,
+		c.tupHotUpdated,
+		c.tupDeleted,
+		c.tupLive,
+		c.tupDead,
+		c.tupModified,
+		c.maintLastVacuumAge,
+		c.maintLastAnalyzeAge,
+		c.maintLastVacuumTime,
+		c.maintLastAnalyzeTime,
+		c.maintenance,
+		c.io,
+		c.sizes,
+		c.reltuples,
+		c.truncated,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+	databases, err := listServiceDatabases(config)
 	if err != nil {
 		return err
 	}
 
-	databases, err := listDatabases(conn)
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
 	if err != nil {
 		return err
 	}
 
-	conn.Close()
+	var truncated float64
+	c.relations = 0
 
-	pgconfig, err := pgx.ParseConfig(config.ConnString)
-	if err != nil {
-		return err
-	}
+	aggregate := c.aggregatePartitions && config.serverVersionNum >= PostgresV12
+	query := selectTablesQuery(aggregate)
 
 	for _, d := range databases {
-		// Skip database if not matched to allowed.
-		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
+		// Skip database if it is rejected by collector's filters, avoid connecting to it.
+		if !c.filters.Pass("database", d) {
 			continue
 		}
 
@@ -206,241 +249,235 @@ func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Met
 			return err
 		}
 
-		res, err := conn.Query(userTablesQuery)
+		res, err := conn.Query(limitRowsQuery(query, "size_bytes", c.rowsLimit))
 		conn.Close()
 		if err != nil {
 			log.Warnf("get tables stat of database '%s' failed: %s; skip", d, err)
 			continue
 		}
 
-		stats := parsePostgresTableStats(res, c.labelNames)
+		if truncateResult(res, c.rowsLimit) {
+			truncated = 1
+		}
+
+		c.relations += res.Nrows
+
+		stats := parsePostgresTableStats(res)
+		if aggregate {
+			stats = aggregatePartitionsStats(stats)
+		}
 
 		for _, stat := range stats {
 			// scan stats
-			ch <- c.seqscan.newConstMetric(stat.seqscan, stat.database, stat.schema, stat.table)
-			ch <- c.seqtupread.newConstMetric(stat.seqtupread, stat.database, stat.schema, stat.table)
-			ch <- c.idxscan.newConstMetric(stat.idxscan, stat.database, stat.schema, stat.table)
-			ch <- c.idxtupfetch.newConstMetric(stat.idxtupfetch, stat.database, stat.schema, stat.table)
+			ch <- c.seqscan.newConstMetric(stat.Seqscan, stat.Database, stat.Schema, stat.Table)
+			ch <- c.seqtupread.newConstMetric(stat.Seqtupread, stat.Database, stat.Schema, stat.Table)
+			ch <- c.idxscan.newConstMetric(stat.Idxscan, stat.Database, stat.Schema, stat.Table)
+			ch <- c.idxtupfetch.newConstMetric(stat.Idxtupfetch, stat.Database, stat.Schema, stat.Table)
 
 			// tuples stats
-			ch <- c.tupInserted.newConstMetric(stat.inserted, stat.database, stat.schema, stat.table)
-			ch <- c.tupUpdated.newConstMetric(stat.updated, stat.database, stat.schema, stat.table)
-			ch <- c.tupDeleted.newConstMetric(stat.deleted, stat.database, stat.schema, stat.table)
-			ch <- c.tupHotUpdated.newConstMetric(stat.hotUpdated, stat.database, stat.schema, stat.table)
+			ch <- c.tupInserted.newConstMetric(stat.Inserted, stat.Database, stat.Schema, stat.Table)
+			ch <- c.tupUpdated.newConstMetric(stat.Updated, stat.Database, stat.Schema, stat.Table)
+			ch <- c.tupDeleted.newConstMetric(stat.Deleted, stat.Database, stat.Schema, stat.Table)
+			ch <- c.tupHotUpdated.newConstMetric(stat.HotUpdated, stat.Database, stat.Schema, stat.Table)
 
 			// tuples total stats
-			ch <- c.tupLive.newConstMetric(stat.live, stat.database, stat.schema, stat.table)
-			ch <- c.tupDead.newConstMetric(stat.dead, stat.database, stat.schema, stat.table)
-			ch <- c.tupModified.newConstMetric(stat.modified, stat.database, stat.schema, stat.table)
+			ch <- c.tupLive.newConstMetric(stat.Live, stat.Database, stat.Schema, stat.Table)
+			ch <- c.tupDead.newConstMetric(stat.Dead, stat.Database, stat.Schema, stat.Table)
+			ch <- c.tupModified.newConstMetric(stat.Modified, stat.Database, stat.Schema, stat.Table)
 
 			// maintenance stats -- avoid metrics spam produced by inactive tables, don't send metrics if counters are zero.
-			if stat.lastvacuumAge > 0 {
-				ch <- c.maintLastVacuumAge.newConstMetric(stat.lastvacuumAge, stat.database, stat.schema, stat.table)
+			if stat.LastvacuumAge > 0 {
+				ch <- c.maintLastVacuumAge.newConstMetric(stat.LastvacuumAge, stat.Database, stat.Schema, stat.Table)
 			}
-			if stat.lastanalyzeAge > 0 {
-				ch <- c.maintLastAnalyzeAge.newConstMetric(stat.lastanalyzeAge, stat.database, stat.schema, stat.table)
+			if stat.LastanalyzeAge > 0 {
+				ch <- c.maintLastAnalyzeAge.newConstMetric(stat.LastanalyzeAge, stat.Database, stat.Schema, stat.Table)
 			}
-			if stat.lastvacuumTime > 0 {
-				ch <- c.maintLastVacuumTime.newConstMetric(stat.lastvacuumTime, stat.database, stat.schema, stat.table)
+			if stat.LastvacuumTime > 0 {
+				ch <- c.maintLastVacuumTime.newConstMetric(stat.LastvacuumTime, stat.Database, stat.Schema, stat.Table)
 			}
-			if stat.lastanalyzeTime > 0 {
-				ch <- c.maintLastAnalyzeTime.newConstMetric(stat.lastanalyzeTime, stat.database, stat.schema, stat.table)
+			if stat.LastanalyzeTime > 0 {
+				ch <- c.maintLastAnalyzeTime.newConstMetric(stat.LastanalyzeTime, stat.Database, stat.Schema, stat.Table)
 			}
-			if stat.vacuum > 0 {
-				ch <- c.maintenance.newConstMetric(stat.vacuum, stat.database, stat.schema, stat.table, "vacuum")
+			if stat.Vacuum > 0 {
+				ch <- c.maintenance.newConstMetric(stat.Vacuum, stat.Database, stat.Schema, stat.Table, "vacuum")
 			}
-			if stat.autovacuum > 0 {
-				ch <- c.maintenance.newConstMetric(stat.autovacuum, stat.database, stat.schema, stat.table, "autovacuum")
+			if stat.Autovacuum > 0 {
+				ch <- c.maintenance.newConstMetric(stat.Autovacuum, stat.Database, stat.Schema, stat.Table, "autovacuum")
 			}
-			if stat.analyze > 0 {
-				ch <- c.maintenance.newConstMetric(stat.analyze, stat.database, stat.schema, stat.table, "analyze")
+			if stat.Analyze > 0 {
+				ch <- c.maintenance.newConstMetric(stat.Analyze, stat.Database, stat.Schema, stat.Table, "analyze")
 			}
-			if stat.autoanalyze > 0 {
-				ch <- c.maintenance.newConstMetric(stat.autoanalyze, stat.database, stat.schema, stat.table, "autoanalyze")
+			if stat.Autoanalyze > 0 {
+				ch <- c.maintenance.newConstMetric(stat.Autoanalyze, stat.Database, stat.Schema, stat.Table, "autoanalyze")
 			}
 
 			// io stats -- avoid metrics spam produced by inactive tables, don't send metrics if counters are zero.
-			if stat.heapread > 0 {
-				ch <- c.io.newConstMetric(stat.heapread, stat.database, stat.schema, stat.table, "heap", "read")
+			if stat.Heapread > 0 {
+				ch <- c.io.newConstMetric(stat.Heapread, stat.Database, stat.Schema, stat.Table, "heap", "read")
 			}
-			if stat.heaphit > 0 {
-				ch <- c.io.newConstMetric(stat.heaphit, stat.database, stat.schema, stat.table, "heap", "hit")
+			if stat.Heaphit > 0 {
+				ch <- c.io.newConstMetric(stat.Heaphit, stat.Database, stat.Schema, stat.Table, "heap", "hit")
 			}
-			if stat.idxread > 0 {
-				ch <- c.io.newConstMetric(stat.idxread, stat.database, stat.schema, stat.table, "idx", "read")
+			if stat.Idxread > 0 {
+				ch <- c.io.newConstMetric(stat.Idxread, stat.Database, stat.Schema, stat.Table, "idx", "read")
 			}
-			if stat.idxhit > 0 {
-				ch <- c.io.newConstMetric(stat.idxhit, stat.database, stat.schema, stat.table, "idx", "hit")
+			if stat.Idxhit > 0 {
+				ch <- c.io.newConstMetric(stat.Idxhit, stat.Database, stat.Schema, stat.Table, "idx", "hit")
 			}
-			if stat.toastread > 0 {
-				ch <- c.io.newConstMetric(stat.toastread, stat.database, stat.schema, stat.table, "toast", "read")
+			if stat.Toastread > 0 {
+				ch <- c.io.newConstMetric(stat.Toastread, stat.Database, stat.Schema, stat.Table, "toast", "read")
 			}
-			if stat.toasthit > 0 {
-				ch <- c.io.newConstMetric(stat.toasthit, stat.database, stat.schema, stat.table, "toast", "hit")
+			if stat.Toasthit > 0 {
+				ch <- c.io.newConstMetric(stat.Toasthit, stat.Database, stat.Schema, stat.Table, "toast", "hit")
 			}
-			if stat.tidxread > 0 {
-				ch <- c.io.newConstMetric(stat.tidxread, stat.database, stat.schema, stat.table, "tidx", "read")
+			if stat.Tidxread > 0 {
+				ch <- c.io.newConstMetric(stat.Tidxread, stat.Database, stat.Schema, stat.Table, "tidx", "read")
 			}
-			if stat.tidxhit > 0 {
-				ch <- c.io.newConstMetric(stat.tidxhit, stat.database, stat.schema, stat.table, "tidx", "hit")
+			if stat.Tidxhit > 0 {
+				ch <- c.io.newConstMetric(stat.Tidxhit, stat.Database, stat.Schema, stat.Table, "tidx", "hit")
 			}
 
-			ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database, stat.schema, stat.table)
-			ch <- c.reltuples.newConstMetric(stat.reltuples, stat.database, stat.schema, stat.table)
+			ch <- c.sizes.newConstMetric(stat.Sizebytes, stat.Database, stat.Schema, stat.Table)
+			ch <- c.reltuples.newConstMetric(stat.Reltuples, stat.Database, stat.Schema, stat.Table)
 		}
 	}
 
+	if c.rowsLimit > 0 {
+		ch <- c.truncated.newConstMetric(truncated, "postgres/tables")
+	}
+
 	return nil
 }
 
+// relationsCount returns number of tables processed during the last update.
+func (c *postgresTablesCollector) relationsCount() int {
+	return c.relations
+}
+
 // postgresTableStat is per-table store for metrics related to how tables are accessed.
 type postgresTableStat struct {
-	database        string
-	schema          string
-	table           string
-	seqscan         float64
-	seqtupread      float64
-	idxscan         float64
-	idxtupfetch     float64
-	inserted        float64
-	updated         float64
-	deleted         float64
-	hotUpdated      float64
-	live            float64
-	dead            float64
-	modified        float64
-	lastvacuumAge   float64
-	lastanalyzeAge  float64
-	lastvacuumTime  float64
-	lastanalyzeTime float64
-	vacuum          float64
-	autovacuum      float64
-	analyze         float64
-	autoanalyze     float64
-	heapread        float64
-	heaphit         float64
-	idxread         float64
-	idxhit          float64
-	toastread       float64
-	toasthit        float64
-	tidxread        float64
-	tidxhit         float64
-	sizebytes       float64
-	reltuples       float64
+	Database        string  `column:"database"`
+	Schema          string  `column:"schema"`
+	Table           string  `column:"table"`
+	Seqscan         float64 `column:"seq_scan"`
+	Seqtupread      float64 `column:"seq_tup_read"`
+	Idxscan         float64 `column:"idx_scan"`
+	Idxtupfetch     float64 `column:"idx_tup_fetch"`
+	Inserted        float64 `column:"n_tup_ins"`
+	Updated         float64 `column:"n_tup_upd"`
+	Deleted         float64 `column:"n_tup_del"`
+	HotUpdated      float64 `column:"n_tup_hot_upd"`
+	Live            float64 `column:"n_live_tup"`
+	Dead            float64 `column:"n_dead_tup"`
+	Modified        float64 `column:"n_mod_since_analyze"`
+	LastvacuumAge   float64 `column:"last_vacuum_seconds"`
+	LastanalyzeAge  float64 `column:"last_analyze_seconds"`
+	LastvacuumTime  float64 `column:"last_vacuum_time"`
+	LastanalyzeTime float64 `column:"last_analyze_time"`
+	Vacuum          float64 `column:"vacuum_count"`
+	Autovacuum      float64 `column:"autovacuum_count"`
+	Analyze         float64 `column:"analyze_count"`
+	Autoanalyze     float64 `column:"autoanalyze_count"`
+	Heapread        float64 `column:"heap_blks_read"`
+	Heaphit         float64 `column:"heap_blks_hit"`
+	Idxread         float64 `column:"idx_blks_read"`
+	Idxhit          float64 `column:"idx_blks_hit"`
+	Toastread       float64 `column:"toast_blks_read"`
+	Toasthit        float64 `column:"toast_blks_hit"`
+	Tidxread        float64 `column:"tidx_blks_read"`
+	Tidxhit         float64 `column:"tidx_blks_hit"`
+	Sizebytes       float64 `column:"size_bytes"`
+	Reltuples       float64 `column:"reltuples"`
+	ParentSchema    string  `column:"parent_schema"`
+	ParentTable     string  `column:"parent_table"`
+}
+
+// add accumulates stats of the passed table. Counters and sizes are summed, maintenance ages and times are taken from
+// the most recently maintained table.
+func (s *postgresTableStat) add(o postgresTableStat) {
+	s.Seqscan += o.Seqscan
+	s.Seqtupread += o.Seqtupread
+	s.Idxscan += o.Idxscan
+	s.Idxtupfetch += o.Idxtupfetch
+	s.Inserted += o.Inserted
+	s.Updated += o.Updated
+	s.Deleted += o.Deleted
+	s.HotUpdated += o.HotUpdated
+	s.Live += o.Live
+	s.Dead += o.Dead
+	s.Modified += o.Modified
+	s.Vacuum += o.Vacuum
+	s.Autovacuum += o.Autovacuum
+	s.Analyze += o.Analyze
+	s.Autoanalyze += o.Autoanalyze
+	s.Heapread += o.Heapread
+	s.Heaphit += o.Heaphit
+	s.Idxread += o.Idxread
+	s.Idxhit += o.Idxhit
+	s.Toastread += o.Toastread
+	s.Toasthit += o.Toasthit
+	s.Tidxread += o.Tidxread
+	s.Tidxhit += o.Tidxhit
+	s.Sizebytes += o.Sizebytes
+	s.Reltuples += o.Reltuples
+
+	if o.LastvacuumTime > s.LastvacuumTime {
+		s.LastvacuumTime, s.LastvacuumAge = o.LastvacuumTime, o.LastvacuumAge
+	}
+	if o.LastanalyzeTime > s.LastanalyzeTime {
+		s.LastanalyzeTime, s.LastanalyzeAge = o.LastanalyzeTime, o.LastanalyzeAge
+	}
 }
 
 // parsePostgresTableStats parses PGResult and returns structs with stats values.
-func parsePostgresTableStats(r *model.PGResult, labelNames []string) map[string]postgresTableStat {
+func parsePostgresTableStats(r *model.PGResult) map[string]postgresTableStat {
 	log.Debug("parse postgres tables stats")
 
 	var stats = make(map[string]postgresTableStat)
 
-	var tablename string
-
 	for _, row := range r.Rows {
 		table := postgresTableStat{}
-		for i, colname := range r.Colnames {
-			switch string(colname.Name) {
-			case "database":
-				table.database = row[i].String
-			case "schema":
-				table.schema = row[i].String
-			case "table":
-				table.table = row[i].String
-			}
+		if err := scanRow(r.Colnames, row, &table); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
 		}
 
 		// create a table name consisting of trio database/schema/table
-		tablename = strings.Join([]string{table.database, table.schema, table.table}, "/")
+		tablename := strings.Join([]string{table.Database, table.Schema, table.Table}, "/")
 
 		stats[tablename] = table
+	}
 
-		for i, colname := range r.Colnames {
-			// skip columns if its value used as a label
-			if stringsContains(labelNames, string(colname.Name)) {
-				continue
-			}
+	return stats
+}
 
-			// Skip empty (NULL) values.
-			if !row[i].Valid {
-				continue
-			}
+// aggregatePartitionsStats aggregates stats of partitions into stats of their root tables.
+func aggregatePartitionsStats(stats map[string]postgresTableStat) map[string]postgresTableStat {
+	var result = make(map[string]postgresTableStat)
 
-			// Get data value and convert it to float64 used by Prometheus.
-			v, err := strconv.ParseFloat(row[i].String, 64)
-			if err != nil {
-				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
-				continue
-			}
+	for _, stat := range stats {
+		if stat.ParentTable != "" {
+			stat.Schema, stat.Table = stat.ParentSchema, stat.ParentTable
+			stat.ParentSchema, stat.ParentTable = "", ""
+		}
 
-			s := stats[tablename]
-
-			switch string(colname.Name) {
-			case "seq_scan":
-				s.seqscan = v
-			case "seq_tup_read":
-				s.seqtupread = v
-			case "idx_scan":
-				s.idxscan = v
-			case "idx_tup_fetch":
-				s.idxtupfetch = v
-			case "n_tup_ins":
-				s.inserted = v
-			case "n_tup_upd":
-				s.updated = v
-			case "n_tup_del":
-				s.deleted = v
-			case "n_tup_hot_upd":
-				s.hotUpdated = v
-			case "n_live_tup":
-				s.live = v
-			case "n_dead_tup":
-				s.dead = v
-			case "n_mod_since_analyze":
-				s.modified = v
-			case "last_vacuum_seconds":
-				s.lastvacuumAge = v
-			case "last_analyze_seconds":
-				s.lastanalyzeAge = v
-			case "last_vacuum_time":
-				s.lastvacuumTime = v
-			case "last_analyze_time":
-				s.lastanalyzeTime = v
-			case "vacuum_count":
-				s.vacuum = v
-			case "autovacuum_count":
-				s.autovacuum = v
-			case "analyze_count":
-				s.analyze = v
-			case "autoanalyze_count":
-				s.autoanalyze = v
-			case "heap_blks_read":
-				s.heapread = v
-			case "heap_blks_hit":
-				s.heaphit = v
-			case "idx_blks_read":
-				s.idxread = v
-			case "idx_blks_hit":
-				s.idxhit = v
-			case "toast_blks_read":
-				s.toastread = v
-			case "toast_blks_hit":
-				s.toasthit = v
-			case "tidx_blks_read":
-				s.tidxread = v
-			case "tidx_blks_hit":
-				s.tidxhit = v
-			case "size_bytes":
-				s.sizebytes = v
-			case "reltuples":
-				s.reltuples = v
-			default:
-				continue
-			}
+		tablename := strings.Join([]string{stat.Database, stat.Schema, stat.Table}, "/")
 
-			stats[tablename] = s
+		if s, ok := result[tablename]; ok {
+			s.add(stat)
+			stat = s
 		}
+
+		result[tablename] = stat
 	}
 
-	return stats
+	return result
+}
+
+// selectTablesQuery returns tables query, with root tables of partitions if partitions should be aggregated.
+func selectTablesQuery(aggregate bool) string {
+	if aggregate {
+		return fmt.Sprintf(userTablesQuery, userTablesPartitionsColumns, userTablesPartitionsJoin)
+	}
+	return fmt.Sprintf(userTablesQuery, "", "")
 }
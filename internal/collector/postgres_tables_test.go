@@ -78,12 +78,12 @@ func Test_parsePostgresTableStats(t *testing.T) {
 			},
 			want: map[string]postgresTableStat{
 				"testdb/testschema/testrelname": {
-					database: "testdb", schema: "testschema", table: "testrelname",
-					seqscan: 100, seqtupread: 1000, idxscan: 200, idxtupfetch: 2000,
-					inserted: 300, updated: 400, deleted: 500, hotUpdated: 150, live: 600, dead: 100, modified: 500,
-					lastvacuumAge: 700, lastanalyzeAge: 800, lastvacuumTime: 12345678, lastanalyzeTime: 87654321, vacuum: 910, autovacuum: 920, analyze: 930, autoanalyze: 940,
-					heapread: 4528, heaphit: 5845, idxread: 458, idxhit: 698, toastread: 125, toasthit: 825, tidxread: 699, tidxhit: 375,
-					sizebytes: 458523, reltuples: 50000,
+					Database: "testdb", Schema: "testschema", Table: "testrelname",
+					Seqscan: 100, Seqtupread: 1000, Idxscan: 200, Idxtupfetch: 2000,
+					Inserted: 300, Updated: 400, Deleted: 500, HotUpdated: 150, Live: 600, Dead: 100, Modified: 500,
+					LastvacuumAge: 700, LastanalyzeAge: 800, LastvacuumTime: 12345678, LastanalyzeTime: 87654321, Vacuum: 910, Autovacuum: 920, Analyze: 930, Autoanalyze: 940,
+					Heapread: 4528, Heaphit: 5845, Idxread: 458, Idxhit: 698, Toastread: 125, Toasthit: 825, Tidxread: 699, Tidxhit: 375,
+					Sizebytes: 458523, Reltuples: 50000,
 				},
 			},
 		},
@@ -91,8 +91,37 @@ func Test_parsePostgresTableStats(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := parsePostgresTableStats(tc.res, []string{"database", "schema", "table"})
+			got := parsePostgresTableStats(tc.res)
 			assert.EqualValues(t, tc.want, got)
 		})
 	}
 }
+
+func Test_aggregatePartitionsStats(t *testing.T) {
+	stats := map[string]postgresTableStat{
+		"testdb/public/events": {Database: "testdb", Schema: "public", Table: "events"},
+		"testdb/public/events_2023": {
+			Database: "testdb", Schema: "public", Table: "events_2023", ParentSchema: "public", ParentTable: "events",
+			Seqscan: 10, Live: 1000, Dead: 10, Sizebytes: 8192, LastvacuumTime: 100, LastvacuumAge: 50,
+		},
+		"testdb/public/events_2024": {
+			Database: "testdb", Schema: "public", Table: "events_2024", ParentSchema: "public", ParentTable: "events",
+			Seqscan: 5, Live: 500, Dead: 20, Sizebytes: 4096, LastvacuumTime: 120, LastvacuumAge: 30,
+		},
+		"testdb/public/clients": {Database: "testdb", Schema: "public", Table: "clients", Seqscan: 1},
+	}
+
+	assert.Equal(t, map[string]postgresTableStat{
+		"testdb/public/events": {
+			Database: "testdb", Schema: "public", Table: "events",
+			Seqscan: 15, Live: 1500, Dead: 30, Sizebytes: 12288, LastvacuumTime: 120, LastvacuumAge: 30,
+		},
+		"testdb/public/clients": {Database: "testdb", Schema: "public", Table: "clients", Seqscan: 1},
+	}, aggregatePartitionsStats(stats))
+}
+
+func Test_selectTablesQuery(t *testing.T) {
+	assert.NotContains(t, selectTablesQuery(false), "pg_partition_root")
+	assert.Contains(t, selectTablesQuery(true), "pg_partition_root")
+	assert.Contains(t, selectTablesQuery(true), "parent_table")
+}
@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/filter"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// unusedIndexesQuery defines query for querying sizes of the largest rarely used indexes of the database. Unique
+	// indexes are skipped, they enforce constraints regardless of scans.
+	unusedIndexesQuery = "SELECT current_database() AS database, s.schemaname AS schema, s.relname AS table, " +
+		"s.indexrelname AS index, s.idx_scan AS scans, pg_relation_size(s.indexrelid) AS size_bytes " +
+		"FROM pg_stat_user_indexes s JOIN pg_index i ON i.indexrelid = s.indexrelid " +
+		"WHERE NOT i.indisunique AND s.idx_scan <= %d " +
+		"AND NOT EXISTS (SELECT 1 FROM pg_locks WHERE relation = s.indexrelid AND mode = 'AccessExclusiveLock' AND granted) " +
+		"AND pg_relation_size(s.indexrelid) >= %d " +
+		"ORDER BY pg_relation_size(s.indexrelid) DESC LIMIT %d"
+
+	// defaultUnusedIndexesLimit defines default number of the largest unused indexes reported per database.
+	defaultUnusedIndexesLimit = 10
+)
+
+// postgresUnusedIndexesCollector defines metric descriptors of the largest unused indexes.
+type postgresUnusedIndexesCollector struct {
+	sizes          typedDesc
+	filters        filter.Filters
+	limit          int
+	sizeThreshold  int64
+	scansThreshold int64
+}
+
+// NewPostgresUnusedIndexesCollector returns a new Collector exposing sizes of the largest indexes of every database
+// which have not been scanned since statistics reset. Indexes scanned more times than 'scans_threshold' are considered
+// used. Number of indexes is limited by 'rows_limit' setting, indexes smaller than 'size_threshold' are not reported.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#PG-STAT-ALL-INDEXES-VIEW
+func NewPostgresUnusedIndexesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	limit := settings.RowsLimit
+	if limit == 0 {
+		limit = defaultUnusedIndexesLimit
+	}
+
+	return &postgresUnusedIndexesCollector{
+		filters:        settings.Filters,
+		limit:          limit,
+		sizeThreshold:  settings.SizeThreshold,
+		scansThreshold: settings.ScansThreshold,
+		sizes: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "unused_size_bytes", "Size of the largest indexes not used since statistics reset, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresUnusedIndexesCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.sizes,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresUnusedIndexesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	databases, err := listServiceDatabases(config)
+	if err != nil {
+		return err
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if it is rejected by collector's filters, avoid connecting to it.
+		if !c.filters.Pass("database", d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfig(pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.Query(fmt.Sprintf(unusedIndexesQuery, c.scansThreshold, c.sizeThreshold, c.limit))
+		conn.Close()
+		if err != nil {
+			log.Warnf("get unused indexes of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresUnusedIndexesStats(res) {
+			ch <- c.sizes.newConstMetric(stat.Size, stat.Database, stat.Schema, stat.Table, stat.Index)
+		}
+	}
+
+	return nil
+}
+
+// postgresUnusedIndexStat represents size of a single unused index.
+type postgresUnusedIndexStat struct {
+	Database string  `column:"database"`
+	Schema   string  `column:"schema"`
+	Table    string  `column:"table"`
+	Index    string  `column:"index"`
+	Scans    float64 `column:"scans"`
+	Size     float64 `column:"size_bytes"`
+}
+
+// parsePostgresUnusedIndexesStats parses PGResult and returns structs with unused indexes sizes.
+func parsePostgresUnusedIndexesStats(r *model.PGResult) []postgresUnusedIndexStat {
+	log.Debug("parse postgres unused indexes")
+
+	stats := make([]postgresUnusedIndexStat, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresUnusedIndexStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
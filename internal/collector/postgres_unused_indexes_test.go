@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresUnusedIndexesCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_index_unused_size_bytes",
+		},
+		collector: NewPostgresUnusedIndexesCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresUnusedIndexesStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 6,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("table")},
+			{Name: []byte("index")}, {Name: []byte("scans")}, {Name: []byte("size_bytes")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "orders", Valid: true},
+				{String: "orders_created_at_idx", Valid: true}, {String: "0", Valid: true}, {String: "819200", Valid: true},
+			},
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "clients", Valid: true},
+				{String: "clients_email_idx", Valid: true}, {String: "5", Valid: true}, {String: "16384", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresUnusedIndexStat{
+		{Database: "testdb", Schema: "public", Table: "orders", Index: "orders_created_at_idx", Size: 819200},
+		{Database: "testdb", Schema: "public", Table: "clients", Index: "clients_email_idx", Scans: 5, Size: 16384},
+	}, parsePostgresUnusedIndexesStats(res))
+}
@@ -101,13 +101,30 @@ func NewPostgresWalCollector(constLabels labels, settings model.CollectorSetting
 		),
 		resetUnix: newBuiltinTypedDesc(
 			descOpts{"postgres", "wal", "stats_reset_time", "Time at which WAL statistics were last reset, in unixtime.", 0},
-			prometheus.CounterValue,
+			prometheus.GaugeValue,
 			nil, constLabels,
 			settings.Filters,
 		),
 	}, nil
 }
 
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresWalCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.recovery,
+		c.records,
+		c.fpi,
+		c.bytes,
+		c.writtenBytes,
+		c.buffersFull,
+		c.writes,
+		c.syncs,
+		c.secondsAll,
+		c.seconds,
+		c.resetUnix,
+	}
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	conn, err := store.New(config.ConnString)
@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// walInspectLSNQuery defines query for querying current WAL flush location, NULL in case of standby.
+	walInspectLSNQuery = "SELECT CASE WHEN pg_is_in_recovery() THEN NULL ELSE pg_current_wal_flush_lsn()::text END"
+
+	// walInspectStatsQuery defines query for querying statistics of WAL records by resource managers in the WAL
+	// window. Window is limited by walInspectMaxWindow bytes before its end.
+	walInspectStatsQuery = "SELECT \"resource_manager/record_type\" AS rmgr, count, record_size, fpi_size " +
+		"FROM %s.pg_get_wal_stats(greatest('%s'::pg_lsn, '%s'::pg_lsn - %d), '%s'::pg_lsn)"
+
+	// walInspectMaxWindow defines max amount of WAL inspected during single update, in bytes. WAL generated above
+	// the limit since the previous update is not accounted.
+	walInspectMaxWindow = 256 * 1024 * 1024
+)
+
+// postgresWalInspectCollector defines metric descriptors and WAL statistics accumulated since the first update.
+type postgresWalInspectCollector struct {
+	records typedDesc
+	bytes   typedDesc
+	// lastLSN defines WAL location where the previous update has been finished.
+	lastLSN string
+	stats   map[string]postgresWalRmgrStat
+}
+
+// NewPostgresWalInspectCollector returns a new Collector exposing WAL records statistics by resource managers. The
+// collector is opt-in, it works only when pg_walinspect extension is installed in the database pgSCV connects to.
+// For details see https://www.postgresql.org/docs/current/pgwalinspect.html
+func NewPostgresWalInspectCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresWalInspectCollector{
+		stats: map[string]postgresWalRmgrStat{},
+		records: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_rmgr", "records_total", "Total number of WAL records generated by resource manager since pgSCV start.", 0},
+			prometheus.CounterValue,
+			[]string{"rmgr"}, constLabels,
+			settings.Filters,
+		),
+		bytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_rmgr", "bytes_total", "Total amount of WAL generated by resource manager since pgSCV start, by type of data (record, fpi), in bytes.", 0},
+			prometheus.CounterValue,
+			[]string{"rmgr", "type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresWalInspectCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.records,
+		c.bytes,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresWalInspectCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV15 {
+		log.Debugln("[postgres walinspect collector]: pg_walinspect is not supported, skip")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	schema := extensionInstalledSchema(conn, "pg_walinspect")
+	if schema == "" {
+		return nil
+	}
+
+	var lsn *string
+	err = conn.Conn().QueryRow(context.Background(), walInspectLSNQuery).Scan(&lsn)
+	if err != nil {
+		return err
+	}
+
+	// WAL is not generated on standby.
+	if lsn == nil {
+		return nil
+	}
+
+	start, end := c.lastLSN, *lsn
+	c.lastLSN = end
+
+	// The first update defines the start of WAL window.
+	if start != "" && start != end {
+		query := fmt.Sprintf(walInspectStatsQuery, schema, start, end, walInspectMaxWindow, end)
+		res, err := conn.Query(query)
+		if err != nil {
+			return err
+		}
+
+		for rmgr, stat := range parsePostgresWalRmgrStats(res) {
+			s := c.stats[rmgr]
+			s.Records += stat.Records
+			s.RecordBytes += stat.RecordBytes
+			s.FpiBytes += stat.FpiBytes
+			c.stats[rmgr] = s
+		}
+	}
+
+	for rmgr, stat := range c.stats {
+		ch <- c.records.newConstMetric(stat.Records, rmgr)
+		ch <- c.bytes.newConstMetric(stat.RecordBytes, rmgr, "record")
+		ch <- c.bytes.newConstMetric(stat.FpiBytes, rmgr, "fpi")
+	}
+
+	return nil
+}
+
+// postgresWalRmgrStat represents WAL statistics of a single resource manager.
+type postgresWalRmgrStat struct {
+	Rmgr        string  `column:"rmgr"`
+	Records     float64 `column:"count"`
+	RecordBytes float64 `column:"record_size"`
+	FpiBytes    float64 `column:"fpi_size"`
+}
+
+// parsePostgresWalRmgrStats parses PGResult and returns WAL statistics by resource managers. Resource managers without
+// records are skipped.
+func parsePostgresWalRmgrStats(r *model.PGResult) map[string]postgresWalRmgrStat {
+	log.Debug("parse postgres wal rmgr stats")
+
+	stats := make(map[string]postgresWalRmgrStat)
+	for _, row := range r.Rows {
+		stat := postgresWalRmgrStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		if stat.Records == 0 {
+			continue
+		}
+		stats[stat.Rmgr] = stat
+	}
+
+	return stats
+}
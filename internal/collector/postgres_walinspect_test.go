@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresWalInspectCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_wal_rmgr_records_total",
+			"postgres_wal_rmgr_bytes_total",
+		},
+		collector: NewPostgresWalInspectCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresWalRmgrStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("rmgr")}, {Name: []byte("count")}, {Name: []byte("record_size")}, {Name: []byte("fpi_size")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "Heap", Valid: true}, {String: "100", Valid: true}, {String: "8000", Valid: true}, {String: "40960", Valid: true}},
+			{{String: "Btree", Valid: true}, {String: "20", Valid: true}, {String: "1200", Valid: true}, {String: "0", Valid: true}},
+			{{String: "XLOG", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, map[string]postgresWalRmgrStat{
+		"Heap":  {Rmgr: "Heap", Records: 100, RecordBytes: 8000, FpiBytes: 40960},
+		"Btree": {Rmgr: "Btree", Records: 20, RecordBytes: 1200},
+	}, parsePostgresWalRmgrStats(res))
+}
@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Query for Postgres versions from 10 to 12.
+	walReceiverQuery10 = "SELECT status, coalesce(slot_name, '') AS slot_name, '' AS sender_host, " +
+		"received_lsn - pg_last_wal_replay_lsn() AS replay_lag_bytes, " +
+		"extract(epoch FROM clock_timestamp() - last_msg_send_time) AS send_age_seconds, " +
+		"extract(epoch FROM clock_timestamp() - last_msg_receipt_time) AS receipt_age_seconds, " +
+		"extract(epoch FROM clock_timestamp() - latest_end_time) AS reported_age_seconds " +
+		"FROM pg_stat_wal_receiver"
+
+	// Query for Postgres versions from 13 and newer.
+	walReceiverQueryLatest = "SELECT status, coalesce(slot_name, '') AS slot_name, coalesce(sender_host, '') AS sender_host, " +
+		"written_lsn - flushed_lsn AS unflushed_bytes, flushed_lsn - pg_last_wal_replay_lsn() AS replay_lag_bytes, " +
+		"extract(epoch FROM clock_timestamp() - last_msg_send_time) AS send_age_seconds, " +
+		"extract(epoch FROM clock_timestamp() - last_msg_receipt_time) AS receipt_age_seconds, " +
+		"extract(epoch FROM clock_timestamp() - latest_end_time) AS reported_age_seconds " +
+		"FROM pg_stat_wal_receiver"
+)
+
+// postgresWalReceiverCollector defines metric descriptors for WAL receiver statistics.
+type postgresWalReceiverCollector struct {
+	info      typedDesc
+	unflushed typedDesc
+	replayLag typedDesc
+	age       typedDesc
+}
+
+// NewPostgresWalReceiverCollector returns a new Collector exposing state of WAL receiver on standbys.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-WAL-RECEIVER-VIEW
+func NewPostgresWalReceiverCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresWalReceiverCollector{
+		info: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "info", "Labeled information about WAL receiver and its status.", 0},
+			prometheus.GaugeValue,
+			[]string{"status", "slot_name", "sender_host"}, constLabels,
+			settings.Filters,
+		),
+		unflushed: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "unflushed_bytes", "Amount of WAL received and written, but not flushed to disk yet, in bytes.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		replayLag: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "replay_lag_bytes", "Amount of WAL received and flushed to disk, but not replayed yet, in bytes.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		age: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "message_age_seconds", "Time since the last message has been sent by the upstream, received by WAL receiver and reported back to the upstream, by type (send, receipt, report), in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// descriptors returns descriptors of metrics produced by collector.
+func (c *postgresWalReceiverCollector) descriptors() []typedDesc {
+	return []typedDesc{
+		c.info,
+		c.unflushed,
+		c.replayLag,
+		c.age,
+	}
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresWalReceiverCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV10 {
+		log.Debugln("[postgres wal receiver collector]: some system functions are not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := store.New(config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(selectWalReceiverQuery(config.serverVersionNum))
+	if err != nil {
+		return err
+	}
+
+	// WAL receiver is not running on primary and on standby which doesn't use streaming replication.
+	for _, stat := range parsePostgresWalReceiver(res) {
+		ch <- c.info.newConstMetric(1, stat.Status, stat.Slot, stat.SenderHost)
+		ch <- c.replayLag.newConstMetric(stat.ReplayLag)
+		ch <- c.age.newConstMetric(stat.SendAge, "send")
+		ch <- c.age.newConstMetric(stat.ReceiptAge, "receipt")
+		ch <- c.age.newConstMetric(stat.ReportedAge, "report")
+
+		// Written location is available since Postgres 13.
+		if config.serverVersionNum >= PostgresV13 {
+			ch <- c.unflushed.newConstMetric(stat.Unflushed)
+		}
+	}
+
+	return nil
+}
+
+// postgresWalReceiverStat represents WAL receiver statistics.
+type postgresWalReceiverStat struct {
+	Status      string  `column:"status"`
+	Slot        string  `column:"slot_name"`
+	SenderHost  string  `column:"sender_host"`
+	Unflushed   float64 `column:"unflushed_bytes"`
+	ReplayLag   float64 `column:"replay_lag_bytes"`
+	SendAge     float64 `column:"send_age_seconds"`
+	ReceiptAge  float64 `column:"receipt_age_seconds"`
+	ReportedAge float64 `column:"reported_age_seconds"`
+}
+
+// parsePostgresWalReceiver parses PGResult and returns structs with WAL receiver statistics.
+func parsePostgresWalReceiver(r *model.PGResult) []postgresWalReceiverStat {
+	log.Debug("parse postgres wal receiver stats")
+
+	stats := make([]postgresWalReceiverStat, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		stat := postgresWalReceiverStat{}
+		if err := scanRow(r.Colnames, row, &stat); err != nil {
+			log.Errorf("invalid input, %s; skip", err)
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// selectWalReceiverQuery returns suitable WAL receiver query depending on passed version.
+func selectWalReceiverQuery(version int) string {
+	switch {
+	case version < PostgresV13:
+		return walReceiverQuery10
+	default:
+		return walReceiverQueryLatest
+	}
+}
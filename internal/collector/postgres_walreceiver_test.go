@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"database/sql"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresWalReceiverCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_wal_receiver_info",
+			"postgres_wal_receiver_unflushed_bytes",
+			"postgres_wal_receiver_replay_lag_bytes",
+			"postgres_wal_receiver_message_age_seconds",
+		},
+		collector: NewPostgresWalReceiverCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresWalReceiver(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 8,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("status")}, {Name: []byte("slot_name")}, {Name: []byte("sender_host")},
+			{Name: []byte("unflushed_bytes")}, {Name: []byte("replay_lag_bytes")}, {Name: []byte("send_age_seconds")},
+			{Name: []byte("receipt_age_seconds")}, {Name: []byte("reported_age_seconds")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "streaming", Valid: true}, {String: "standby1", Valid: true}, {String: "10.0.0.1", Valid: true},
+				{String: "0", Valid: true}, {String: "8192", Valid: true}, {String: "0.5", Valid: true},
+				{String: "0.4", Valid: true}, {String: "2", Valid: true},
+			},
+		},
+	}
+
+	assert.Equal(t, []postgresWalReceiverStat{
+		{
+			Status: "streaming", Slot: "standby1", SenderHost: "10.0.0.1",
+			ReplayLag: 8192, SendAge: 0.5, ReceiptAge: 0.4, ReportedAge: 2,
+		},
+	}, parsePostgresWalReceiver(res))
+}
+
+func Test_selectWalReceiverQuery(t *testing.T) {
+	var testcases = []struct {
+		version int
+		want    string
+	}{
+		{version: 100000, want: walReceiverQuery10},
+		{version: 120005, want: walReceiverQuery10},
+		{version: 130000, want: walReceiverQueryLatest},
+		{version: 150002, want: walReceiverQueryLatest},
+	}
+
+	for _, tc := range testcases {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tc.want, selectWalReceiverQuery(tc.version))
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/collector"
+	"io"
+	"strings"
+)
+
+const (
+	// panelWidth defines width of panels, two panels are placed in a line of 24 units wide grid.
+	panelWidth = 12
+	// panelHeight defines height of panels.
+	panelHeight = 8
+)
+
+// Dashboard describes Grafana dashboard, only necessary properties are defined.
+type Dashboard struct {
+	Title         string     `json:"title"`
+	UID           string     `json:"uid"`
+	Tags          []string   `json:"tags"`
+	Editable      bool       `json:"editable"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          TimeRange  `json:"time"`
+	Refresh       string     `json:"refresh"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+// TimeRange describes default time range of the dashboard.
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Templating describes dashboard variables.
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+// Variable describes a single dashboard variable.
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label"`
+	Type       string      `json:"type"`
+	Query      string      `json:"query"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Refresh    int         `json:"refresh,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+}
+
+// Datasource describes reference to datasource.
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// Panel describes dashboard panel or row.
+type Panel struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description,omitempty"`
+	GridPos     GridPos     `json:"gridPos"`
+	Datasource  *Datasource `json:"datasource,omitempty"`
+	Targets     []Target    `json:"targets,omitempty"`
+	Collapsed   bool        `json:"collapsed,omitempty"`
+	Panels      []Panel     `json:"panels,omitempty"`
+}
+
+// GridPos describes position of the panel.
+type GridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Target describes panel's query.
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+// New creates dashboard with panels for all passed metrics. Panels are grouped into rows by collectors.
+func New(title string, catalog []collector.MetricDescription) Dashboard {
+	datasource := &Datasource{Type: "prometheus", UID: "${datasource}"}
+
+	d := Dashboard{
+		Title:         title,
+		UID:           "pgscv-" + strings.ToLower(strings.Join(strings.Fields(title), "-")),
+		Tags:          []string{"pgscv"},
+		Editable:      true,
+		SchemaVersion: 36,
+		Time:          TimeRange{From: "now-1h", To: "now"},
+		Refresh:       "1m",
+		Templating: Templating{List: []Variable{
+			{Name: "datasource", Label: "Datasource", Type: "datasource", Query: "prometheus"},
+			{
+				Name: "service_id", Label: "Service", Type: "query", Query: "label_values(service_id)",
+				Datasource: datasource, Refresh: 2, Multi: true, IncludeAll: true,
+			},
+		}},
+	}
+
+	// Group metrics by collectors, catalog is already sorted by collectors names.
+	var groups [][]collector.MetricDescription
+	for i, m := range catalog {
+		if i == 0 || m.Collector != catalog[i-1].Collector {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], m)
+	}
+
+	// Each collector has its own collapsed row, two panels per line inside the row.
+	var id, y int
+	for _, metrics := range groups {
+		id++
+		row := Panel{ID: id, Type: "row", Title: metrics[0].Collector, GridPos: GridPos{X: 0, Y: y, W: 24, H: 1}, Collapsed: true}
+		y++
+
+		for i, m := range metrics {
+			id++
+			row.Panels = append(row.Panels, Panel{
+				ID:          id,
+				Type:        "timeseries",
+				Title:       m.Name,
+				Description: m.Help,
+				GridPos:     GridPos{X: (i % 2) * panelWidth, Y: y + (i/2)*panelHeight, W: panelWidth, H: panelHeight},
+				Datasource:  datasource,
+				Targets:     []Target{{RefID: "A", Expr: expr(m), LegendFormat: legend(m)}},
+			})
+		}
+
+		y += (len(metrics) + 1) / 2 * panelHeight
+		d.Panels = append(d.Panels, row)
+	}
+
+	return d
+}
+
+// Write writes dashboard JSON into passed writer.
+func Write(w io.Writer, d Dashboard) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// expr returns PromQL expression for the metric, counters are shown as per-second rates.
+func expr(m collector.MetricDescription) string {
+	selector := fmt.Sprintf(`%s{service_id=~"$service_id"}`, m.Name)
+	if m.Type == "counter" {
+		return fmt.Sprintf("rate(%s[$__rate_interval])", selector)
+	}
+	return selector
+}
+
+// legend returns legend format built from metric's labels.
+func legend(m collector.MetricDescription) string {
+	parts := make([]string, 0, len(m.Labels))
+	for _, l := range m.Labels {
+		parts = append(parts, "{{"+l+"}}")
+	}
+
+	if len(parts) == 0 {
+		return m.Name
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,56 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/lesovsky/pgscv/internal/collector"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	catalog := []collector.MetricDescription{
+		{Collector: "postgres/database", Name: "postgres_database_xact_commits_total", Type: "counter", Labels: []string{"database", "service_id"}},
+		{Collector: "postgres/database", Name: "postgres_database_size_bytes", Type: "gauge", Labels: []string{"database", "service_id"}},
+		{Collector: "postgres/database", Name: "postgres_database_stats_age_seconds", Type: "gauge", Labels: []string{"database", "service_id"}},
+		{Collector: "system/loadaverage", Name: "node_load1", Type: "gauge"},
+	}
+
+	d := New("pgSCV Example", catalog)
+	assert.Equal(t, "pgscv-pgscv-example", d.UID)
+	assert.Len(t, d.Templating.List, 2)
+	assert.Len(t, d.Panels, 2)
+
+	row := d.Panels[0]
+	assert.Equal(t, "row", row.Type)
+	assert.Equal(t, "postgres/database", row.Title)
+	assert.Len(t, row.Panels, 3)
+	assert.Equal(t, `rate(postgres_database_xact_commits_total{service_id=~"$service_id"}[$__rate_interval])`, row.Panels[0].Targets[0].Expr)
+	assert.Equal(t, `postgres_database_size_bytes{service_id=~"$service_id"}`, row.Panels[1].Targets[0].Expr)
+	assert.Equal(t, "{{database}} {{service_id}}", row.Panels[1].Targets[0].LegendFormat)
+	assert.Equal(t, GridPos{X: 12, Y: 1, W: 12, H: 8}, row.Panels[1].GridPos)
+	assert.Equal(t, GridPos{X: 0, Y: 9, W: 12, H: 8}, row.Panels[2].GridPos)
+
+	// Second row is placed after all panels of the first row.
+	assert.Equal(t, GridPos{X: 0, Y: 17, W: 24, H: 1}, d.Panels[1].GridPos)
+	assert.Equal(t, "node_load1", d.Panels[1].Panels[0].Targets[0].LegendFormat)
+
+	// Panels IDs should be unique.
+	ids := map[int]bool{}
+	for _, r := range d.Panels {
+		ids[r.ID] = true
+		for _, p := range r.Panels {
+			ids[p.ID] = true
+		}
+	}
+	assert.Len(t, ids, 6)
+}
+
+func TestWrite(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, Write(buf, New("test", nil)))
+
+	var got map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "test", got["title"])
+}
@@ -10,11 +10,11 @@ type Filter struct {
 	// Exclude pattern string.
 	Exclude string `yaml:"exclude,omitempty"`
 	// Compiled exclude pattern regexp.
-	ExcludeRE *regexp.Regexp
+	ExcludeRE *regexp.Regexp `yaml:"-"`
 	// Include pattern string.
 	Include string `yaml:"include,omitempty"`
 	// Compiled include pattern regexp.
-	IncludeRE *regexp.Regexp
+	IncludeRE *regexp.Regexp `yaml:"-"`
 }
 
 // Pass checks that target is satisfied to filter's regexps.
@@ -68,6 +68,16 @@ func (f Filters) Add(name string, filter Filter) {
 	f[name] = filter
 }
 
+// Pass checks target against filter with specified name. Target is passed if there is no such filter.
+func (f Filters) Pass(name string, target string) bool {
+	filter, ok := f[name]
+	if !ok {
+		return true
+	}
+
+	return filter.Pass(target)
+}
+
 // Compile walk trough filters and compile them.
 func (f Filters) Compile() error {
 	log.Debug("compile filters")
@@ -103,3 +103,29 @@ func TestFilter_Pass2(t *testing.T) {
 		assert.Equal(t, tc.pass, f.Pass(tc.in))
 	}
 }
+
+func TestFilters_Pass(t *testing.T) {
+	f := Filters{
+		"database": {Exclude: "^template"},
+		"schema":   {Include: "^public$"},
+	}
+	assert.NoError(t, f.Compile())
+
+	var testcases = []struct {
+		name   string
+		target string
+		want   bool
+	}{
+		{name: "database", target: "testdb", want: true},
+		{name: "database", target: "template1", want: false},
+		{name: "schema", target: "public", want: true},
+		{name: "schema", target: "shard_0001", want: false},
+		{name: "table", target: "example", want: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name+"/"+tc.target, func(t *testing.T) {
+			assert.Equal(t, tc.want, f.Pass(tc.name, tc.target))
+		})
+	}
+}
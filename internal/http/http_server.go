@@ -3,11 +3,21 @@ package http
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/yaml.v2"
+	"html/template"
 	"io"
+	"net"
 	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,9 +26,11 @@ type AuthConfig struct {
 	EnableAuth bool   // flag tells about authentication should be enabled
 	Username   string `yaml:"username"` // username used for basic authentication
 	Password   string `yaml:"password"` // password used for basic authentication
-	EnableTLS  bool   // flag tells about TLS should be enabled
-	Keyfile    string `yaml:"keyfile"`  // path to key file
-	Certfile   string `yaml:"certfile"` // path to certificate file
+	// PasswordFile defines path to file with password used for basic authentication.
+	PasswordFile string `yaml:"password_file"`
+	EnableTLS    bool   // flag tells about TLS should be enabled
+	Keyfile      string `yaml:"keyfile"`  // path to key file
+	Certfile     string `yaml:"certfile"` // path to certificate file
 }
 
 // Validate check authentication options of AuthConfig and returns toggle flags.
@@ -47,7 +59,23 @@ func (cfg AuthConfig) Validate() (bool, bool, error) {
 // ServerConfig defines HTTP server configuration.
 type ServerConfig struct {
 	Addr string
+	// ExtraAddrs defines additional addresses to listen on. Addresses with 'unix:' prefix are paths to Unix sockets.
+	ExtraAddrs []string
+	// UnixSocketMode defines permissions of created Unix sockets.
+	UnixSocketMode os.FileMode
 	AuthConfig
+	// Version defines application version shown on the landing page.
+	Version string
+	// Services defines function which returns state of monitored services exposed by '/services' endpoint.
+	Services func() interface{}
+	// EnableDebug defines debug endpoints '/debug/pprof', '/debug/config' and '/-/loglevel' should be enabled.
+	EnableDebug bool
+	// Config defines function which returns application configuration exposed by '/debug/config' endpoint.
+	Config func() interface{}
+	// MaxConcurrentScrapes defines max number of concurrent requests to metrics endpoints. Zero means no limit.
+	MaxConcurrentScrapes int
+	// Gatherers defines additional gatherers which metrics are exposed along with metrics of default registry.
+	Gatherers []prometheus.Gatherer
 }
 
 // Server defines HTTP server.
@@ -60,12 +88,46 @@ type Server struct {
 func NewServer(cfg ServerConfig) *Server {
 	mux := http.NewServeMux()
 
-	mux.Handle("/", handleRoot())
+	gatherer := prometheus.Gatherers(append([]prometheus.Gatherer{prometheus.DefaultGatherer}, cfg.Gatherers...))
 
+	// Both metrics endpoints collect metrics, hence they share the same limit of concurrent scrapes.
+	limit := limitConcurrency(cfg.MaxConcurrentScrapes)
+	metricsHandler := limit(handleMetrics(prometheus.DefaultRegisterer, gatherer))
+	metricsJSONHandler := limit(handleMetricsJSON(gatherer))
+
+	servicesHandler := handleServices(cfg.Services)
+	rootHandler := handleRoot(cfg.Version, cfg.Services)
+
+	// Landing page shows state of services, hence it is protected in the same way as other endpoints.
 	if cfg.EnableAuth {
-		mux.Handle("/metrics", basicAuth(cfg.AuthConfig, promhttp.Handler()))
+		mux.Handle("/", basicAuth(cfg.AuthConfig, rootHandler))
+		mux.Handle("/metrics", basicAuth(cfg.AuthConfig, metricsHandler))
+		mux.Handle("/metrics.json", basicAuth(cfg.AuthConfig, metricsJSONHandler))
+		mux.Handle("/services", basicAuth(cfg.AuthConfig, servicesHandler))
 	} else {
-		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/", rootHandler)
+		mux.Handle("/metrics", metricsHandler)
+		mux.Handle("/metrics.json", metricsJSONHandler)
+		mux.Handle("/services", servicesHandler)
+	}
+
+	if cfg.EnableDebug {
+		debugHandlers := map[string]http.Handler{
+			"/debug/pprof/":        http.HandlerFunc(pprof.Index),
+			"/debug/pprof/cmdline": http.HandlerFunc(pprof.Cmdline),
+			"/debug/pprof/profile": http.HandlerFunc(pprof.Profile),
+			"/debug/pprof/symbol":  http.HandlerFunc(pprof.Symbol),
+			"/debug/pprof/trace":   http.HandlerFunc(pprof.Trace),
+			"/debug/config":        handleDebugConfig(cfg.Config),
+			"/-/loglevel":          handleLogLevel(),
+		}
+
+		for path, handler := range debugHandlers {
+			if cfg.EnableAuth {
+				handler = basicAuth(cfg.AuthConfig, handler)
+			}
+			mux.Handle(path, handler)
+		}
 	}
 
 	return &Server{
@@ -80,36 +142,276 @@ func NewServer(cfg ServerConfig) *Server {
 	}
 }
 
-// Serve method starts listening and serving requests.
+// Serve method starts listening on all configured addresses and serving requests. Returns when serving on any of
+// addresses fails.
 func (s *Server) Serve() error {
-	if s.config.EnableTLS {
-		log.Infof("listen on https://%s", s.server.Addr)
-		return s.server.ListenAndServeTLS(s.config.Certfile, s.config.Keyfile)
+	addrs := append([]string{s.config.Addr}, s.config.ExtraAddrs...)
+
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		l, err := listen(addr, s.config.UnixSocketMode)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, l)
 	}
 
-	log.Infof("listen on http://%s", s.server.Addr)
-	return s.server.ListenAndServe()
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			if s.config.EnableTLS {
+				log.Infof("listen on https://%s", l.Addr())
+				errCh <- s.server.ServeTLS(l, s.config.Certfile, s.config.Keyfile)
+				return
+			}
+
+			log.Infof("listen on http://%s", l.Addr())
+			errCh <- s.server.Serve(l)
+		}(l)
+	}
+
+	return <-errCh
 }
 
-// handleRoot defines handler for '/' endpoint.
-func handleRoot() http.Handler {
-	const htmlTemplate = `<html>
+// listen creates TCP listener or Unix socket listener, if address has 'unix:' prefix.
+func listen(addr string, mode os.FileMode) (net.Listener, error) {
+	path := strings.TrimPrefix(addr, "unix:")
+	if path == addr {
+		return net.Listen("tcp", addr)
+	}
+
+	// Remove socket left after unclean shutdown.
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			_ = l.Close()
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// rootTemplate defines HTML template of the landing page.
+var rootTemplate = template.Must(template.New("root").Parse(`<html>
 <head><title>pgSCV / Weaponry metrics collector</title></head>
 <body>
 pgSCV / PostgreSQL metrics collector, for more info visit <a href="https://github.com/lesovsky/pgscv">Github</a> page.
+<p>Version: {{ if .Version }}{{ .Version }}{{ else }}unknown{{ end }}, runtime mode: pull</p>
 <p><a href="/metrics">Metrics</a></p>
+<p><a href="/metrics.json">Metrics (JSON)</a></p>
+<p><a href="/services">Services</a></p>
+{{- if .Services }}
+<table border="1" cellpadding="4">
+<tr><th>Service</th><th>Type</th><th>Version</th><th>Last collect</th><th>Last error</th><th>Collectors</th></tr>
+{{- range .Services }}
+<tr><td>{{ .ServiceID }}</td><td>{{ .ServiceType }}</td><td>{{ if .Version }}{{ .Version }}{{ end }}</td><td>{{ .LastCollect }}</td><td>{{ .LastError }}</td><td>{{ range $i, $c := .Collectors }}{{ if $i }}, {{ end }}{{ $c }}{{ end }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
 </body>
 </html>
-`
+`))
+
+// handleRoot defines handler for '/' endpoint.
+func handleRoot(version string, services func() interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := struct {
+			Version  string
+			Services interface{}
+		}{Version: version}
+
+		if services != nil {
+			data.Services = services()
+		}
+
+		err := rootTemplate.Execute(w, data)
+		if err != nil {
+			log.Warnln("response write failed: ", err)
+		}
+	})
+}
+
+// handleMetrics defines handler for '/metrics' endpoint. Exposition format is negotiated using 'Accept' header of
+// the request, OpenMetrics format is used if requested, otherwise Prometheus text format is used. In contrast to
+// text format, OpenMetrics keeps explicit samples' timestamps.
+func handleMetrics(reg prometheus.Registerer, gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		reg, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+}
+
+// handleServices defines handler for '/services' endpoint.
+func handleServices(services func() interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp interface{} = []struct{}{}
+		if services != nil {
+			resp = services()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			log.Warnln("response write failed: ", err)
+		}
+	})
+}
 
+// handleLogLevel defines handler for '/-/loglevel' endpoint. GET request returns current logging level, PUT request
+// with 'level' parameter changes logging level. POST is not allowed, hence level couldn't be changed by cross-site
+// HTML forms.
+func handleLogLevel() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := w.Write([]byte(htmlTemplate))
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			level := r.FormValue("level")
+			if !log.IsValidLevel(level) {
+				http.Error(w, fmt.Sprintf("invalid log level '%s', valid levels: debug, info, warn, error", level), http.StatusBadRequest)
+				return
+			}
+
+			log.SetLevel(level)
+			log.Infof("log level changed to %s", level)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		_, err := fmt.Fprintln(w, log.Level())
+		if err != nil {
+			log.Warnln("response write failed: ", err)
+		}
+	})
+}
+
+// handleDebugConfig defines handler for '/debug/config' endpoint.
+func handleDebugConfig(config func() interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp interface{} = struct{}{}
+		if config != nil {
+			resp = config()
+		}
+
+		out, err := yaml.Marshal(resp)
+		if err != nil {
+			log.Warnln("marshal config failed: ", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err = w.Write(out)
+		if err != nil {
+			log.Warnln("response write failed: ", err)
+		}
+	})
+}
+
+// jsonSample defines a single sample exposed by '/metrics.json' endpoint.
+type jsonSample struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels"`
+	Value     string            `json:"value"`     // string is used because JSON has no representation for NaN and Inf
+	Timestamp int64             `json:"timestamp"` // unixtime in milliseconds
+}
+
+// handleMetricsJSON defines handler for '/metrics.json' endpoint.
+func handleMetricsJSON(gatherer prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			// Gather returns as many metrics as possible even in case of errors, continue with what has been gathered.
+			log.Warnln("gather metrics failed: ", err)
+		}
+
+		samples := newJSONSamples(families, time.Now().UnixNano()/int64(time.Millisecond))
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(samples)
 		if err != nil {
 			log.Warnln("response write failed: ", err)
 		}
 	})
 }
 
+// newJSONSamples flattens metric families into list of samples. Summaries and histograms are flattened in the same way
+// as in Prometheus text format, i.e. into quantile/bucket, '_sum' and '_count' samples.
+func newJSONSamples(families []*dto.MetricFamily, ts int64) []jsonSample {
+	samples := make([]jsonSample, 0, len(families))
+
+	for _, mf := range families {
+		name := mf.GetName()
+
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+
+			// Use metric's own timestamp if it is specified, or use gathering time otherwise.
+			sampleTs := ts
+			if m.TimestampMs != nil {
+				sampleTs = m.GetTimestampMs()
+			}
+
+			add := func(name string, value float64, extra ...string) {
+				l := make(map[string]string, len(labels)+1)
+				for k, v := range labels {
+					l[k] = v
+				}
+				if len(extra) == 2 {
+					l[extra[0]] = extra[1]
+				}
+				samples = append(samples, jsonSample{Name: name, Labels: l, Value: formatFloat(value), Timestamp: sampleTs})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.GetSummary().GetQuantile() {
+					add(name, q.GetValue(), "quantile", formatFloat(q.GetQuantile()))
+				}
+				add(name+"_sum", m.GetSummary().GetSampleSum())
+				add(name+"_count", float64(m.GetSummary().GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				for _, b := range m.GetHistogram().GetBucket() {
+					add(name+"_bucket", float64(b.GetCumulativeCount()), "le", formatFloat(b.GetUpperBound()))
+				}
+				add(name+"_bucket", float64(m.GetHistogram().GetSampleCount()), "le", "+Inf")
+				add(name+"_sum", m.GetHistogram().GetSampleSum())
+				add(name+"_count", float64(m.GetHistogram().GetSampleCount()))
+			}
+		}
+	}
+
+	return samples
+}
+
+// formatFloat returns string representation of float value in the same way as Prometheus does.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
 // basicAuth is a middleware for basic authentication.
 func basicAuth(cfg AuthConfig, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -126,6 +428,30 @@ func basicAuth(cfg AuthConfig, next http.Handler) http.Handler {
 	})
 }
 
+// limitConcurrency returns a middleware which limits number of concurrent requests. The limit is shared by all
+// handlers wrapped with the returned middleware. Requests exceeding the limit are rejected with 429 status. Zero
+// limit means no limits.
+func limitConcurrency(limit int) func(next http.Handler) http.Handler {
+	if limit <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	sem := make(chan struct{}, limit)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				next.ServeHTTP(w, r)
+			default:
+				log.Warnf("too many concurrent requests from %s, limit %d, reject", r.RemoteAddr, limit)
+				http.Error(w, "Too many concurrent requests", StatusTooManyRequests)
+			}
+		})
+	}
+}
+
 // NewPushRequest creates new HTTP request for sending metrics into remote service.
 func NewPushRequest(url, apiKey, hostname string, payload []byte) (*http.Request, error) {
 	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
@@ -1,11 +1,19 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -54,7 +62,7 @@ func TestServer_Serve_HTTP(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	cl := NewClient(ClientConfig{})
-	endpoints := []string{"/", "/metrics"}
+	endpoints := []string{"/", "/metrics", "/metrics.json"}
 
 	for _, e := range endpoints {
 		resp, err := cl.Get("http://" + addr + e)
@@ -63,6 +71,53 @@ func TestServer_Serve_HTTP(t *testing.T) {
 	}
 }
 
+func TestServer_Serve_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "pgscv.sock")
+	addr := "127.0.0.1:17892"
+	srv := NewServer(ServerConfig{Addr: addr, ExtraAddrs: []string{"unix:" + socket}, UnixSocketMode: 0600})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	fi, err := os.Stat(socket)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	// Metrics are available through both TCP and Unix socket.
+	resp, err := NewClient(ClientConfig{}).Get("http://" + addr + "/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+
+	cl := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err = cl.Get("http://unix/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+}
+
+func Test_listen(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "pgscv.sock")
+
+	l, err := listen("unix:"+socket, 0660)
+	assert.NoError(t, err)
+	assert.Equal(t, socket, l.Addr().String())
+	assert.NoError(t, l.Close())
+
+	l, err = listen("127.0.0.1:0", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	_, err = listen("unix:"+filepath.Join(t.TempDir(), "nonexistent", "pgscv.sock"), 0660)
+	assert.Error(t, err)
+}
+
 func TestServer_Serve_HTTPS(t *testing.T) {
 	addr := "127.0.0.1:17891"
 	srv := NewServer(ServerConfig{Addr: addr, AuthConfig: AuthConfig{
@@ -101,7 +156,7 @@ func Test_handleRoot(t *testing.T) {
 	res := httptest.NewRecorder()
 
 	mux := http.NewServeMux()
-	mux.Handle("/", handleRoot())
+	mux.Handle("/", handleRoot("", nil))
 	mux.ServeHTTP(res, req)
 
 	assert.Equal(t, StatusOK, res.Code)
@@ -112,6 +167,33 @@ func Test_handleRoot(t *testing.T) {
 	res.Flush()
 }
 
+func Test_handleRoot_Services(t *testing.T) {
+	services := func() interface{} {
+		return []struct {
+			ServiceID   string
+			ServiceType string
+			Version     int
+			LastCollect string
+			LastError   string
+			Collectors  []string
+		}{
+			{ServiceID: "postgres:5432", ServiceType: "postgres", Version: 140005, LastError: "<failed>", Collectors: []string{"postgres/activity", "postgres/tables"}},
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+
+	handleRoot("v0.8.0", services).ServeHTTP(res, req)
+	assert.Equal(t, StatusOK, res.Code)
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "Version: v0.8.0, runtime mode: pull")
+	assert.Contains(t, string(body), "<td>postgres:5432</td><td>postgres</td><td>140005</td>")
+	assert.Contains(t, string(body), "<td>&lt;failed&gt;</td><td>postgres/activity, postgres/tables</td>")
+}
+
 func Test_basicAuth(t *testing.T) {
 	testcases := []struct {
 		name   string
@@ -129,7 +211,7 @@ func Test_basicAuth(t *testing.T) {
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			mux := http.NewServeMux()
-			mux.Handle("/", basicAuth(AuthConfig{Username: "user", Password: "pass"}, handleRoot()))
+			mux.Handle("/", basicAuth(AuthConfig{Username: "user", Password: "pass"}, handleRoot("", nil)))
 
 			res := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -141,6 +223,41 @@ func Test_basicAuth(t *testing.T) {
 	}
 }
 
+func Test_limitConcurrency(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	limit := limitConcurrency(1)
+	handler := limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	another := limit(handleRoot("", nil))
+
+	// Occupy the single slot by blocked request.
+	done := make(chan int)
+	go func() {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		done <- res.Code
+	}()
+	<-started
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, StatusTooManyRequests, res.Code)
+
+	// The limit is shared by all wrapped handlers.
+	res = httptest.NewRecorder()
+	another.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics.json", nil))
+	assert.Equal(t, StatusTooManyRequests, res.Code)
+
+	close(release)
+	assert.Equal(t, StatusOK, <-done)
+
+	// Zero limit means no limits, passed handler is returned as-is.
+	next := handleRoot("", nil)
+	assert.NotNil(t, limitConcurrency(0)(next))
+}
+
 func TestNewPushRequest(t *testing.T) {
 	req, err := NewPushRequest("https://example.org", "example", "example", []byte("example"))
 	assert.NoError(t, err)
@@ -173,3 +290,165 @@ func TestDoPushRequest(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Error(t, DoPushRequest(cl, req))
 }
+
+func Test_handleMetricsJSON(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "example_gauge", Help: "example"}, []string{"label"})
+	gauge.WithLabelValues("value").Set(10)
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "example_summary", Help: "example", Objectives: map[float64]float64{0.5: 0.05}})
+	reg.MustRegister(gauge, summary)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics.json", nil)
+	res := httptest.NewRecorder()
+
+	handleMetricsJSON(reg).ServeHTTP(res, req)
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var samples []jsonSample
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&samples))
+	assert.Len(t, samples, 4)
+
+	assert.Equal(t, "example_gauge", samples[0].Name)
+	assert.Equal(t, map[string]string{"label": "value"}, samples[0].Labels)
+	assert.Equal(t, "10", samples[0].Value)
+	assert.Greater(t, samples[0].Timestamp, int64(0))
+
+	// Summary without observations has NaN quantiles which must be encoded properly.
+	assert.Equal(t, "example_summary", samples[1].Name)
+	assert.Equal(t, map[string]string{"quantile": "0.5"}, samples[1].Labels)
+	assert.Equal(t, "NaN", samples[1].Value)
+	assert.Equal(t, "example_summary_sum", samples[2].Name)
+	assert.Equal(t, "example_summary_count", samples[3].Name)
+}
+
+func Test_handleMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "example_gauge", Help: "example"})
+	gauge.Set(10)
+	reg.MustRegister(gauge)
+
+	testcases := []struct {
+		name        string
+		accept      string
+		contentType string
+		want        string
+	}{
+		{name: "text format", accept: "", contentType: "text/plain; version=0.0.4; charset=utf-8", want: "example_gauge 10\n"},
+		{
+			name:        "openmetrics format",
+			accept:      "application/openmetrics-text; version=0.0.1",
+			contentType: "application/openmetrics-text; version=0.0.1; charset=utf-8",
+			want:        "example_gauge 10.0\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			res := httptest.NewRecorder()
+
+			handleMetrics(reg, reg).ServeHTTP(res, req)
+			assert.Equal(t, StatusOK, res.Code)
+			assert.Equal(t, tc.contentType, res.Header().Get("Content-Type"))
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Contains(t, string(body), tc.want)
+		})
+	}
+}
+
+func Test_handleServices(t *testing.T) {
+	testcases := []struct {
+		services func() interface{}
+		want     string
+	}{
+		{services: nil, want: "[]\n"},
+		{
+			services: func() interface{} { return []map[string]string{{"service_id": "system:0"}} },
+			want:     `[{"service_id":"system:0"}]` + "\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		req := httptest.NewRequest(http.MethodGet, "/services", nil)
+		res := httptest.NewRecorder()
+
+		handleServices(tc.services).ServeHTTP(res, req)
+		assert.Equal(t, StatusOK, res.Code)
+		assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+		assert.Equal(t, tc.want, res.Body.String())
+	}
+}
+
+func Test_handleDebugConfig(t *testing.T) {
+	config := func() interface{} {
+		return struct {
+			ListenAddress string `yaml:"listen_address"`
+		}{ListenAddress: "127.0.0.1:9890"}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/config", nil)
+	res := httptest.NewRecorder()
+
+	handleDebugConfig(config).ServeHTTP(res, req)
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, "listen_address: 127.0.0.1:9890\n", res.Body.String())
+}
+
+func TestServer_Debug(t *testing.T) {
+	testcases := []struct {
+		enable bool
+		want   bool // whether response is served by debug handler
+	}{
+		{enable: true, want: true},
+		{enable: false, want: false},
+	}
+
+	for _, tc := range testcases {
+		srv := NewServer(ServerConfig{EnableDebug: tc.enable})
+
+		for _, path := range []string{"/debug/pprof/", "/debug/config"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			res := httptest.NewRecorder()
+			srv.server.Handler.ServeHTTP(res, req)
+			assert.Equal(t, StatusOK, res.Code)
+
+			// Unknown paths are served by landing page handler.
+			assert.Equal(t, !tc.want, strings.Contains(res.Body.String(), "pgSCV / PostgreSQL metrics collector"))
+		}
+	}
+}
+
+func Test_handleLogLevel(t *testing.T) {
+	defer log.SetLevel(log.Level())
+
+	testcases := []struct {
+		method string
+		target string
+		status int
+		want   string
+	}{
+		{method: http.MethodPut, target: "/-/loglevel?level=debug", status: StatusOK, want: "debug\n"},
+		{method: http.MethodGet, target: "/-/loglevel", status: StatusOK, want: "debug\n"},
+		{method: http.MethodPut, target: "/-/loglevel?level=warn", status: StatusOK, want: "warn\n"},
+		{method: http.MethodPut, target: "/-/loglevel?level=invalid", status: http.StatusBadRequest},
+		{method: http.MethodPost, target: "/-/loglevel?level=debug", status: http.StatusMethodNotAllowed},
+		{method: http.MethodDelete, target: "/-/loglevel", status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testcases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		res := httptest.NewRecorder()
+
+		handleLogLevel().ServeHTTP(res, req)
+		assert.Equal(t, tc.status, res.Code)
+		if tc.want != "" {
+			assert.Equal(t, tc.want, res.Body.String())
+		}
+	}
+}
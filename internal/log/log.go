@@ -3,12 +3,17 @@ package log
 import (
 	"fmt"
 	"github.com/rs/zerolog"
+	"io"
 	"os"
+	"time"
 )
 
 // Logger is the global logger with predefined settings
 var Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
 
+// output defines destination of log records.
+var output io.Writer = os.Stdout
+
 // KV is a simple key-value store
 type KV map[string]string
 
@@ -28,6 +33,41 @@ func SetLevel(level string) {
 	}
 }
 
+// SetOutput sets destination of log records, should be called before SetFormat.
+func SetOutput(w io.Writer) {
+	output = w
+	Logger = Logger.Output(w)
+}
+
+// SetFormat sets format of log records: 'json' or 'text'. Unknown format is not applied and error is returned.
+func SetFormat(format string) error {
+	switch format {
+	case "text":
+		Logger = Logger.Output(zerolog.ConsoleWriter{Out: output, NoColor: true, TimeFormat: time.RFC3339})
+	case "json":
+		Logger = Logger.Output(output)
+	default:
+		return fmt.Errorf("unknown log format '%s', supported formats: json, text", format)
+	}
+
+	return nil
+}
+
+// Level returns current logging level.
+func Level() string {
+	return zerolog.GlobalLevel().String()
+}
+
+// IsValidLevel returns true if passed level is supported.
+func IsValidLevel(level string) bool {
+	switch level {
+	case "debug", "info", "warn", "error":
+		return true
+	default:
+		return false
+	}
+}
+
 func New() zerolog.Logger {
 	var logger = Logger
 	return logger
@@ -98,6 +138,33 @@ func Errorln(v ...interface{}) {
 	Logger.Error().Msg(fmt.Sprint(v...))
 }
 
+// KVDebugf prints formatted message with DEBUG severity with attached KV map
+func KVDebugf(kv KV, format string, v ...interface{}) {
+	log := Logger.Debug()
+	for k, v := range kv {
+		log.Str(k, v)
+	}
+	log.Msgf(format, v...)
+}
+
+// KVInfof prints formatted message with INFO severity with attached KV map
+func KVInfof(kv KV, format string, v ...interface{}) {
+	log := Logger.Info()
+	for k, v := range kv {
+		log.Str(k, v)
+	}
+	log.Msgf(format, v...)
+}
+
+// KVWarnf prints formatted message with WARNING severity with attached KV map
+func KVWarnf(kv KV, format string, v ...interface{}) {
+	log := Logger.Warn()
+	for k, v := range kv {
+		log.Str(k, v)
+	}
+	log.Msgf(format, v...)
+}
+
 // KVError prints message with ERROR severity with attached KV map
 func KVError(kv KV, msg string) {
 	log := Logger.Error()
@@ -5,6 +5,7 @@ import (
 	"github.com/jackc/pgproto3/v2"
 	"github.com/lesovsky/pgscv/internal/filter"
 	"regexp"
+	"time"
 )
 
 const (
@@ -16,6 +17,15 @@ const (
 	ServiceTypePgbouncer = "pgbouncer"
 )
 
+const (
+	// NullValuesSkip defines metrics with NULL values are not sent.
+	NullValuesSkip = "skip"
+	// NullValuesZero defines NULL values are sent as zero.
+	NullValuesZero = "zero"
+	// NullValuesFlag defines NULL values are not sent, but flagged using extra '_isnull' metric.
+	NullValuesFlag = "flag"
+)
+
 // PGResult is the iterable store that contains query result (data and metadata) returned from Postgres
 type PGResult struct {
 	Nrows    int
@@ -30,6 +40,17 @@ type PGResult struct {
 //
 //  collectors:                                                 <- Collectors (root level in YAML)
 //    postgres/archiver:                                        <- CollectorSettings
+//      series_limit: 1000                                      <- CollectorSettings.SeriesLimit
+//      rows_limit: 500                                         <- CollectorSettings.RowsLimit
+//      interval: 5m                                            <- CollectorSettings.Interval
+//      null_values: zero                                       <- CollectorSettings.NullValues
+//      reset_interval: 24h                                     <- CollectorSettings.ResetInterval
+//      latency_buckets: [ 0.01, 0.1, 1 ]                       <- CollectorSettings.LatencyBuckets
+//      size_threshold: 1073741824                              <- CollectorSettings.SizeThreshold
+//      scans_threshold: 10                                     <- CollectorSettings.ScansThreshold
+//      aggregate_partitions: true                              <- CollectorSettings.AggregatePartitions
+//      baseline:                                               <- CollectorSettings.Baseline
+//        shared_buffers: 8GB                                   <- expected value of the setting
 //      filters:                                                <- CollectorSettings.Filters
 //        query:                                                <- label
 //          exclude: "(UPDATE|DELETE)"                          <- exclude metrics with labels contains these values
@@ -54,6 +75,45 @@ type CollectorsSettings map[string]CollectorSettings
 
 // CollectorSettings unions all settings related to a single collector.
 type CollectorSettings struct {
+	// SeriesLimit defines max number of series produced by collector, surplus series are dropped. Zero means no limit.
+	SeriesLimit int `yaml:"series_limit"`
+	// RowsLimit defines max number of top rows fetched by collector's query, e.g. the largest tables or the most
+	// time-consuming statements. Zero means no limit. Supported by postgres/statements, postgres/tables and
+	// postgres/indexes collectors. For postgres/relations collector it defines number of the largest relations
+	// reported per database, 10 by default. For postgres/memory collector it defines number of the largest backends
+	// which log their memory contexts, zero disables the collector.
+	RowsLimit int `yaml:"rows_limit"`
+	// Interval defines how often collector runs, metrics collected during the previous run are sent in between.
+	// Zero means collector runs on every scrape, except postgres/tables and postgres/indexes collectors which
+	// interval depends on number of relations, postgres/fdw, postgres/memory and postgres/plans collectors which run
+	// every 5 minutes, postgres/relations collector which runs every 15 minutes, postgres/bloat and
+	// postgres/unused_indexes collectors which run every hour.
+	Interval time.Duration `yaml:"interval"`
+	// NullValues defines how NULL values of metrics are handled: 'skip' (default), 'zero' or 'flag'. Supported by
+	// postgres/replication collector, user-defined metrics, postgres/statements collector (blocks access times, buffers
+	// and temp files usage which are zero) and postgres/databases collector (blocks access times when track_io_timing
+	// is off, checksum failures when checksums are disabled, size of 'global' stats), the latter sends NULL values as
+	// zero by default. Other collectors don't apply the setting.
+	NullValues string `yaml:"null_values"`
+	// ResetInterval defines how often statistics is reset after it has been collected. Zero means statistics is never
+	// reset. Supported by postgres/statements collector.
+	ResetInterval time.Duration `yaml:"reset_interval"`
+	// LatencyBuckets defines upper bounds of latency buckets in seconds, in increasing order. Supported by
+	// postgres/statements collector.
+	LatencyBuckets []float64 `yaml:"latency_buckets"`
+	// SizeThreshold defines minimal size of relations reported by collector, in bytes. Supported by postgres/relations,
+	// postgres/bloat (minimal size of bloat) and postgres/unused_indexes collectors.
+	SizeThreshold int64 `yaml:"size_threshold"`
+	// ScansThreshold defines max number of scans of indexes considered unused, zero means indexes never scanned since
+	// statistics reset. Supported by postgres/unused_indexes collector.
+	ScansThreshold int64 `yaml:"scans_threshold"`
+	// AggregatePartitions defines stats of partitions are aggregated into a single series of their root table, Postgres
+	// 12 and newer. Supported by postgres/tables collector.
+	AggregatePartitions bool `yaml:"aggregate_partitions"`
+	// Baseline defines expected values of settings, settings which values differ are reported as drifted. Values
+	// are compared with raw values and values with units (e.g. 8GB) case-insensitively. Supported by postgres/settings
+	// collector.
+	Baseline map[string]string `yaml:"baseline"`
 	// Filters defines label-based filters applied to metrics.
 	Filters filter.Filters `yaml:"filters"`
 	// Subsystems defines subsystem with user-defined metrics.
@@ -68,7 +128,7 @@ type MetricsSubsystem struct {
 	// Databases defines which databases should be visited for collecting metrics.
 	Databases string `yaml:"databases"`
 	// DatabasesRE defines regexp object based on Databases.
-	DatabasesRE *regexp.Regexp
+	DatabasesRE *regexp.Regexp `yaml:"-"`
 	// Query defines a SQL statement used for getting label/values for metrics.
 	Query string `yaml:"query"`
 	// Metrics defines a list of labels and metrics should be extracted from Query result.
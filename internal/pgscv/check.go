@@ -0,0 +1,153 @@
+package pgscv
+
+import (
+	"fmt"
+	dto "github.com/prometheus/client_model/go"
+	"sort"
+	"strings"
+)
+
+// Nagios-compatible check statuses, used as exit codes.
+const (
+	CheckOK       = 0
+	CheckWarning  = 1
+	CheckCritical = 2
+	CheckUnknown  = 3
+)
+
+// checkStatusNames defines names of check statuses used in check output.
+var checkStatusNames = map[int]string{
+	CheckOK:       "OK",
+	CheckWarning:  "WARNING",
+	CheckCritical: "CRITICAL",
+	CheckUnknown:  "UNKNOWN",
+}
+
+// check describes threshold check based on metric produced by the collector.
+type check struct {
+	collector string
+	metric    string
+	// lowerIsWorse defines that thresholds are lower bounds, e.g. number of transactions left before wraparound.
+	lowerIsWorse bool
+	unit         string
+	warning      float64
+	critical     float64
+}
+
+// checks defines all supported checks.
+var checks = map[string]check{
+	"replication_lag": {
+		collector: "postgres/replication", metric: "postgres_replication_lag_all_seconds",
+		unit: "s", warning: 300, critical: 900,
+	},
+	"connections": {
+		collector: "postgres/activity", metric: "postgres_activity_connections_all_in_flight",
+		warning: 200, critical: 400,
+	},
+	"wraparound": {
+		collector: "postgres/databases", metric: "postgres_xacts_left_before_wraparound",
+		lowerIsWorse: true, warning: 500000000, critical: 100000000,
+	},
+	// Age of the last archived WAL segment - how far the latest point available for recovery from archive is.
+	"backup_age": {
+		collector: "postgres/archiver", metric: "postgres_archiver_since_last_archive_seconds",
+		unit: "s", warning: 3600, critical: 86400,
+	},
+}
+
+// CheckNames returns sorted names of supported checks.
+func CheckNames() []string {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check collects metric used by named check and evaluates it against thresholds. Check returns Nagios-compatible
+// status and single line output with performance data. Nil thresholds mean default values should be used.
+func Check(config *Config, serviceID string, name string, warning, critical *float64) (int, string) {
+	c, ok := checks[name]
+	if !ok {
+		return CheckUnknown, fmt.Sprintf("UNKNOWN - unknown check '%s'", name)
+	}
+
+	if warning != nil {
+		c.warning = *warning
+	}
+	if critical != nil {
+		c.critical = *critical
+	}
+
+	families, err := gather(config, serviceID, c.collector)
+	if err != nil {
+		return CheckUnknown, fmt.Sprintf("%s UNKNOWN - %s", strings.ToUpper(name), err)
+	}
+
+	value, found := worstValue(families, c.metric, c.lowerIsWorse)
+	if !found {
+		return CheckUnknown, fmt.Sprintf("%s UNKNOWN - no data for %s", strings.ToUpper(name), c.metric)
+	}
+
+	status := c.evaluate(value)
+
+	return status, fmt.Sprintf("%s %s - %s is %g%s | %s=%g%s;%g;%g",
+		strings.ToUpper(name), checkStatusNames[status], c.metric, value, c.unit,
+		name, value, c.unit, c.warning, c.critical,
+	)
+}
+
+// evaluate returns check status depending on value and thresholds.
+func (c check) evaluate(value float64) int {
+	if c.lowerIsWorse {
+		switch {
+		case value <= c.critical:
+			return CheckCritical
+		case value <= c.warning:
+			return CheckWarning
+		}
+		return CheckOK
+	}
+
+	switch {
+	case value >= c.critical:
+		return CheckCritical
+	case value >= c.warning:
+		return CheckWarning
+	}
+	return CheckOK
+}
+
+// worstValue returns the worst value of the metric among all its series.
+func worstValue(families []*dto.MetricFamily, metric string, lowerIsWorse bool) (float64, bool) {
+	var value float64
+	var found bool
+
+	for _, mf := range families {
+		if mf.GetName() != metric {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			var v float64
+			switch {
+			case m.Gauge != nil:
+				v = m.GetGauge().GetValue()
+			case m.Counter != nil:
+				v = m.GetCounter().GetValue()
+			case m.Untyped != nil:
+				v = m.GetUntyped().GetValue()
+			default:
+				continue
+			}
+
+			if !found || (lowerIsWorse && v < value) || (!lowerIsWorse && v > value) {
+				value = v
+			}
+			found = true
+		}
+	}
+
+	return value, found
+}
@@ -0,0 +1,61 @@
+package pgscv
+
+import (
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	config := &Config{}
+	assert.NoError(t, config.Validate())
+
+	status, output := Check(config, "", "unknown", nil, nil)
+	assert.Equal(t, CheckUnknown, status)
+	assert.Equal(t, "UNKNOWN - unknown check 'unknown'", output)
+
+	// System service has no Postgres collectors.
+	status, output = Check(config, "system:0", "connections", nil, nil)
+	assert.Equal(t, CheckUnknown, status)
+	assert.Equal(t, "CONNECTIONS UNKNOWN - no collectors to scrape", output)
+}
+
+func Test_check_evaluate(t *testing.T) {
+	var testcases = []struct {
+		check check
+		value float64
+		want  int
+	}{
+		{check: check{warning: 10, critical: 20}, value: 5, want: CheckOK},
+		{check: check{warning: 10, critical: 20}, value: 10, want: CheckWarning},
+		{check: check{warning: 10, critical: 20}, value: 25, want: CheckCritical},
+		{check: check{lowerIsWorse: true, warning: 20, critical: 10}, value: 25, want: CheckOK},
+		{check: check{lowerIsWorse: true, warning: 20, critical: 10}, value: 15, want: CheckWarning},
+		{check: check{lowerIsWorse: true, warning: 20, critical: 10}, value: 10, want: CheckCritical},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, tc.check.evaluate(tc.value))
+	}
+}
+
+func Test_worstValue(t *testing.T) {
+	gauge := func(v float64) *dto.Metric { return &dto.Metric{Gauge: &dto.Gauge{Value: &v}} }
+	name, other := "example", "other"
+
+	families := []*dto.MetricFamily{
+		{Name: &name, Metric: []*dto.Metric{gauge(10), gauge(30), gauge(20)}},
+		{Name: &other, Metric: []*dto.Metric{gauge(100)}},
+	}
+
+	v, ok := worstValue(families, "example", false)
+	assert.True(t, ok)
+	assert.Equal(t, float64(30), v)
+
+	v, ok = worstValue(families, "example", true)
+	assert.True(t, ok)
+	assert.Equal(t, float64(10), v)
+
+	_, ok = worstValue(families, "unknown", false)
+	assert.False(t, ok)
+}
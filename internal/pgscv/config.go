@@ -1,46 +1,99 @@
 package pgscv
 
 import (
+	"crypto/tls"
 	"fmt"
 	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/collector"
 	"github.com/lesovsky/pgscv/internal/http"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/proxy"
+	"github.com/lesovsky/pgscv/internal/relabel"
+	"github.com/lesovsky/pgscv/internal/rules"
 	"github.com/lesovsky/pgscv/internal/service"
 	"gopkg.in/yaml.v2"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	defaultListenAddress     = "127.0.0.1:9890"
+	defaultUnixSocketMode    = "0660"
 	defaultPostgresUsername  = "pgscv"
 	defaultPostgresDbname    = "postgres"
 	defaultPgbouncerUsername = "pgscv"
 	defaultPgbouncerDbname   = "pgbouncer"
+	defaultStatementTimeout  = 30 * time.Second
+	defaultLockTimeout       = 5 * time.Second
+	defaultBreakerThreshold  = 5
+	defaultBreakerBackoff    = 10 * time.Minute
 )
 
 // Config defines application's configuration.
 type Config struct {
-	NoTrackMode           bool                     `yaml:"no_track_mode"`      // controls tracking sensitive information (query texts, etc)
-	ListenAddress         string                   `yaml:"listen_address"`     // Network address and port where the application should listen on
-	ServicesConnsSettings service.ConnsSettings    `yaml:"services"`           // All connections settings for exact services
-	Defaults              map[string]string        `yaml:"defaults"`           // Defaults
-	DisableCollectors     []string                 `yaml:"disable_collectors"` // List of collectors which should be disabled. DEPRECATED in favor collectors settings
-	CollectorsSettings    model.CollectorsSettings `yaml:"collectors"`         // Collectors settings propagated from main YAML configuration
-	Databases             string                   `yaml:"databases"`          // Regular expression string specifies databases from which metrics should be collected
-	DatabasesRE           *regexp.Regexp           // Regular expression object compiled from Databases
-	AuthConfig            http.AuthConfig          `yaml:"authentication"` // TLS and Basic auth configuration
+	BinaryVersion         string                   `yaml:"-"`                // Version of the application, is set at startup
+	NoTrackMode           bool                     `yaml:"no_track_mode"`    // controls tracking sensitive information (query texts, etc)
+	ListenAddress         string                   `yaml:"listen_address"`   // Network address and port where the application should listen on
+	ListenAddresses       []string                 `yaml:"listen_addresses"` // Additional addresses to listen on, 'unix:' prefix for Unix sockets
+	UnixSocketMode        string                   `yaml:"unix_socket_mode"` // Permissions of created Unix sockets, in octal format
+	unixSocketMode        os.FileMode              // Permissions of created Unix sockets parsed from UnixSocketMode
+	ServicesConnsSettings service.ConnsSettings    `yaml:"services"`                            // All connections settings for exact services
+	Defaults              map[string]string        `yaml:"defaults"`                            // Defaults
+	DisableCollectors     []string                 `yaml:"disable_collectors"`                  // List of collectors which should be disabled. DEPRECATED in favor collectors settings
+	CollectorsSettings    model.CollectorsSettings `yaml:"collectors"`                          // Collectors settings propagated from main YAML configuration
+	Databases             string                   `yaml:"databases"`                           // Regular expression string specifies databases from which metrics should be collected
+	DatabasesRE           *regexp.Regexp           `yaml:"-"`                                   // Regular expression object compiled from Databases
+	ExcludeDatabases      string                   `yaml:"exclude_databases"`                   // Regular expression string specifies databases which should be skipped
+	ExcludeDatabasesRE    *regexp.Regexp           `yaml:"-"`                                   // Regular expression object compiled from ExcludeDatabases
+	DatabasesMinSize      int64                    `yaml:"databases_min_size"`                  // Databases smaller than this size (in bytes) are skipped
+	AuthConfig            http.AuthConfig          `yaml:"authentication"`                      // TLS and Basic auth configuration
+	Relabel               relabel.Rules            `yaml:"relabel"`                             // Rules for relabeling and renaming metrics
+	SeriesLimit           int                      `yaml:"series_limit"`                        // Max number of series produced per service
+	CacheTTL              time.Duration            `yaml:"cache_ttl"`                           // How long collected metrics are reused by subsequent scrapes
+	EnableDebug           bool                     `yaml:"enable_debug"`                        // Enable /debug/pprof, /debug/config and /-/loglevel endpoints
+	Audit                 bool                     `yaml:"audit"`                               // Log all executed SQL statements
+	Labels                map[string]string        `yaml:"labels"`                              // Constant labels attached to all metrics
+	MaxConcurrentScrapes  int                      `yaml:"max_concurrent_scrapes"`              // Max number of concurrent requests to metrics endpoints
+	Alerts                rules.Thresholds         `yaml:"alerts"`                              // Thresholds used in exported alerting rules
+	Exporters             proxy.Targets            `yaml:"exporters"`                           // Local exporters which metrics are merged with pgSCV metrics
+	StatementTimeout      time.Duration            `yaml:"statement_timeout"`                   // Max duration of statements executed by collectors
+	LockTimeout           time.Duration            `yaml:"lock_timeout"`                        // Max duration of waiting for locks by collectors
+	IdleInTxTimeout       time.Duration            `yaml:"idle_in_transaction_session_timeout"` // Max duration of idle-in-transaction state of collectors' sessions
+	MaxConnections        int                      `yaml:"max_connections"`                     // Max number of simultaneous connections to all services
+	BreakerThreshold      int                      `yaml:"breaker_threshold"`                   // Number of consecutive failures after which collector is disabled
+	BreakerBackoff        time.Duration            `yaml:"breaker_backoff"`                     // How long failed collector stays disabled
 }
 
 // NewConfig creates new config based on config file or return default config if config file is not specified.
 func NewConfig(configFilePath string) (*Config, error) {
+	var config *Config
+	var err error
 	if configFilePath == "" {
-		return newConfigFromEnv()
+		config, err = newConfigFromEnv()
+	} else {
+		config, err = newConfigFromFile(configFilePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Settings could be overridden using PGSCV__SECTION__KEY environment variables.
+	err = applyEnvOverrides(config, os.Environ())
+	if err != nil {
+		return nil, err
 	}
 
+	return config, nil
+}
+
+// newConfigFromFile creates config using config file.
+func newConfigFromFile(configFilePath string) (*Config, error) {
 	log.Infoln("read configuration from ", configFilePath)
 	content, err := os.ReadFile(filepath.Clean(configFilePath))
 	if err != nil {
@@ -63,6 +116,16 @@ func (c *Config) Validate() error {
 		c.ListenAddress = defaultListenAddress
 	}
 
+	if c.UnixSocketMode == "" {
+		c.UnixSocketMode = defaultUnixSocketMode
+	}
+
+	mode, err := strconv.ParseUint(c.UnixSocketMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return fmt.Errorf("invalid unix_socket_mode: %s", c.UnixSocketMode)
+	}
+	c.unixSocketMode = os.FileMode(mode)
+
 	if c.NoTrackMode {
 		log.Infoln("no-track enabled for [pg_stat_statements.query].")
 	} else {
@@ -102,6 +165,23 @@ func (c *Config) Validate() error {
 					return fmt.Errorf("empty service_type for %s", k)
 				}
 
+				if err := validateLabels(s.Labels); err != nil {
+					return fmt.Errorf("invalid labels for %s: %s", k, err)
+				}
+
+				if s.PasswordFile != "" {
+					password, err := readSecretFile(s.PasswordFile)
+					if err != nil {
+						return fmt.Errorf("read password file for %s failed: %s", k, err)
+					}
+
+					s.Conninfo, err = service.ConninfoWithPassword(s.Conninfo, password)
+					if err != nil {
+						return fmt.Errorf("invalid conninfo for %s: %s", k, err)
+					}
+					c.ServicesConnsSettings[k] = s
+				}
+
 				_, err := pgx.ParseConfig(s.Conninfo)
 				if err != nil {
 					return fmt.Errorf("invalid conninfo for %s: %s", k, err)
@@ -117,13 +197,105 @@ func (c *Config) Validate() error {
 	}
 	c.DatabasesRE = re
 
+	// Create 'exclude_databases' regexp object, databases matched to it are not visited by collectors.
+	if c.ExcludeDatabases != "" {
+		re, err := regexp.Compile(c.ExcludeDatabases)
+		if err != nil {
+			return fmt.Errorf("invalid exclude_databases: %s", err)
+		}
+		c.ExcludeDatabasesRE = re
+	}
+
+	if c.DatabasesMinSize < 0 {
+		return fmt.Errorf("invalid databases_min_size: %d", c.DatabasesMinSize)
+	}
+
 	// Validate collector settings.
 	err = validateCollectorSettings(c.CollectorsSettings)
 	if err != nil {
 		return err
 	}
 
+	if c.SeriesLimit < 0 {
+		return fmt.Errorf("invalid series_limit: %d", c.SeriesLimit)
+	}
+
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("invalid cache_ttl: %s", c.CacheTTL)
+	}
+
+	// Validate timeouts of collectors' sessions.
+	if c.StatementTimeout < 0 {
+		return fmt.Errorf("invalid statement_timeout: %s", c.StatementTimeout)
+	}
+	if c.StatementTimeout == 0 {
+		c.StatementTimeout = defaultStatementTimeout
+	}
+
+	if c.LockTimeout < 0 {
+		return fmt.Errorf("invalid lock_timeout: %s", c.LockTimeout)
+	}
+	if c.LockTimeout == 0 {
+		c.LockTimeout = defaultLockTimeout
+	}
+
+	if c.IdleInTxTimeout < 0 {
+		return fmt.Errorf("invalid idle_in_transaction_session_timeout: %s", c.IdleInTxTimeout)
+	}
+
+	if c.MaxConnections < 0 {
+		return fmt.Errorf("invalid max_connections: %d", c.MaxConnections)
+	}
+
+	// Validate settings of collectors' circuit breaker.
+	if c.BreakerThreshold < 0 {
+		return fmt.Errorf("invalid breaker_threshold: %d", c.BreakerThreshold)
+	}
+	if c.BreakerThreshold == 0 {
+		c.BreakerThreshold = defaultBreakerThreshold
+	}
+
+	if c.BreakerBackoff < 0 {
+		return fmt.Errorf("invalid breaker_backoff: %s", c.BreakerBackoff)
+	}
+	if c.BreakerBackoff == 0 {
+		c.BreakerBackoff = defaultBreakerBackoff
+	}
+
+	if c.MaxConcurrentScrapes < 0 {
+		return fmt.Errorf("invalid max_concurrent_scrapes: %d", c.MaxConcurrentScrapes)
+	}
+
+	// Validate constant labels.
+	if err := validateLabels(c.Labels); err != nil {
+		return fmt.Errorf("invalid labels: %s", err)
+	}
+
+	// Validate alerting rules thresholds.
+	if err := c.Alerts.Validate(); err != nil {
+		return err
+	}
+
+	// Validate local exporters settings.
+	if err := c.Exporters.Validate(); err != nil {
+		return err
+	}
+
+	// Validate relabeling rules.
+	err = c.Relabel.Compile()
+	if err != nil {
+		return err
+	}
+
 	// Validate authentication settings.
+	if c.AuthConfig.PasswordFile != "" {
+		password, err := readSecretFile(c.AuthConfig.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("read authentication password file failed: %s", err)
+		}
+		c.AuthConfig.Password = password
+	}
+
 	enableAuth, enableTLS, err := c.AuthConfig.Validate()
 	if err != nil {
 		return err
@@ -134,6 +306,97 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// Check performs additional checks of validated configuration which are not necessary for running, but help to find
+// mistakes in configuration, such as misspelled collectors names or missing TLS files. Returns all found problems.
+func (c *Config) Check() []error {
+	var errs []error
+
+	known := map[string]bool{}
+	for _, name := range collector.Names() {
+		known[name] = true
+	}
+
+	for _, name := range c.DisableCollectors {
+		switch name {
+		case model.ServiceTypeSystem, model.ServiceTypePostgresql, model.ServiceTypePgbouncer:
+			continue
+		}
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("disable_collectors: unknown collector '%s'", name))
+		}
+	}
+
+	for name := range c.CollectorsSettings {
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("collectors: unknown collector '%s'", name))
+		}
+	}
+
+	if c.AuthConfig.EnableTLS {
+		_, err := tls.LoadX509KeyPair(c.AuthConfig.Certfile, c.AuthConfig.Keyfile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("authentication: load TLS certificate failed: %s", err))
+		}
+	}
+
+	return errs
+}
+
+// redacted returns copy of the configuration with passwords replaced, used for exposing configuration.
+func (c Config) redacted() Config {
+	const mask = "********"
+
+	if c.AuthConfig.Password != "" {
+		c.AuthConfig.Password = mask
+	}
+
+	defaults := make(map[string]string, len(c.Defaults))
+	for k, v := range c.Defaults {
+		if strings.Contains(k, "password") {
+			v = mask
+		}
+		defaults[k] = v
+	}
+	c.Defaults = defaults
+
+	settings := make(service.ConnsSettings, len(c.ServicesConnsSettings))
+	for k, v := range c.ServicesConnsSettings {
+		v.Conninfo = service.RedactConninfo(v.Conninfo)
+		settings[k] = v
+	}
+	c.ServicesConnsSettings = settings
+
+	return c
+}
+
+// labelNameRE defines valid name of label.
+var labelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateLabels validates names of constant labels.
+func validateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !labelNameRE.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name '%s'", name)
+		}
+
+		if name == "service_id" {
+			return fmt.Errorf("label name '%s' is reserved", name)
+		}
+	}
+
+	return nil
+}
+
+// readSecretFile reads secret from file, trailing newlines are removed.
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
 // validateCollectorSettings validates collectors settings passed from main YAML configuration.
 func validateCollectorSettings(cs model.CollectorsSettings) error {
 	if cs == nil || len(cs) == 0 {
@@ -141,11 +404,54 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 	}
 
 	for csName, settings := range cs {
-		re1 := regexp.MustCompile(`^[a-zA-Z0-9]+/[a-zA-Z0-9]+$`)
+		re1 := regexp.MustCompile(`^[a-zA-Z0-9]+/[a-zA-Z0-9_]+$`)
 		if !re1.MatchString(csName) {
 			return fmt.Errorf("invalid collector name: %s", csName)
 		}
 
+		if settings.SeriesLimit < 0 {
+			return fmt.Errorf("invalid series_limit for collector %s: %d", csName, settings.SeriesLimit)
+		}
+
+		if settings.RowsLimit < 0 {
+			return fmt.Errorf("invalid rows_limit for collector %s: %d", csName, settings.RowsLimit)
+		}
+
+		if settings.Interval < 0 {
+			return fmt.Errorf("invalid interval for collector %s: %s", csName, settings.Interval)
+		}
+
+		if settings.SizeThreshold < 0 {
+			return fmt.Errorf("invalid size_threshold for collector %s: %d", csName, settings.SizeThreshold)
+		}
+
+		if settings.ScansThreshold < 0 {
+			return fmt.Errorf("invalid scans_threshold for collector %s: %d", csName, settings.ScansThreshold)
+		}
+
+		if settings.ResetInterval < 0 {
+			return fmt.Errorf("invalid reset_interval for collector %s: %s", csName, settings.ResetInterval)
+		}
+
+		reBaseline := regexp.MustCompile(`^[a-zA-Z0-9_.]+$`)
+		for name := range settings.Baseline {
+			if !reBaseline.MatchString(name) {
+				return fmt.Errorf("invalid baseline setting name for collector %s: %s", csName, name)
+			}
+		}
+
+		for i, b := range settings.LatencyBuckets {
+			if b <= 0 || (i > 0 && b <= settings.LatencyBuckets[i-1]) {
+				return fmt.Errorf("invalid latency_buckets for collector %s: bounds must be positive and increasing", csName)
+			}
+		}
+
+		switch settings.NullValues {
+		case "", model.NullValuesSkip, model.NullValuesZero, model.NullValuesFlag:
+		default:
+			return fmt.Errorf("invalid null_values for collector %s: %s", csName, settings.NullValues)
+		}
+
 		err := settings.Filters.Compile()
 		if err != nil {
 			return err
@@ -210,6 +516,10 @@ func newConfigFromEnv() (*Config, error) {
 		ServicesConnsSettings: map[string]service.ConnSetting{},
 	}
 
+	// Password files are specified using DSN keys with '_PASSWORD_FILE' suffix, e.g. POSTGRES_DSN_EXAMPLE_PASSWORD_FILE.
+	// They are attached to services when all variables are read.
+	passwordFiles := map[string]service.ConnSetting{}
+
 	for _, env := range os.Environ() {
 		if !strings.HasPrefix(env, "PGSCV_") &&
 			!strings.HasPrefix(env, "POSTGRES_DSN") &&
@@ -223,6 +533,28 @@ func newConfigFromEnv() (*Config, error) {
 
 		key, value := ff[0], ff[1]
 
+		// Parse password files of POSTGRES_DSN, DATABASE_DSN and PGBOUNCER_DSN.
+		if strings.HasSuffix(key, "_PASSWORD_FILE") && (strings.HasPrefix(key, "POSTGRES_DSN") ||
+			strings.HasPrefix(key, "DATABASE_DSN") || strings.HasPrefix(key, "PGBOUNCER_DSN")) {
+			dsnKey := strings.TrimSuffix(key, "_PASSWORD_FILE")
+
+			var id string
+			var cs service.ConnSetting
+			var err error
+			if strings.HasPrefix(dsnKey, "PGBOUNCER_DSN") {
+				id, cs, err = service.ParsePgbouncerDSNEnv(dsnKey, "")
+			} else {
+				id, cs, err = service.ParsePostgresDSNEnv(dsnKey, "")
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			cs.PasswordFile = value
+			passwordFiles[id] = cs
+			continue
+		}
+
 		// Parse POSTGRES_DSN (or its alias DATABASE_DSN).
 		if strings.HasPrefix(key, "POSTGRES_DSN") || strings.HasPrefix(key, "DATABASE_DSN") {
 			id, cs, err := service.ParsePostgresDSNEnv(key, value)
@@ -246,6 +578,10 @@ func newConfigFromEnv() (*Config, error) {
 		switch key {
 		case "PGSCV_LISTEN_ADDRESS":
 			config.ListenAddress = value
+		case "PGSCV_LISTEN_ADDRESSES":
+			config.ListenAddresses = strings.Split(strings.Replace(value, " ", "", -1), ",")
+		case "PGSCV_UNIX_SOCKET_MODE":
+			config.UnixSocketMode = value
 		case "PGSCV_NO_TRACK_MODE":
 			switch value {
 			case "y", "yes", "Yes", "YES", "t", "true", "True", "TRUE", "1", "on":
@@ -255,22 +591,257 @@ func newConfigFromEnv() (*Config, error) {
 			}
 		case "PGSCV_DATABASES":
 			config.Databases = value
+		case "PGSCV_EXCLUDE_DATABASES":
+			config.ExcludeDatabases = value
+		case "PGSCV_DATABASES_MIN_SIZE":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_DATABASES_MIN_SIZE: %s", err)
+			}
+			config.DatabasesMinSize = size
 		case "PGSCV_DISABLE_COLLECTORS":
 			config.DisableCollectors = strings.Split(strings.Replace(value, " ", "", -1), ",")
+		case "PGSCV_LABELS":
+			labels, err := parseLabels(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_LABELS: %s", err)
+			}
+			config.Labels = labels
 		case "PGSCV_AUTH_USERNAME":
 			config.AuthConfig.Username = value
 		case "PGSCV_AUTH_PASSWORD":
 			config.AuthConfig.Password = value
+		case "PGSCV_AUTH_PASSWORD_FILE":
+			config.AuthConfig.PasswordFile = value
 		case "PGSCV_AUTH_KEYFILE":
 			config.AuthConfig.Keyfile = value
 		case "PGSCV_AUTH_CERTFILE":
 			config.AuthConfig.Certfile = value
+		case "PGSCV_ENABLE_DEBUG":
+			switch value {
+			case "y", "yes", "Yes", "YES", "t", "true", "True", "TRUE", "1", "on":
+				config.EnableDebug = true
+			default:
+				config.EnableDebug = false
+			}
+		case "PGSCV_AUDIT":
+			switch value {
+			case "y", "yes", "Yes", "YES", "t", "true", "True", "TRUE", "1", "on":
+				config.Audit = true
+			default:
+				config.Audit = false
+			}
+		case "PGSCV_MAX_CONCURRENT_SCRAPES":
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_MAX_CONCURRENT_SCRAPES: %s", err)
+			}
+			config.MaxConcurrentScrapes = limit
+		case "PGSCV_CACHE_TTL":
+			ttl, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_CACHE_TTL: %s", err)
+			}
+			config.CacheTTL = ttl
+		}
+	}
+
+	for id, pf := range passwordFiles {
+		cs, ok := config.ServicesConnsSettings[id]
+		if !ok || cs.ServiceType != pf.ServiceType {
+			return nil, fmt.Errorf("password file is specified for unknown service %s", id)
 		}
+
+		cs.PasswordFile = pf.PasswordFile
+		config.ServicesConnsSettings[id] = cs
 	}
 
 	return config, nil
 }
 
+// envOverridePrefix defines prefix of environment variables which override config settings.
+const envOverridePrefix = "PGSCV__"
+
+// applyEnvOverrides overrides config settings using environment variables in PGSCV__SECTION__KEY format, where
+// sections and keys are YAML names of settings, e.g. PGSCV__AUTHENTICATION__USERNAME or PGSCV__LABELS__ENV.
+func applyEnvOverrides(config *Config, environ []string) error {
+	for _, env := range environ {
+		if !strings.HasPrefix(env, envOverridePrefix) {
+			continue
+		}
+
+		ff := strings.SplitN(env, "=", 2)
+		if len(ff) != 2 {
+			continue
+		}
+
+		path := strings.Split(strings.TrimPrefix(ff[0], envOverridePrefix), "__")
+		err := setConfigValue(reflect.ValueOf(config).Elem(), path, ff[1])
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", ff[0], err)
+		}
+
+		log.Debugf("config setting overridden by %s", ff[0])
+	}
+
+	return nil
+}
+
+// setConfigValue sets value of setting located by path in passed value.
+func setConfigValue(v reflect.Value, path []string, value string) error {
+	if len(path) > 0 && path[0] == "" {
+		return fmt.Errorf("empty setting name")
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if len(path) == 0 {
+			return fmt.Errorf("section could not be overridden")
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			name := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" || !v.Field(i).CanSet() {
+				continue
+			}
+
+			if strings.EqualFold(name, path[0]) {
+				return setConfigValue(v.Field(i), path[1:], value)
+			}
+		}
+
+		return fmt.Errorf("unknown setting '%s'", strings.ToLower(path[0]))
+	case reflect.Map:
+		if len(path) == 0 {
+			return fmt.Errorf("section could not be overridden")
+		}
+
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported setting")
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		// Environment variables names don't allow characters used in keys (like '/' or ':'), hence look for existing
+		// or known key with similar name. Keys of other maps are arbitrary, use lowercase name.
+		names := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			names = append(names, k.String())
+		}
+
+		known := envKnownKeys[v.Type()]
+		if known != nil {
+			names = append(names, known()...)
+		}
+
+		name := ""
+		for _, n := range names {
+			if envKeyName(n) == strings.ToUpper(path[0]) {
+				name = n
+				break
+			}
+		}
+
+		if name == "" {
+			if known != nil {
+				return fmt.Errorf("unknown key '%s'", strings.ToLower(path[0]))
+			}
+			name = strings.ToLower(path[0])
+		}
+
+		key := reflect.ValueOf(name).Convert(v.Type().Key())
+
+		// Map values are not addressable, modify copy and put it back.
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+
+		err := setConfigValue(elem, path[1:], value)
+		if err != nil {
+			return err
+		}
+
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+
+	if len(path) != 0 {
+		return fmt.Errorf("unknown setting '%s'", strings.ToLower(path[0]))
+	}
+
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		switch strings.ToLower(value) {
+		case "y", "yes", "on":
+			v.SetBool(true)
+		case "n", "no", "off":
+			v.SetBool(false)
+		default:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			v.SetBool(b)
+		}
+	case v.Kind() == reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(i))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(strings.Split(strings.Replace(value, " ", "", -1), ",")).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported setting")
+	}
+
+	return nil
+}
+
+// envKnownKeys defines functions which return all valid keys of config maps. Environment variables names are mapped
+// to these keys when keys are not present in config yet.
+var envKnownKeys = map[reflect.Type]func() []string{
+	reflect.TypeOf(model.CollectorsSettings{}): collector.Names,
+}
+
+// envKeyNameRE defines characters which are not allowed in environment variables names.
+var envKeyNameRE = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// envKeyName converts map key to the form used in environment variables names.
+func envKeyName(key string) string {
+	return strings.ToUpper(envKeyNameRE.ReplaceAllString(key, "_"))
+}
+
+// parseLabels parses labels specified in 'name1=value1,name2=value2' format.
+func parseLabels(s string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid label '%s'", pair)
+		}
+
+		labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return labels, nil
+}
+
 // toggleAutoupdate control auto-update setting.
 func toggleAutoupdate(value string) (string, error) {
 	// Empty value explicitly set to 'off'.
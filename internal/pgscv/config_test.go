@@ -4,10 +4,12 @@ import (
 	"github.com/lesovsky/pgscv/internal/filter"
 	"github.com/lesovsky/pgscv/internal/http"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/relabel"
 	"github.com/lesovsky/pgscv/internal/service"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -112,6 +114,46 @@ func TestNewConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "valid: cache TTL",
+			valid: true,
+			file:  "testdata/pgscv-cache-example.yaml",
+			want: &Config{
+				ListenAddress: "127.0.0.1:8080",
+				Defaults:      map[string]string{},
+				CacheTTL:      15 * time.Second,
+			},
+		},
+		{
+			name:  "valid: labels",
+			valid: true,
+			file:  "testdata/pgscv-labels-example.yaml",
+			want: &Config{
+				ListenAddress: "127.0.0.1:8080",
+				Defaults:      map[string]string{},
+				Labels:        map[string]string{"env": "prod", "dc": "fra1"},
+				ServicesConnsSettings: service.ConnsSettings{
+					"postgres:5432": {
+						ServiceType: model.ServiceTypePostgresql,
+						Conninfo:    "host=127.0.0.1 port=5432 dbname=pgscv_fixtures user=pgscv",
+						Labels:      map[string]string{"team": "payments"},
+					},
+				},
+			},
+		},
+		{
+			name:  "valid: relabel",
+			valid: true,
+			file:  "testdata/pgscv-relabel-example.yaml",
+			want: &Config{
+				ListenAddress: "127.0.0.1:8080",
+				Defaults:      map[string]string{},
+				Relabel: relabel.Rules{
+					{Metric: "postgres_.+", Label: "database", Regex: "template.*", Action: "drop"},
+					{Metric: "postgres_(.+)", Action: "rename", Replacement: "pg_$1"},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -190,6 +232,117 @@ func TestConfig_Validate(t *testing.T) {
 			valid: false,
 			in:    &Config{ListenAddress: "127.0.0.1:8080", AuthConfig: http.AuthConfig{Keyfile: "example.key"}},
 		},
+		{
+			name:  "invalid config: negative series limit",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", SeriesLimit: -1},
+		},
+		{
+			name:  "invalid config: invalid exclude_databases",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExcludeDatabases: "["},
+		},
+		{
+			name:  "invalid config: negative databases_min_size",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", DatabasesMinSize: -1},
+		},
+		{
+			name:  "invalid config: negative statement timeout",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", StatementTimeout: -time.Second},
+		},
+		{
+			name:  "invalid config: negative lock timeout",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", LockTimeout: -time.Second},
+		},
+		{
+			name:  "invalid config: negative idle in transaction timeout",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", IdleInTxTimeout: -time.Second},
+		},
+		{
+			name:  "invalid config: negative max connections",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MaxConnections: -1},
+		},
+		{
+			name:  "invalid config: negative breaker threshold",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", BreakerThreshold: -1},
+		},
+		{
+			name:  "invalid config: negative breaker backoff",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", BreakerBackoff: -time.Second},
+		},
+		{
+			name:  "invalid config: invalid unix socket mode",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", UnixSocketMode: "rw-rw----"},
+		},
+		{
+			name:  "invalid config: negative max concurrent scrapes",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MaxConcurrentScrapes: -1},
+		},
+		{
+			name:  "invalid config: negative cache TTL",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", CacheTTL: -time.Second},
+		},
+		{
+			name:  "invalid config: invalid relabel rules",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", Relabel: relabel.Rules{{Action: "invalid"}}},
+		},
+		{
+			name:  "valid config with password files",
+			valid: true,
+			in: &Config{
+				ListenAddress: "127.0.0.1:8080",
+				ServicesConnsSettings: service.ConnsSettings{
+					"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 user=pgscv", PasswordFile: "testdata/password.txt"},
+				},
+				AuthConfig: http.AuthConfig{Username: "user", PasswordFile: "testdata/password.txt"},
+			},
+		},
+		{
+			name:  "invalid config: missing service password file",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", PasswordFile: "testdata/nonexistent"},
+			}},
+		},
+		{
+			name:  "valid config with labels",
+			valid: true,
+			in: &Config{
+				ListenAddress: "127.0.0.1:8080",
+				Labels:        map[string]string{"env": "prod"},
+				ServicesConnsSettings: service.ConnsSettings{
+					"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", Labels: map[string]string{"team": "payments"}},
+				},
+			},
+		},
+		{
+			name:  "invalid config: invalid label name",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", Labels: map[string]string{"invalid-name": "prod"}},
+		},
+		{
+			name:  "invalid config: reserved label name",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", Labels: map[string]string{"service_id": "test"}},
+			}},
+		},
+		{
+			name:  "invalid config: missing auth password file",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", AuthConfig: http.AuthConfig{Username: "user", PasswordFile: "testdata/nonexistent"}},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -211,6 +364,23 @@ func Test_validateCollectorSettings(t *testing.T) {
 	}{
 		{valid: true, settings: nil},
 		{valid: true, settings: make(map[string]model.CollectorSettings)},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/tables": {RowsLimit: 500}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/tables": {RowsLimit: -1}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/tables": {Interval: 5 * time.Minute}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/tables": {Interval: -time.Minute}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/relations": {RowsLimit: 20, SizeThreshold: 1 << 30}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/relations": {SizeThreshold: -1}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/unused_indexes": {ScansThreshold: 10}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/unused_indexes": {ScansThreshold: -1}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/statements": {ResetInterval: 24 * time.Hour}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/statements": {ResetInterval: -time.Hour}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/statements": {LatencyBuckets: []float64{0.01, 0.1, 1}}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/statements": {LatencyBuckets: []float64{0.1, 0.01}}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/statements": {LatencyBuckets: []float64{0, 1}}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/settings": {Baseline: map[string]string{"shared_buffers": "8GB", "pg_stat_statements.max": "5000"}}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/settings": {Baseline: map[string]string{"shared_buffers'); --": "8GB"}}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/custom": {NullValues: model.NullValuesFlag}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/custom": {NullValues: "invalid"}}},
 		{
 			valid: true,
 			settings: map[string]model.CollectorSettings{
@@ -389,23 +559,35 @@ func Test_newConfigFromEnv(t *testing.T) {
 		{
 			valid: true, // Completely valid variables
 			envvars: map[string]string{
-				"PGSCV_LISTEN_ADDRESS":     "127.0.0.1:12345",
-				"PGSCV_NO_TRACK_MODE":      "yes",
-				"PGSCV_DATABASES":          "exampledb",
-				"PGSCV_DISABLE_COLLECTORS": "example/1,example/2, example/3",
-				"POSTGRES_DSN":             "example_dsn",
-				"POSTGRES_DSN_EXAMPLE1":    "example_dsn",
-				"PGBOUNCER_DSN":            "example_dsn",
-				"PGBOUNCER_DSN_EXAMPLE2":   "example_dsn",
-				"PGSCV_AUTH_USERNAME":      "user",
-				"PGSCV_AUTH_PASSWORD":      "pass",
-				"PGSCV_AUTH_KEYFILE":       "keyfile.key",
-				"PGSCV_AUTH_CERTFILE":      "certfile.cert",
+				"PGSCV_LISTEN_ADDRESS":         "127.0.0.1:12345",
+				"PGSCV_NO_TRACK_MODE":          "yes",
+				"PGSCV_DATABASES":              "exampledb",
+				"PGSCV_EXCLUDE_DATABASES":      "^tenant_",
+				"PGSCV_DATABASES_MIN_SIZE":     "1048576",
+				"PGSCV_DISABLE_COLLECTORS":     "example/1,example/2, example/3",
+				"POSTGRES_DSN":                 "example_dsn",
+				"POSTGRES_DSN_EXAMPLE1":        "example_dsn",
+				"PGBOUNCER_DSN":                "example_dsn",
+				"PGBOUNCER_DSN_EXAMPLE2":       "example_dsn",
+				"PGSCV_AUTH_USERNAME":          "user",
+				"PGSCV_AUTH_PASSWORD":          "pass",
+				"PGSCV_AUTH_KEYFILE":           "keyfile.key",
+				"PGSCV_AUTH_CERTFILE":          "certfile.cert",
+				"PGSCV_CACHE_TTL":              "15s",
+				"PGSCV_MAX_CONCURRENT_SCRAPES": "2",
+				"PGSCV_LISTEN_ADDRESSES":       "127.0.0.1:12346, unix:/run/pgscv.sock",
+				"PGSCV_UNIX_SOCKET_MODE":       "0600",
+				"PGSCV_ENABLE_DEBUG":           "yes",
+				"PGSCV_AUDIT":                  "on",
 			},
 			want: &Config{
 				ListenAddress:     "127.0.0.1:12345",
+				ListenAddresses:   []string{"127.0.0.1:12346", "unix:/run/pgscv.sock"},
+				UnixSocketMode:    "0600",
 				NoTrackMode:       true,
 				Databases:         "exampledb",
+				ExcludeDatabases:  "^tenant_",
+				DatabasesMinSize:  1048576,
 				DisableCollectors: []string{"example/1", "example/2", "example/3"},
 				ServicesConnsSettings: map[string]service.ConnSetting{
 					"postgres":  {ServiceType: model.ServiceTypePostgresql, Conninfo: "example_dsn"},
@@ -419,7 +601,11 @@ func Test_newConfigFromEnv(t *testing.T) {
 					Keyfile:  "keyfile.key",
 					Certfile: "certfile.cert",
 				},
-				Defaults: map[string]string{},
+				CacheTTL:             15 * time.Second,
+				MaxConcurrentScrapes: 2,
+				EnableDebug:          true,
+				Audit:                true,
+				Defaults:             map[string]string{},
 			},
 		},
 		{
@@ -430,6 +616,53 @@ func Test_newConfigFromEnv(t *testing.T) {
 			valid:   false, // Invalid pgbouncer DSN key
 			envvars: map[string]string{"PGBOUNCER_DSN_": "example_dsn"},
 		},
+		{
+			valid:   false, // Invalid max concurrent scrapes
+			envvars: map[string]string{"PGSCV_MAX_CONCURRENT_SCRAPES": "invalid"},
+		},
+		{
+			valid:   false, // Invalid cache TTL
+			envvars: map[string]string{"PGSCV_CACHE_TTL": "invalid"},
+		},
+		{
+			valid:   false, // Invalid databases min size
+			envvars: map[string]string{"PGSCV_DATABASES_MIN_SIZE": "1GB"},
+		},
+		{
+			valid: true, // Password files
+			envvars: map[string]string{
+				"POSTGRES_DSN":                         "example_dsn",
+				"POSTGRES_DSN_PASSWORD_FILE":           "/etc/pgscv/postgres",
+				"PGBOUNCER_DSN_EXAMPLE2":               "example_dsn",
+				"PGBOUNCER_DSN_EXAMPLE2_PASSWORD_FILE": "/etc/pgscv/pgbouncer",
+				"PGSCV_AUTH_PASSWORD_FILE":             "/etc/pgscv/auth",
+			},
+			want: &Config{
+				ServicesConnsSettings: map[string]service.ConnSetting{
+					"postgres": {ServiceType: model.ServiceTypePostgresql, Conninfo: "example_dsn", PasswordFile: "/etc/pgscv/postgres"},
+					"EXAMPLE2": {ServiceType: model.ServiceTypePgbouncer, Conninfo: "example_dsn", PasswordFile: "/etc/pgscv/pgbouncer"},
+				},
+				AuthConfig: http.AuthConfig{PasswordFile: "/etc/pgscv/auth"},
+				Defaults:   map[string]string{},
+			},
+		},
+		{
+			valid:   true, // Labels
+			envvars: map[string]string{"PGSCV_LABELS": "env=prod, team=payments"},
+			want: &Config{
+				Labels:                map[string]string{"env": "prod", "team": "payments"},
+				ServicesConnsSettings: map[string]service.ConnSetting{},
+				Defaults:              map[string]string{},
+			},
+		},
+		{
+			valid:   false, // Invalid labels
+			envvars: map[string]string{"PGSCV_LABELS": "env"},
+		},
+		{
+			valid:   false, // Password file for unknown service
+			envvars: map[string]string{"POSTGRES_DSN_EXAMPLE_PASSWORD_FILE": "/etc/pgscv/postgres"},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -451,6 +684,120 @@ func Test_newConfigFromEnv(t *testing.T) {
 	}
 }
 
+func Test_applyEnvOverrides(t *testing.T) {
+	config := &Config{
+		ListenAddress: "127.0.0.1:8080",
+		ServicesConnsSettings: service.ConnsSettings{
+			"postgres:5432": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1"},
+		},
+		CollectorsSettings: model.CollectorsSettings{
+			"postgres/tables": {SeriesLimit: 10},
+		},
+	}
+
+	err := applyEnvOverrides(config, []string{
+		"PGSCV_LISTEN_ADDRESS=127.0.0.1:9999", // not an override
+		"PGSCV__NO_TRACK_MODE=yes",
+		"PGSCV__SERIES_LIMIT=100",
+		"PGSCV__CACHE_TTL=30s",
+		"PGSCV__DISABLE_COLLECTORS=system/cpu, system/netdev",
+		"PGSCV__AUTHENTICATION__USERNAME=user",
+		"PGSCV__LABELS__ENV=prod",
+		"PGSCV__SERVICES__POSTGRES_5432__CONNINFO=host=127.0.0.2",
+		"PGSCV__SERVICES__PGBOUNCER__SERVICE_TYPE=pgbouncer",
+		"PGSCV__COLLECTORS__POSTGRES_TABLES__FILTERS__DATABASE__EXCLUDE=^test$",
+		"PGSCV__COLLECTORS__POSTGRES_UNUSED_INDEXES__ROWS_LIMIT=20",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8080", config.ListenAddress)
+	assert.True(t, config.NoTrackMode)
+	assert.Equal(t, 100, config.SeriesLimit)
+	assert.Equal(t, 30*time.Second, config.CacheTTL)
+	assert.Equal(t, []string{"system/cpu", "system/netdev"}, config.DisableCollectors)
+	assert.Equal(t, "user", config.AuthConfig.Username)
+	assert.Equal(t, map[string]string{"env": "prod"}, config.Labels)
+	assert.Equal(t, service.ConnsSettings{
+		"postgres:5432": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.2"},
+		"pgbouncer":     {ServiceType: model.ServiceTypePgbouncer},
+	}, config.ServicesConnsSettings)
+	assert.Equal(t, 10, config.CollectorsSettings["postgres/tables"].SeriesLimit)
+	assert.Equal(t, "^test$", config.CollectorsSettings["postgres/tables"].Filters["database"].Exclude)
+	assert.Equal(t, 20, config.CollectorsSettings["postgres/unused_indexes"].RowsLimit)
+	assert.NoError(t, validateCollectorSettings(config.CollectorsSettings))
+
+	for _, env := range []string{
+		"PGSCV__UNKNOWN=value",
+		"PGSCV__SERIES_LIMIT=invalid",
+		"PGSCV__CACHE_TTL=invalid",
+		"PGSCV__NO_TRACK_MODE=invalid",
+		"PGSCV__AUTHENTICATION=value",
+		"PGSCV__AUTHENTICATION__UNKNOWN=value",
+		"PGSCV__LISTEN_ADDRESS__UNKNOWN=value",
+		"PGSCV__RELABEL=value",
+		"PGSCV__COLLECTORS__POSTGRES_UNKNOWN__ROWS_LIMIT=20",
+		"PGSCV__=value",
+	} {
+		assert.Error(t, applyEnvOverrides(&Config{}, []string{env}), env)
+	}
+}
+
+func TestConfig_Check(t *testing.T) {
+	testcases := []struct {
+		name string
+		in   *Config
+		want int
+	}{
+		{name: "empty config", in: &Config{}, want: 0},
+		{
+			name: "valid config",
+			in: &Config{
+				DisableCollectors:  []string{"system", "postgres/tables"},
+				CollectorsSettings: model.CollectorsSettings{"postgres/custom": {}},
+				AuthConfig:         http.AuthConfig{EnableTLS: true, Keyfile: "../http/testdata/example.key", Certfile: "../http/testdata/example.crt"},
+			},
+			want: 0,
+		},
+		{
+			name: "invalid config",
+			in: &Config{
+				DisableCollectors:  []string{"postgres/unknown"},
+				CollectorsSettings: model.CollectorsSettings{"postgres/unknown": {}},
+				AuthConfig:         http.AuthConfig{EnableTLS: true, Keyfile: "testdata/unknown.key", Certfile: "testdata/unknown.crt"},
+			},
+			want: 3,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Len(t, tc.in.Check(), tc.want)
+		})
+	}
+}
+
+func TestConfig_redacted(t *testing.T) {
+	config := Config{
+		ListenAddress: "127.0.0.1:8080",
+		Defaults:      map[string]string{"postgres_username": "pgscv", "postgres_password": "secret"},
+		ServicesConnsSettings: service.ConnsSettings{
+			"postgres:5432": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 port=5432 user=pgscv password=secret dbname=postgres"},
+		},
+		AuthConfig: http.AuthConfig{Username: "user", Password: "secret"},
+	}
+
+	got := config.redacted()
+	assert.Equal(t, "pgscv", got.Defaults["postgres_username"])
+	assert.Equal(t, "********", got.Defaults["postgres_password"])
+	assert.Equal(t, "host=127.0.0.1 port=5432 user=pgscv dbname=postgres", got.ServicesConnsSettings["postgres:5432"].Conninfo)
+	assert.Equal(t, "user", got.AuthConfig.Username)
+	assert.Equal(t, "********", got.AuthConfig.Password)
+
+	// Original config must not be changed.
+	assert.Equal(t, "secret", config.Defaults["postgres_password"])
+	assert.Equal(t, "secret", config.AuthConfig.Password)
+	assert.Contains(t, config.ServicesConnsSettings["postgres:5432"].Conninfo, "password=secret")
+}
+
 func Test_toggleAutoupdate(t *testing.T) {
 	testcases := []struct {
 		valid bool
@@ -5,8 +5,12 @@ import (
 	"errors"
 	"github.com/lesovsky/pgscv/internal/http"
 	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/lesovsky/pgscv/internal/proxy"
 	"github.com/lesovsky/pgscv/internal/service"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
 	"sync"
+	"time"
 )
 
 // Start is the application's starting point.
@@ -20,8 +24,29 @@ func Start(ctx context.Context, config *Config) error {
 		ConnDefaults:       config.Defaults,
 		ConnsSettings:      config.ServicesConnsSettings,
 		DatabasesRE:        config.DatabasesRE,
+		ExcludeDatabasesRE: config.ExcludeDatabasesRE,
+		DatabasesMinSize:   config.DatabasesMinSize,
 		DisabledCollectors: config.DisableCollectors,
 		CollectorsSettings: config.CollectorsSettings,
+		Relabel:            config.Relabel,
+		SeriesLimit:        config.SeriesLimit,
+		CacheTTL:           config.CacheTTL,
+		BreakerThreshold:   config.BreakerThreshold,
+		BreakerBackoff:     config.BreakerBackoff,
+		Audit:              config.Audit,
+		Labels:             config.Labels,
+	}
+
+	// Limit duration of statements and waiting for locks of collectors' sessions.
+	store.SetSessionTimeouts(config.StatementTimeout, config.LockTimeout, config.IdleInTxTimeout)
+
+	// Limit number of simultaneous connections to all services, surplus connections wait in a queue.
+	store.SetMaxConnections(config.MaxConnections)
+
+	// Log all executed SQL statements in audit mode.
+	if config.Audit {
+		log.Info("audit mode enabled, all executed SQL statements are logged")
+		store.EnableAudit()
 	}
 
 	if len(config.ServicesConnsSettings) == 0 {
@@ -46,17 +71,40 @@ func Start(ctx context.Context, config *Config) error {
 	// Start HTTP metrics listener.
 	wg.Add(1)
 	go func() {
-		if err := runMetricsListener(ctx, config); err != nil {
+		if err := runMetricsListener(ctx, config, serviceRepo); err != nil {
 			errCh <- err
 		}
 		wg.Done()
 	}()
 
+	// Services are set up, notify systemd about readiness.
+	if ok, err := sdNotify("READY=1"); ok {
+		if err != nil {
+			log.Warnf("send readiness notification to systemd failed: %s", err)
+		} else {
+			log.Debug("readiness notification sent to systemd")
+		}
+	}
+
+	// Send watchdog keepalives from the main loop, if the loop hangs systemd restarts the service.
+	var watchdog <-chan time.Time
+	if interval := sdWatchdogInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		watchdog = ticker.C
+		log.Debugf("systemd watchdog enabled, send keepalives every %s", interval)
+	}
+
 	// Waiting for errors or context cancelling.
 	for {
 		select {
+		case <-watchdog:
+			if _, err := sdNotify("WATCHDOG=1"); err != nil {
+				log.Warnf("send watchdog keepalive to systemd failed: %s", err)
+			}
 		case <-ctx.Done():
 			log.Info("exit signaled, stop application")
+			_, _ = sdNotify("STOPPING=1")
 			cancel()
 			wg.Wait()
 			return nil
@@ -69,10 +117,23 @@ func Start(ctx context.Context, config *Config) error {
 }
 
 // runMetricsListener start HTTP listener accordingly to passed configuration.
-func runMetricsListener(ctx context.Context, config *Config) error {
+func runMetricsListener(ctx context.Context, config *Config, repo *service.Repository) error {
+	var gatherers []prometheus.Gatherer
+	if len(config.Exporters) > 0 {
+		gatherers = append(gatherers, proxy.NewGatherer(config.Exporters))
+	}
+
 	srv := http.NewServer(http.ServerConfig{
-		Addr:       config.ListenAddress,
-		AuthConfig: config.AuthConfig,
+		Addr:                 config.ListenAddress,
+		ExtraAddrs:           config.ListenAddresses,
+		UnixSocketMode:       config.unixSocketMode,
+		AuthConfig:           config.AuthConfig,
+		Version:              config.BinaryVersion,
+		Services:             func() interface{} { return repo.Status() },
+		EnableDebug:          config.EnableDebug,
+		Config:               func() interface{} { return config.redacted() },
+		MaxConcurrentScrapes: config.MaxConcurrentScrapes,
+		Gatherers:            gatherers,
 	})
 
 	errCh := make(chan error)
@@ -0,0 +1,110 @@
+package pgscv
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/collector"
+	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/service"
+	"github.com/lesovsky/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"io"
+)
+
+// Scrape performs single collection of metrics and writes them to passed writer using text exposition format.
+// Collection could be limited to the single service and/or the single collector, empty values mean no limits.
+func Scrape(w io.Writer, config *Config, serviceID string, collectorName string) error {
+	// Gather could return errors along with successfully collected metrics, write them anyway.
+	families, gatherErr := gather(config, serviceID, collectorName)
+
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+			return err
+		}
+	}
+
+	return gatherErr
+}
+
+// gather performs single collection of metrics and returns collected metric families. Collection could be limited
+// to the single service and/or the single collector, empty values mean no limits.
+func gather(config *Config, serviceID string, collectorName string) ([]*dto.MetricFamily, error) {
+	if config.Audit {
+		store.EnableAudit()
+	}
+
+	store.SetSessionTimeouts(config.StatementTimeout, config.LockTimeout, config.IdleInTxTimeout)
+
+	services := map[string]service.ConnSetting{"system:0": {ServiceType: model.ServiceTypeSystem}}
+	for id, cs := range config.ServicesConnsSettings {
+		services[id] = cs
+	}
+
+	if serviceID != "" {
+		cs, ok := services[serviceID]
+		if !ok {
+			return nil, fmt.Errorf("service '%s' not found", serviceID)
+		}
+		services = map[string]service.ConnSetting{serviceID: cs}
+	}
+
+	registry := prometheus.NewRegistry()
+
+	var registered int
+	for id, cs := range services {
+		factories := collector.Factories{}
+		switch cs.ServiceType {
+		case model.ServiceTypeSystem:
+			factories.RegisterSystemCollectors(config.DisableCollectors)
+		case model.ServiceTypePostgresql:
+			factories.RegisterPostgresCollectors(config.DisableCollectors)
+		case model.ServiceTypePgbouncer:
+			factories.RegisterPgbouncerCollectors(config.DisableCollectors)
+		default:
+			continue
+		}
+
+		// Keep only requested collector, skip services which don't have it.
+		if collectorName != "" {
+			factory, ok := factories[collectorName]
+			if !ok {
+				continue
+			}
+			factories = collector.Factories{collectorName: factory}
+		}
+
+		mc, err := collector.NewPgscvCollector(id, factories, collector.Config{
+			NoTrackMode:        config.NoTrackMode,
+			ServiceType:        cs.ServiceType,
+			ConnString:         cs.Conninfo,
+			Settings:           config.CollectorsSettings,
+			DatabasesRE:        config.DatabasesRE,
+			ExcludeDatabasesRE: config.ExcludeDatabasesRE,
+			DatabasesMinSize:   config.DatabasesMinSize,
+			Relabel:            config.Relabel,
+			SeriesLimit:        config.SeriesLimit,
+			Audit:              config.Audit,
+			Labels:             service.MergeLabels(config.Labels, cs.Labels),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create collector for %s failed: %s", id, err)
+		}
+
+		if err := registry.Register(mc); err != nil {
+			return nil, fmt.Errorf("register collector for %s failed: %s", id, err)
+		}
+		registered++
+	}
+
+	if registered == 0 {
+		return nil, fmt.Errorf("no collectors to scrape")
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		return families, fmt.Errorf("gather metrics failed: %s", err)
+	}
+
+	return families, nil
+}
@@ -0,0 +1,20 @@
+package pgscv
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScrape(t *testing.T) {
+	config := &Config{}
+	assert.NoError(t, config.Validate())
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, Scrape(buf, config, "system:0", "system/loadaverage"))
+	assert.Contains(t, buf.String(), "node_load1")
+	assert.NotContains(t, buf.String(), "node_cpu_seconds_total")
+
+	assert.Error(t, Scrape(buf, config, "unknown:0", ""))
+	assert.Error(t, Scrape(buf, config, "system:0", "unknown/collector"))
+}
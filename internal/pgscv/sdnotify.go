@@ -0,0 +1,42 @@
+package pgscv
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends state notification to systemd, when pgSCV is running as a service of 'Type=notify'. Returns false
+// if notifications are not expected by service manager (NOTIFY_SOCKET is not set).
+func sdNotify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = conn.Write([]byte(state))
+	return true, err
+}
+
+// sdWatchdogInterval returns interval for sending watchdog keepalives to systemd. Keepalives are sent twice per
+// interval configured in 'WatchdogSec' setting. Returns zero if watchdog is disabled.
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.Atoi(os.Getenv("WATCHDOG_USEC"))
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	// Watchdog could be configured for another process.
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond / 2
+}
@@ -0,0 +1,47 @@
+package pgscv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_sdNotify(t *testing.T) {
+	assert.NoError(t, os.Unsetenv("NOTIFY_SOCKET"))
+	ok, err := sdNotify("READY=1")
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	t.Setenv("NOTIFY_SOCKET", socket)
+	ok, err = sdNotify("READY=1")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "READY=1", string(buf[:n]))
+}
+
+func Test_sdWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	assert.Equal(t, time.Duration(0), sdWatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "10000000")
+	assert.Equal(t, 5*time.Second, sdWatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	assert.Equal(t, 5*time.Second, sdWatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", "1")
+	assert.Equal(t, time.Duration(0), sdWatchdogInterval())
+}
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTimeout defines default timeout of requests to exporters.
+	defaultTimeout = 10 * time.Second
+	// upMetricName defines name of the metric which shows state of the exporters.
+	upMetricName = "pgscv_exporter_up"
+)
+
+var prefixRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Target describes local exporter which metrics are merged with pgSCV metrics.
+type Target struct {
+	// URL defines address of exporter's metrics endpoint, e.g. 'http://127.0.0.1:9100/metrics'.
+	URL string `yaml:"url"`
+	// Prefix defines prefix added to names of exporter's metrics.
+	Prefix string `yaml:"prefix"`
+	// Timeout defines timeout of requests to exporter.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Targets is the list of local exporters.
+type Targets []Target
+
+// Validate checks targets settings and set defaults.
+func (tt Targets) Validate() error {
+	for i, t := range tt {
+		if t.URL == "" {
+			return fmt.Errorf("exporter %d: url is not specified", i)
+		}
+
+		u, err := url.Parse(t.URL)
+		if err != nil {
+			return fmt.Errorf("exporter %d: invalid url: %s", i, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("exporter %d: unsupported url scheme '%s'", i, u.Scheme)
+		}
+
+		if t.Prefix != "" && !prefixRE.MatchString(t.Prefix) {
+			return fmt.Errorf("exporter %d: invalid prefix '%s'", i, t.Prefix)
+		}
+
+		if t.Timeout < 0 {
+			return fmt.Errorf("exporter %d: invalid timeout %s", i, t.Timeout)
+		}
+		if t.Timeout == 0 {
+			t.Timeout = defaultTimeout
+		}
+
+		// Save updated target back to slice.
+		tt[i] = t
+	}
+
+	return nil
+}
+
+// Gatherer gathers metrics from local exporters.
+type Gatherer struct {
+	targets Targets
+	client  *http.Client
+}
+
+// NewGatherer creates new gatherer of exporters' metrics.
+func NewGatherer(targets Targets) *Gatherer {
+	return &Gatherer{targets: targets, client: &http.Client{}}
+}
+
+// Gather implements prometheus.Gatherer interface. Exporters are requested concurrently, failed exporters are
+// logged and skipped, hence their failures don't affect collection of other metrics. State of each exporter is
+// exposed using 'pgscv_exporter_up' metric.
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	results := make([]map[string]*dto.MetricFamily, len(g.targets))
+
+	var wg sync.WaitGroup
+	for i, t := range g.targets {
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			families, err := g.fetch(t)
+			if err != nil {
+				log.Warnf("gather metrics from exporter %s failed: %s; skip", t.URL, err)
+				return
+			}
+			results[i] = families
+		}(i, t)
+	}
+	wg.Wait()
+
+	up := &dto.MetricFamily{
+		Name: strPtr(upMetricName),
+		Help: strPtr("State of local exporter: 0 is down, 1 is up."),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+
+	var families []*dto.MetricFamily
+	for i, t := range g.targets {
+		var value float64
+		if results[i] != nil {
+			value = 1
+		}
+		up.Metric = append(up.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{{Name: strPtr("exporter"), Value: strPtr(t.URL)}},
+			Gauge: &dto.Gauge{Value: &value},
+		})
+
+		for name, mf := range results[i] {
+			mf.Name = strPtr(t.Prefix + name)
+			families = append(families, mf)
+		}
+	}
+
+	return append(families, up), nil
+}
+
+// fetch requests metrics of the exporter and parses them.
+func (g *Gatherer) fetch(t Target) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequest(http.MethodGet, t.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request text format, it is the only format supported by parser.
+	req.Header.Set("Accept", string(expfmt.FmtText))
+
+	client := *g.client
+	client.Timeout = t.Timeout
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
+
+func strPtr(s string) *string { return &s }
+
+// Check that Gatherer satisfies prometheus.Gatherer interface.
+var _ prometheus.Gatherer = (*Gatherer)(nil)
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTargets_Validate(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		valid bool
+		in    Targets
+	}{
+		{name: "empty", valid: true, in: nil},
+		{name: "valid", valid: true, in: Targets{{URL: "http://127.0.0.1:9100/metrics", Prefix: "exporter_"}}},
+		{name: "empty url", valid: false, in: Targets{{Prefix: "exporter_"}}},
+		{name: "invalid scheme", valid: false, in: Targets{{URL: "ftp://127.0.0.1/metrics"}}},
+		{name: "invalid prefix", valid: false, in: Targets{{URL: "http://127.0.0.1:9100/metrics", Prefix: "1-invalid"}}},
+		{name: "invalid timeout", valid: false, in: Targets{{URL: "http://127.0.0.1:9100/metrics", Timeout: -time.Second}}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, tc.in.Validate())
+				for _, target := range tc.in {
+					assert.Equal(t, defaultTimeout, target.Timeout)
+				}
+			} else {
+				assert.Error(t, tc.in.Validate())
+			}
+		})
+	}
+}
+
+func TestGatherer_Gather(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, "# HELP example_total Example metric.")
+		_, _ = fmt.Fprintln(w, "# TYPE example_total counter")
+		_, _ = fmt.Fprintln(w, `example_total{device="sda"} 10`)
+	}))
+	defer ts.Close()
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failed.Close()
+
+	targets := Targets{{URL: ts.URL, Prefix: "local_"}, {URL: failed.URL}}
+	assert.NoError(t, targets.Validate())
+
+	families, err := NewGatherer(targets).Gather()
+	assert.NoError(t, err)
+	assert.Len(t, families, 2)
+
+	assert.Equal(t, "local_example_total", families[0].GetName())
+	assert.Equal(t, float64(10), families[0].GetMetric()[0].GetCounter().GetValue())
+
+	up := families[1]
+	assert.Equal(t, "pgscv_exporter_up", up.GetName())
+	assert.Len(t, up.GetMetric(), 2)
+	assert.Equal(t, float64(1), up.GetMetric()[0].GetGauge().GetValue())
+	assert.Equal(t, ts.URL, up.GetMetric()[0].GetLabel()[0].GetValue())
+	assert.Equal(t, float64(0), up.GetMetric()[1].GetGauge().GetValue())
+}
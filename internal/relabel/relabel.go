@@ -0,0 +1,254 @@
+package relabel
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// ActionDrop drops metrics which names (and label values, if label is specified) match the rule.
+	ActionDrop = "drop"
+	// ActionKeep keeps metrics which names (and label values, if label is specified) match the rule, drops all other.
+	ActionKeep = "keep"
+	// ActionRename replaces name of matched metrics using 'replacement'.
+	ActionRename = "rename"
+	// ActionReplace replaces value of the label of matched metrics using 'replacement'.
+	ActionReplace = "replace"
+	// ActionLabelDrop removes the label from matched metrics.
+	ActionLabelDrop = "labeldrop"
+)
+
+// Rule describes a single relabeling rule.
+type Rule struct {
+	// Metric defines regexp matched against metric name. Empty value matches all metrics.
+	Metric string `yaml:"metric"`
+	// MetricRE defines compiled regexp object based on Metric.
+	MetricRE *regexp.Regexp `yaml:"-"`
+	// Label defines the label name to which the rule is applied.
+	Label string `yaml:"label"`
+	// Regex defines regexp matched against value of the label. Empty value matches all values.
+	Regex string `yaml:"regex"`
+	// RegexRE defines compiled regexp object based on Regex.
+	RegexRE *regexp.Regexp `yaml:"-"`
+	// Action defines action performed when metric matches the rule.
+	Action string `yaml:"action"`
+	// Replacement defines new metric name or label value, capturing groups ($1, $2, ...) are supported.
+	Replacement string `yaml:"replacement"`
+}
+
+// Rules is the ordered set of relabeling rules.
+type Rules []Rule
+
+// Compile validates rules and compiles their regexps.
+func (rr Rules) Compile() error {
+	for i, r := range rr {
+		switch r.Action {
+		case ActionDrop, ActionKeep:
+		case ActionRename:
+			if r.Replacement == "" {
+				return fmt.Errorf("relabel rule %d: replacement is not specified for '%s' action", i, r.Action)
+			}
+		case ActionReplace, ActionLabelDrop:
+			if r.Label == "" {
+				return fmt.Errorf("relabel rule %d: label is not specified for '%s' action", i, r.Action)
+			}
+		default:
+			return fmt.Errorf("relabel rule %d: invalid action '%s'", i, r.Action)
+		}
+
+		metric := r.Metric
+		if metric == "" {
+			metric = ".*"
+		}
+
+		re, err := regexp.Compile("^(?:" + metric + ")$")
+		if err != nil {
+			return fmt.Errorf("relabel rule %d: invalid metric regexp: %s", i, err)
+		}
+		r.MetricRE = re
+
+		regex := r.Regex
+		if regex == "" {
+			regex = ".*"
+		}
+
+		re, err = regexp.Compile("^(?:" + regex + ")$")
+		if err != nil {
+			return fmt.Errorf("relabel rule %d: invalid regex: %s", i, err)
+		}
+		r.RegexRE = re
+
+		// Save updated rule back to slice.
+		rr[i] = r
+	}
+
+	return nil
+}
+
+// descInfo defines metric properties which are not directly accessible through prometheus.Desc.
+type descInfo struct {
+	name string
+	help string
+}
+
+var (
+	// descInfoCache keeps already parsed descriptors. Key is the string representation of descriptor, hence the size
+	// of cache is limited by number of metrics names.
+	descInfoCache = map[string]descInfo{}
+	descInfoMu    sync.RWMutex
+
+	descNameRE = regexp.MustCompile(`fqName: "([a-zA-Z0-9_:]+)"`)
+	descHelpRE = regexp.MustCompile(`help: ("(?:[^"\\]|\\.)*")`)
+)
+
+// parseDesc returns name and help of passed metric descriptor.
+func parseDesc(desc *prometheus.Desc) (descInfo, error) {
+	key := desc.String()
+
+	descInfoMu.RLock()
+	info, ok := descInfoCache[key]
+	descInfoMu.RUnlock()
+	if ok {
+		return info, nil
+	}
+
+	name := descNameRE.FindStringSubmatch(key)
+	help := descHelpRE.FindStringSubmatch(key)
+	if name == nil || help == nil {
+		return descInfo{}, fmt.Errorf("parse descriptor failed: %s", key)
+	}
+
+	h, err := strconv.Unquote(help[1])
+	if err != nil {
+		return descInfo{}, fmt.Errorf("parse descriptor help failed: %s", err)
+	}
+
+	info = descInfo{name: name[1], help: h}
+
+	descInfoMu.Lock()
+	descInfoCache[key] = info
+	descInfoMu.Unlock()
+
+	return info, nil
+}
+
+// Apply applies rules to passed metric. Returns nil if metric has to be dropped. Metric is returned as-is if no rules
+// have been matched.
+func (rr Rules) Apply(m prometheus.Metric) prometheus.Metric {
+	if len(rr) == 0 || m == nil {
+		return m
+	}
+
+	info, err := parseDesc(m.Desc())
+	if err != nil {
+		log.Warnf("%s; skip relabeling", err)
+		return m
+	}
+
+	// Check metric name against all rules, don't touch metric if no rules matched. Rule 'keep' is always matched,
+	// because it drops all non-matched metrics.
+	var matched bool
+	for _, r := range rr {
+		if r.Action == ActionKeep || r.MetricRE.MatchString(info.name) {
+			matched = true
+			break
+		}
+	}
+
+	if !matched {
+		return m
+	}
+
+	metric := &dto.Metric{}
+	if err := m.Write(metric); err != nil {
+		log.Warnf("read metric failed: %s; skip relabeling", err)
+		return m
+	}
+
+	var valueType prometheus.ValueType
+	var value float64
+	switch {
+	case metric.Counter != nil:
+		valueType, value = prometheus.CounterValue, metric.GetCounter().GetValue()
+	case metric.Gauge != nil:
+		valueType, value = prometheus.GaugeValue, metric.GetGauge().GetValue()
+	case metric.Untyped != nil:
+		valueType, value = prometheus.UntypedValue, metric.GetUntyped().GetValue()
+	default:
+		// Summaries and histograms are not supported.
+		return m
+	}
+
+	name := info.name
+	labels := make(map[string]string, len(metric.GetLabel()))
+	for _, lp := range metric.GetLabel() {
+		labels[lp.GetName()] = lp.GetValue()
+	}
+
+	var changed bool
+	for _, r := range rr {
+		// Metrics with missing labels are considered as having an empty label value.
+		match := r.MetricRE.MatchString(name) && (r.Label == "" || r.RegexRE.MatchString(labels[r.Label]))
+
+		switch r.Action {
+		case ActionDrop:
+			if match {
+				return nil
+			}
+		case ActionKeep:
+			if !match {
+				return nil
+			}
+		case ActionRename:
+			if match {
+				name = r.MetricRE.ReplaceAllString(name, r.Replacement)
+				changed = true
+			}
+		case ActionReplace:
+			if match {
+				labels[r.Label] = r.RegexRE.ReplaceAllString(labels[r.Label], r.Replacement)
+				changed = true
+			}
+		case ActionLabelDrop:
+			if _, ok := labels[r.Label]; ok && r.MetricRE.MatchString(name) {
+				delete(labels, r.Label)
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return m
+	}
+
+	labelNames := make([]string, 0, len(labels))
+	for k := range labels {
+		labelNames = append(labelNames, k)
+	}
+	sort.Strings(labelNames)
+
+	labelValues := make([]string, 0, len(labelNames))
+	for _, k := range labelNames {
+		labelValues = append(labelValues, labels[k])
+	}
+
+	newMetric, err := prometheus.NewConstMetric(prometheus.NewDesc(name, info.help, labelNames, nil), valueType, value, labelValues...)
+	if err != nil {
+		log.Warnf("create relabeled metric failed: %s; skip", err)
+		return nil
+	}
+
+	// Keep explicit timestamp if it has been set by collector.
+	if metric.TimestampMs != nil {
+		newMetric = prometheus.NewMetricWithTimestamp(time.Unix(0, metric.GetTimestampMs()*int64(time.Millisecond)), newMetric)
+	}
+
+	return newMetric
+}
@@ -0,0 +1,161 @@
+package relabel
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRules_Compile(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		valid bool
+		in    Rules
+	}{
+		{name: "empty rules", valid: true, in: nil},
+		{
+			name: "valid rules", valid: true,
+			in: Rules{
+				{Metric: "postgres_.+", Label: "database", Regex: "template.*", Action: ActionDrop},
+				{Metric: "postgres_(.+)", Action: ActionRename, Replacement: "pg_$1"},
+				{Label: "database", Regex: "(.+)_prod", Action: ActionReplace, Replacement: "$1"},
+				{Label: "sid", Action: ActionLabelDrop},
+				{Metric: "pg_.+", Action: ActionKeep},
+			},
+		},
+		{name: "invalid action", valid: false, in: Rules{{Action: "invalid"}}},
+		{name: "empty action", valid: false, in: Rules{{Metric: "test"}}},
+		{name: "rename without replacement", valid: false, in: Rules{{Metric: "test", Action: ActionRename}}},
+		{name: "replace without label", valid: false, in: Rules{{Action: ActionReplace, Replacement: "test"}}},
+		{name: "labeldrop without label", valid: false, in: Rules{{Action: ActionLabelDrop}}},
+		{name: "invalid metric regexp", valid: false, in: Rules{{Metric: "[", Action: ActionDrop}}},
+		{name: "invalid regex", valid: false, in: Rules{{Label: "test", Regex: "[", Action: ActionDrop}}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, tc.in.Compile())
+				for _, r := range tc.in {
+					assert.NotNil(t, r.MetricRE)
+					assert.NotNil(t, r.RegexRE)
+				}
+			} else {
+				assert.Error(t, tc.in.Compile())
+			}
+		})
+	}
+}
+
+func TestRules_Apply(t *testing.T) {
+	var testcases = []struct {
+		name       string
+		rules      Rules
+		wantName   string
+		wantLabels map[string]string
+		wantDrop   bool
+	}{
+		{
+			name:       "no rules",
+			rules:      nil,
+			wantName:   "postgres_example_total",
+			wantLabels: map[string]string{"database": "testdb", "sid": "postgres:5432"},
+		},
+		{
+			name:       "no matched rules",
+			rules:      Rules{{Metric: "node_.+", Action: ActionDrop}},
+			wantName:   "postgres_example_total",
+			wantLabels: map[string]string{"database": "testdb", "sid": "postgres:5432"},
+		},
+		{
+			name:     "drop by name",
+			rules:    Rules{{Metric: "postgres_example_.+", Action: ActionDrop}},
+			wantDrop: true,
+		},
+		{
+			name:     "drop by label",
+			rules:    Rules{{Label: "database", Regex: "test.*", Action: ActionDrop}},
+			wantDrop: true,
+		},
+		{
+			name:       "drop by label, not matched",
+			rules:      Rules{{Label: "database", Regex: "template.*", Action: ActionDrop}},
+			wantName:   "postgres_example_total",
+			wantLabels: map[string]string{"database": "testdb", "sid": "postgres:5432"},
+		},
+		{
+			name:     "keep, not matched",
+			rules:    Rules{{Metric: "node_.+", Action: ActionKeep}},
+			wantDrop: true,
+		},
+		{
+			name:       "keep, matched",
+			rules:      Rules{{Metric: "postgres_.+", Action: ActionKeep}},
+			wantName:   "postgres_example_total",
+			wantLabels: map[string]string{"database": "testdb", "sid": "postgres:5432"},
+		},
+		{
+			name:       "rename",
+			rules:      Rules{{Metric: "postgres_(.+)", Action: ActionRename, Replacement: "pg_$1"}},
+			wantName:   "pg_example_total",
+			wantLabels: map[string]string{"database": "testdb", "sid": "postgres:5432"},
+		},
+		{
+			name:       "replace label value",
+			rules:      Rules{{Label: "database", Regex: "test(.+)", Action: ActionReplace, Replacement: "$1"}},
+			wantName:   "postgres_example_total",
+			wantLabels: map[string]string{"database": "db", "sid": "postgres:5432"},
+		},
+		{
+			name:       "drop label",
+			rules:      Rules{{Label: "sid", Action: ActionLabelDrop}},
+			wantName:   "postgres_example_total",
+			wantLabels: map[string]string{"database": "testdb"},
+		},
+		{
+			name: "rename then drop by new name",
+			rules: Rules{
+				{Metric: "postgres_(.+)", Action: ActionRename, Replacement: "pg_$1"},
+				{Metric: "pg_.+", Action: ActionDrop},
+			},
+			wantDrop: true,
+		},
+	}
+
+	desc := prometheus.NewDesc("postgres_example_total", "Example \"metric\".", []string{"database"}, prometheus.Labels{"sid": "postgres:5432"})
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, tc.rules.Compile())
+
+			ts := time.Unix(1600000000, 0)
+			m := prometheus.NewMetricWithTimestamp(ts, prometheus.MustNewConstMetric(desc, prometheus.CounterValue, 10, "testdb"))
+
+			got := tc.rules.Apply(m)
+			if tc.wantDrop {
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NotNil(t, got)
+
+			info, err := parseDesc(got.Desc())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantName, info.name)
+			assert.Equal(t, "Example \"metric\".", info.help)
+
+			metric := &dto.Metric{}
+			assert.NoError(t, got.Write(metric))
+			assert.Equal(t, float64(10), metric.GetCounter().GetValue())
+			assert.Equal(t, ts.UnixNano()/int64(time.Millisecond), metric.GetTimestampMs())
+
+			labels := map[string]string{}
+			for _, lp := range metric.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			assert.Equal(t, tc.wantLabels, labels)
+		})
+	}
+}
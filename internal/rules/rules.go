@@ -0,0 +1,182 @@
+package rules
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"strings"
+)
+
+const (
+	defaultReplicationLagSeconds = 300
+	defaultConnections           = 200
+	defaultWraparoundXactsLeft   = 500000000
+	defaultDiskForecastHours     = 24
+)
+
+// Thresholds defines thresholds used in generated alerting rules.
+type Thresholds struct {
+	// ReplicationLagSeconds defines max allowed replication lag of standbys, in seconds.
+	ReplicationLagSeconds int `yaml:"replication_lag_seconds"`
+	// Connections defines max allowed number of connections to Postgres.
+	Connections int `yaml:"connections"`
+	// WraparoundXactsLeft defines min number of transactions left before transaction ID wraparound.
+	WraparoundXactsLeft int `yaml:"wraparound_xacts_left"`
+	// DiskForecastHours defines period in hours, if filesystem is predicted to fill up within the period alert fires.
+	DiskForecastHours int `yaml:"disk_forecast_hours"`
+}
+
+// Validate checks thresholds and set defaults.
+func (t *Thresholds) Validate() error {
+	values := []struct {
+		name  string
+		value *int
+		def   int
+	}{
+		{name: "replication_lag_seconds", value: &t.ReplicationLagSeconds, def: defaultReplicationLagSeconds},
+		{name: "connections", value: &t.Connections, def: defaultConnections},
+		{name: "wraparound_xacts_left", value: &t.WraparoundXactsLeft, def: defaultWraparoundXactsLeft},
+		{name: "disk_forecast_hours", value: &t.DiskForecastHours, def: defaultDiskForecastHours},
+	}
+
+	for _, v := range values {
+		if *v.value < 0 {
+			return fmt.Errorf("invalid alerts %s: %d", v.name, *v.value)
+		}
+		if *v.value == 0 {
+			*v.value = v.def
+		}
+	}
+
+	return nil
+}
+
+// Rule describes Prometheus alerting rule.
+type Rule struct {
+	// Collector defines collector which produces metrics used in the rule.
+	Collector   string            `yaml:"-"`
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Group describes group of Prometheus rules.
+type Group struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// New returns alerting rules based on passed thresholds. Rules based on metrics of disabled collectors are skipped.
+func New(t Thresholds, disabled []string) []Group {
+	rules := []Rule{
+		{
+			Collector: "postgres/activity",
+			Alert:     "PostgresDown",
+			Expr:      "postgres_up == 0",
+			For:       "1m",
+			Labels:    map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary": "Postgres {{ $labels.service_id }} is down",
+			},
+		},
+		{
+			Collector: "pgbouncer/stats",
+			Alert:     "PgbouncerDown",
+			Expr:      "pgbouncer_up == 0",
+			For:       "1m",
+			Labels:    map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary": "Pgbouncer {{ $labels.service_id }} is down",
+			},
+		},
+		{
+			Collector: "postgres/replication",
+			Alert:     "PostgresReplicationLag",
+			Expr:      fmt.Sprintf("max by (service_id, application_name, client_addr) (postgres_replication_lag_all_seconds) > %d", t.ReplicationLagSeconds),
+			For:       "5m",
+			Labels:    map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Standby {{ $labels.application_name }} of {{ $labels.service_id }} is lagging",
+				"description": "Replication lag is {{ $value | humanizeDuration }}.",
+			},
+		},
+		{
+			Collector: "postgres/activity",
+			Alert:     "PostgresTooManyConnections",
+			Expr:      fmt.Sprintf("postgres_activity_connections_all_in_flight > %d", t.Connections),
+			For:       "5m",
+			Labels:    map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Too many connections to Postgres {{ $labels.service_id }}",
+				"description": "Number of connections is {{ $value }}.",
+			},
+		},
+		{
+			Collector: "postgres/databases",
+			Alert:     "PostgresXactIDWraparound",
+			Expr:      fmt.Sprintf("min by (service_id, xid_from) (postgres_xacts_left_before_wraparound) < %d", t.WraparoundXactsLeft),
+			For:       "5m",
+			Labels:    map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "Postgres {{ $labels.service_id }} is approaching transaction ID wraparound",
+				"description": "{{ $value }} transactions left before wraparound ({{ $labels.xid_from }}).",
+			},
+		},
+		{
+			Collector: "postgres/archiver",
+			Alert:     "PostgresArchiverFailing",
+			Expr:      "increase(postgres_archiver_failed_total[10m]) > 0",
+			Labels:    map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "WAL archiving fails on Postgres {{ $labels.service_id }}",
+			},
+		},
+		{
+			Collector: "system/filesystems",
+			Alert:     "FilesystemWillFillUp",
+			Expr: fmt.Sprintf(`predict_linear(node_filesystem_bytes{usage="avail"}[6h], %d * 3600) < 0`, t.DiskForecastHours) +
+				` and ignoring(usage) node_filesystem_bytes{usage="avail"} / node_filesystem_bytes_total < 0.2`,
+			For:    "30m",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("Filesystem {{ $labels.mountpoint }} on {{ $labels.instance }} is predicted to fill up within %d hours", t.DiskForecastHours),
+			},
+		},
+	}
+
+	group := Group{Name: "pgscv"}
+	for _, r := range rules {
+		if isDisabled(r.Collector, disabled) {
+			continue
+		}
+		group.Rules = append(group.Rules, r)
+	}
+
+	return []Group{group}
+}
+
+// Write writes rules into passed writer in Prometheus rules file format.
+func Write(w io.Writer, groups []Group) error {
+	data, err := yaml.Marshal(struct {
+		Groups []Group `yaml:"groups"`
+	}{Groups: groups})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// isDisabled returns true if collector or its group is in the list of disabled collectors.
+func isDisabled(collector string, disabled []string) bool {
+	group := strings.Split(collector, "/")[0]
+	for _, d := range disabled {
+		if d == collector || d == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+func TestThresholds_Validate(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		valid bool
+		in    Thresholds
+		want  Thresholds
+	}{
+		{
+			name: "defaults", valid: true, in: Thresholds{},
+			want: Thresholds{ReplicationLagSeconds: 300, Connections: 200, WraparoundXactsLeft: 500000000, DiskForecastHours: 24},
+		},
+		{
+			name: "custom", valid: true, in: Thresholds{ReplicationLagSeconds: 60, Connections: 90},
+			want: Thresholds{ReplicationLagSeconds: 60, Connections: 90, WraparoundXactsLeft: 500000000, DiskForecastHours: 24},
+		},
+		{name: "negative value", valid: false, in: Thresholds{Connections: -1}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, tc.in.Validate())
+				assert.Equal(t, tc.want, tc.in)
+			} else {
+				assert.Error(t, tc.in.Validate())
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	thresholds := Thresholds{ReplicationLagSeconds: 60, Connections: 90, WraparoundXactsLeft: 1000, DiskForecastHours: 12}
+
+	groups := New(thresholds, nil)
+	assert.Len(t, groups, 1)
+	assert.Len(t, groups[0].Rules, 7)
+
+	exprs := map[string]string{}
+	for _, r := range groups[0].Rules {
+		assert.NotEmpty(t, r.Labels["severity"])
+		assert.NotEmpty(t, r.Annotations["summary"])
+		exprs[r.Alert] = r.Expr
+	}
+
+	assert.Equal(t, "max by (service_id, application_name, client_addr) (postgres_replication_lag_all_seconds) > 60", exprs["PostgresReplicationLag"])
+	assert.Equal(t, "postgres_activity_connections_all_in_flight > 90", exprs["PostgresTooManyConnections"])
+	assert.Equal(t, "min by (service_id, xid_from) (postgres_xacts_left_before_wraparound) < 1000", exprs["PostgresXactIDWraparound"])
+	assert.Contains(t, exprs["FilesystemWillFillUp"], `predict_linear(node_filesystem_bytes{usage="avail"}[6h], 12 * 3600) < 0`)
+
+	// Rules based on metrics of disabled collectors are skipped.
+	groups = New(thresholds, []string{"system", "pgbouncer/stats", "postgres/archiver"})
+	for _, r := range groups[0].Rules {
+		assert.NotContains(t, []string{"FilesystemWillFillUp", "PgbouncerDown", "PostgresArchiverFailing"}, r.Alert)
+	}
+	assert.Len(t, groups[0].Rules, 4)
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, New(Thresholds{ReplicationLagSeconds: 300}, nil)))
+
+	var got struct {
+		Groups []Group `yaml:"groups"`
+	}
+	assert.NoError(t, yaml.Unmarshal(buf.Bytes(), &got))
+	assert.Len(t, got.Groups, 1)
+	assert.Equal(t, "pgscv", got.Groups[0].Name)
+	assert.Equal(t, "PostgresDown", got.Groups[0].Rules[0].Alert)
+	assert.Equal(t, "postgres_up == 0", got.Groups[0].Rules[0].Expr)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"github.com/lesovsky/pgscv/internal/model"
+	"net/url"
 	"strings"
 )
 
@@ -13,6 +14,10 @@ type ConnSetting struct {
 	ServiceType string `yaml:"service_type"`
 	// Conninfo is the connection string in service-specific format.
 	Conninfo string `yaml:"conninfo"`
+	// PasswordFile defines path to file with password, the password is added to Conninfo.
+	PasswordFile string `yaml:"password_file"`
+	// Labels defines constant labels attached to all metrics of the service, they override global labels.
+	Labels map[string]string `yaml:"labels"`
 }
 
 // ConnsSettings defines a set of all connection settings of exact services.
@@ -60,3 +65,42 @@ func parseDSNEnv(prefix, key, value string) (string, ConnSetting, error) {
 
 	return id, ConnSetting{ServiceType: stype, Conninfo: value}, nil
 }
+
+// ConninfoWithPassword returns conninfo with specified password. Both URL and keyword/value formats are supported.
+func ConninfoWithPassword(conninfo, password string) (string, error) {
+	if strings.HasPrefix(conninfo, "postgres://") || strings.HasPrefix(conninfo, "postgresql://") {
+		u, err := url.Parse(conninfo)
+		if err != nil {
+			return "", err
+		}
+
+		var username string
+		if u.User != nil {
+			username = u.User.Username()
+		}
+		u.User = url.UserPassword(username, password)
+
+		return u.String(), nil
+	}
+
+	// In keyword/value format the last occurrence of the keyword wins.
+	password = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password)
+	return strings.TrimSpace(conninfo + " password='" + password + "'"), nil
+}
+
+// MergeLabels returns union of global and service's labels, service's labels take precedence.
+func MergeLabels(global, local map[string]string) map[string]string {
+	if len(global) == 0 && len(local) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]string, len(global)+len(local))
+	for k, v := range global {
+		labels[k] = v
+	}
+	for k, v := range local {
+		labels[k] = v
+	}
+
+	return labels
+}
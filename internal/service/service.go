@@ -1,14 +1,18 @@
 package service
 
 import (
+	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/lesovsky/pgscv/internal/collector"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/lesovsky/pgscv/internal/relabel"
 	"github.com/lesovsky/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 	"regexp"
+	"sort"
 	"sync"
+	"time"
 )
 
 // Service struct describes service - the target from which should be collected metrics.
@@ -33,10 +37,28 @@ type Config struct {
 	ConnDefaults  map[string]string `yaml:"defaults"` // Defaults
 	ConnsSettings ConnsSettings
 	// DatabasesRE defines regexp with databases from which builtin metrics should be collected.
-	DatabasesRE        *regexp.Regexp
+	DatabasesRE *regexp.Regexp
+	// ExcludeDatabasesRE defines regexp with databases which should be skipped by collectors.
+	ExcludeDatabasesRE *regexp.Regexp
+	// DatabasesMinSize defines min size of databases in bytes, smaller databases are skipped by collectors.
+	DatabasesMinSize   int64
 	DisabledCollectors []string
 	// CollectorsSettings defines all collector settings propagated from main YAML configuration.
 	CollectorsSettings model.CollectorsSettings
+	// Relabel defines rules for relabeling and renaming collected metrics.
+	Relabel relabel.Rules
+	// SeriesLimit defines max number of series produced by all collectors of the service. Zero means no limit.
+	SeriesLimit int
+	// CacheTTL defines how long collected metrics are reused by subsequent scrapes. Zero means no caching.
+	CacheTTL time.Duration
+	// BreakerThreshold defines number of consecutive failures after which collector is disabled.
+	BreakerThreshold int
+	// BreakerBackoff defines how long failed collector stays disabled.
+	BreakerBackoff time.Duration
+	// Audit defines audit mode is enabled.
+	Audit bool
+	// Labels defines constant labels attached to metrics of all services.
+	Labels map[string]string
 }
 
 // Collector is an interface for prometheus.Collector.
@@ -70,6 +92,11 @@ func (repo *Repository) SetupServices(config Config) error {
 	return repo.setupServices(config)
 }
 
+// Status returns state of all services in the repo.
+func (repo *Repository) Status() []Status {
+	return repo.status()
+}
+
 /* Private methods of Repository */
 
 // addService adds service to the repo.
@@ -106,6 +133,67 @@ func (repo *Repository) getServiceIDs() []string {
 	return serviceIDs
 }
 
+// Status defines state of the service exposed for inspection.
+type Status struct {
+	ServiceID   string   `json:"service_id"`
+	ServiceType string   `json:"service_type"`
+	Conninfo    string   `json:"conninfo,omitempty"`     // connection parameters, without password
+	Version     int      `json:"version,omitempty"`      // detected version of the service
+	LastCollect string   `json:"last_collect,omitempty"` // time of the last collection in RFC3339 format
+	LastError   string   `json:"last_error,omitempty"`   // the error occurred during the last collection
+	LastSuccess string   `json:"last_success,omitempty"` // time of the last collection which reached the service in RFC3339 format
+	Collectors  []string `json:"collectors,omitempty"`   // names of enabled collectors
+}
+
+// status returns state of all services in the repo sorted by service ID.
+func (repo *Repository) status() []Status {
+	ids := repo.getServiceIDs()
+	sort.Strings(ids)
+
+	statuses := make([]Status, 0, len(ids))
+	for _, id := range ids {
+		s := repo.getService(id)
+
+		status := Status{
+			ServiceID:   s.ServiceID,
+			ServiceType: s.ConnSettings.ServiceType,
+			Conninfo:    RedactConninfo(s.ConnSettings.Conninfo),
+		}
+
+		// Collector provides state of the last collection.
+		if c, ok := s.Collector.(interface{ Status() collector.Status }); ok {
+			cs := c.Status()
+			status.Version = cs.Version
+			status.LastError = cs.LastError
+			status.Collectors = cs.Collectors
+			if !cs.LastCollect.IsZero() {
+				status.LastCollect = cs.LastCollect.Format(time.RFC3339)
+			}
+			if !cs.LastSuccess.IsZero() {
+				status.LastSuccess = cs.LastSuccess.Format(time.RFC3339)
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// RedactConninfo returns connection parameters of conninfo string without password and other sensitive options.
+func RedactConninfo(conninfo string) string {
+	if conninfo == "" {
+		return ""
+	}
+
+	config, err := pgx.ParseConfig(conninfo)
+	if err != nil {
+		return "<invalid>"
+	}
+
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s", config.Host, config.Port, config.User, config.Database)
+}
+
 // addServicesFromConfig reads info about services from the config file and fulfill the repo.
 func (repo *Repository) addServicesFromConfig(config Config) {
 	log.Debug("config: add services from configuration")
@@ -164,11 +252,20 @@ func (repo *Repository) setupServices(config Config) error {
 		if service.Collector == nil {
 			factories := collector.Factories{}
 			collectorConfig := collector.Config{
-				NoTrackMode: config.NoTrackMode,
-				ServiceType: service.ConnSettings.ServiceType,
-				ConnString:  service.ConnSettings.Conninfo,
-				Settings:    config.CollectorsSettings,
-				DatabasesRE: config.DatabasesRE,
+				NoTrackMode:        config.NoTrackMode,
+				ServiceType:        service.ConnSettings.ServiceType,
+				ConnString:         service.ConnSettings.Conninfo,
+				Settings:           config.CollectorsSettings,
+				DatabasesRE:        config.DatabasesRE,
+				ExcludeDatabasesRE: config.ExcludeDatabasesRE,
+				DatabasesMinSize:   config.DatabasesMinSize,
+				Relabel:            config.Relabel,
+				SeriesLimit:        config.SeriesLimit,
+				CacheTTL:           config.CacheTTL,
+				BreakerThreshold:   config.BreakerThreshold,
+				BreakerBackoff:     config.BreakerBackoff,
+				Audit:              config.Audit,
+				Labels:             MergeLabels(config.Labels, service.ConnSettings.Labels),
 			}
 
 			switch service.ConnSettings.ServiceType {
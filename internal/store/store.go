@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgx/v4"
 	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
+	"strconv"
+	"time"
 )
 
 const (
@@ -27,9 +30,112 @@ const (
 	dataTypeNumeric uint32 = 1700
 )
 
+// applicationName defines application name reported by sessions, if it is not specified in connection string.
+const applicationName = "pgscv"
+
+// sessionTimeouts defines run-time parameters with timeouts set on every session to Postgres.
+var sessionTimeouts = map[string]string{}
+
+// SetSessionTimeouts sets timeouts applied to every session to Postgres, zero values mean timeouts are not set.
+// Timeouts are not applied to Pgbouncer admin console, which doesn't support these parameters.
+func SetSessionTimeouts(statement, lock, idleInTransaction time.Duration) {
+	params := map[string]time.Duration{
+		"statement_timeout":                   statement,
+		"lock_timeout":                        lock,
+		"idle_in_transaction_session_timeout": idleInTransaction,
+	}
+
+	sessionTimeouts = map[string]string{}
+	for name, v := range params {
+		if v > 0 {
+			sessionTimeouts[name] = strconv.FormatInt(v.Milliseconds(), 10)
+		}
+	}
+}
+
+// connSlots limits number of simultaneously opened connections, nil means no limit.
+var connSlots chan struct{}
+
+// SetMaxConnections limits number of simultaneously opened connections to all services. When limit is reached, new
+// connections wait until one of the opened connections is closed. Zero means no limit.
+func SetMaxConnections(n int) {
+	if n <= 0 {
+		connSlots = nil
+		return
+	}
+	connSlots = make(chan struct{}, n)
+}
+
+// connSlotTimeout defines how long new connection waits for a free slot when number of connections is limited.
+var connSlotTimeout = 30 * time.Second
+
+// acquireConnSlot waits until number of opened connections is below limit and takes the slot. Returns the channel
+// the slot should be returned to. Error is returned if the slot is not taken until context is done.
+func acquireConnSlot(ctx context.Context) (chan struct{}, error) {
+	slots := connSlots
+	if slots == nil {
+		return nil, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return slots, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("wait for free connection slot failed: %s, all %d connections are in use", ctx.Err(), cap(slots))
+	}
+}
+
+// releaseConnSlot returns the slot taken by acquireConnSlot.
+func releaseConnSlot(slots chan struct{}) {
+	if slots != nil {
+		<-slots
+	}
+}
+
+// auditEnabled controls logging of all executed SQL statements.
+var auditEnabled bool
+
+// EnableAudit enables logging of all executed SQL statements.
+func EnableAudit() {
+	auditEnabled = true
+}
+
+// auditLogger implements pgx.Logger interface and logs executed SQL statements.
+type auditLogger struct {
+	database string
+}
+
+// Log implements pgx.Logger interface. Only records related to executed statements are logged.
+func (l auditLogger) Log(_ context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	sql, ok := data["sql"]
+	if !ok {
+		return
+	}
+
+	kv := log.KV{"database": l.database, "sql": fmt.Sprint(sql)}
+
+	if v, ok := data["time"]; ok {
+		kv["duration"] = fmt.Sprint(v)
+	}
+	if v, ok := data["rowCount"]; ok {
+		kv["rows"] = fmt.Sprint(v)
+	}
+	if v, ok := data["commandTag"]; ok {
+		kv["command_tag"] = fmt.Sprint(v)
+	}
+
+	if level == pgx.LogLevelError {
+		log.KVErrorf(kv, "audit: %s failed: %v", msg, data["err"])
+		return
+	}
+
+	log.KVInfof(kv, "audit: %s", msg)
+}
+
 // DB is the database representation
 type DB struct {
-	conn *pgx.Conn // database connection object
+	conn  *pgx.Conn     // database connection object
+	slots chan struct{} // connections limit the connection is accounted in
 }
 
 // New creates new connection to Postgres/Pgbouncer using passed DSN
@@ -48,17 +154,48 @@ func NewWithConfig(config *pgx.ConnConfig) (*DB, error) {
 	config.PreferSimpleProtocol = true
 
 	// Using simple protocol requires explicit options to be set.
-	config.RuntimeParams = map[string]string{
+	params := map[string]string{
 		"standard_conforming_strings": "on",
 		"client_encoding":             "UTF8",
+		"application_name":            applicationName,
+	}
+
+	// Keep application name specified in connection string.
+	if v, ok := config.RuntimeParams["application_name"]; ok {
+		params["application_name"] = v
+	}
+
+	// Pgbouncer admin console is the 'pgbouncer' database, it doesn't accept timeouts.
+	if config.Database != "pgbouncer" {
+		for k, v := range sessionTimeouts {
+			params[k] = v
+		}
+	}
+
+	config.RuntimeParams = params
+
+	// Log all executed statements if audit is enabled.
+	if auditEnabled {
+		config.Logger = auditLogger{database: config.Database}
+		config.LogLevel = pgx.LogLevelInfo
+	}
+
+	// Wait for the free slot if number of connections is limited.
+	ctx, cancel := context.WithTimeout(context.Background(), connSlotTimeout)
+	defer cancel()
+
+	slots, err := acquireConnSlot(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	conn, err := pgx.ConnectConfig(context.Background(), config)
 	if err != nil {
+		releaseConnSlot(slots)
 		return nil, err
 	}
 
-	return &DB{conn: conn}, nil
+	return &DB{conn: conn, slots: slots}, nil
 }
 
 /* public db methods */
@@ -66,6 +203,12 @@ func NewWithConfig(config *pgx.ConnConfig) (*DB, error) {
 // Query is a wrapper on private query() method.
 func (db *DB) Query(query string) (*model.PGResult, error) { return db.query(query) }
 
+// QueryFunc is a wrapper on private queryFunc() method.
+func (db *DB) QueryFunc(query string, fn RowFunc) error { return db.queryFunc(query, fn) }
+
+// QueryBatch is a wrapper on private queryBatch() method.
+func (db *DB) QueryBatch(queries ...string) ([]*model.PGResult, error) { return db.queryBatch(queries) }
+
 // Close is wrapper on private close() method.
 func (db *DB) Close() { db.close() }
 
@@ -74,19 +217,100 @@ func (db *DB) Conn() *pgx.Conn { return db.conn }
 
 /* private db methods */
 
+// RowFunc defines function which processes single row of query result. Values slice is reused for subsequent rows,
+// hence function must copy values which have to be kept after returning.
+type RowFunc func(colnames []pgproto3.FieldDescription, values []sql.NullString) error
+
 // Query method executes passed query and wraps result into model.PGResult struct.
 func (db *DB) query(query string) (*model.PGResult, error) {
 	rows, err := db.Conn().Query(context.Background(), query)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	return collectResult(rows, query)
+}
+
+// queryBatch method sends passed queries to Postgres in a single round trip and wraps their results into
+// model.PGResult structs. Results are returned in the same order as queries.
+func (db *DB) queryBatch(queries []string) ([]*model.PGResult, error) {
+	batch := &pgx.Batch{}
+	for _, query := range queries {
+		batch.Queue(query)
+	}
+
+	br := db.Conn().SendBatch(context.Background(), batch)
+	defer func() { _ = br.Close() }()
+
+	results := make([]*model.PGResult, 0, len(queries))
+	for _, query := range queries {
+		rows, err := br.Query()
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := collectResult(rows, query)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, res)
+	}
+
+	return results, br.Close()
+}
+
+// queryFunc method executes passed query and calls passed function for every row of result. In contrast to query()
+// rows are not materialized in memory.
+func (db *DB) queryFunc(query string, fn RowFunc) error {
+	_, err := db.queryRows(query, fn)
+	return err
+}
+
+// queryRows method executes passed query, calls passed function for every row of result and returns columns
+// descriptions of the result.
+func (db *DB) queryRows(query string, fn RowFunc) ([]pgproto3.FieldDescription, error) {
+	rows, err := db.Conn().Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return readRows(rows, query, fn)
+}
+
+// collectResult reads all rows of query result and wraps them into model.PGResult struct.
+func collectResult(rows pgx.Rows, query string) (*model.PGResult, error) {
+	var nrows int
+
+	// Rows are stored into queryResult iterable store with data and information about stored rows, columns
+	// and columns names.
+	var rowsStore = make([][]sql.NullString, 0, 10)
+
+	colnames, err := readRows(rows, query, func(_ []pgproto3.FieldDescription, values []sql.NullString) error {
+		row := make([]sql.NullString, len(values))
+		copy(row, values)
+		rowsStore = append(rowsStore, row)
+		nrows++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PGResult{
+		Nrows:    nrows,
+		Ncols:    len(colnames),
+		Colnames: colnames,
+		Rows:     rowsStore,
+	}, nil
+}
 
-	// Generic variables describe properties of query result.
-	var (
-		colnames = rows.FieldDescriptions()
-		ncols    = len(colnames)
-		nrows    int
-	)
+// readRows reads rows of query result, calls passed function for every row and returns columns descriptions.
+func readRows(rows pgx.Rows, query string, fn RowFunc) ([]pgproto3.FieldDescription, error) {
+	colnames := rows.FieldDescriptions()
 
 	// Not the all data types could be safely converted into sql.NullString
 	// and conversion errors lead to panic.
@@ -100,35 +324,27 @@ func (db *DB) query(query string) (*model.PGResult, error) {
 	// Storage used for data extracted from rows.
 	// Scan operation supports only slice of interfaces, 'pointers' slice is the intermediate store where all values written.
 	// Next values from 'pointers' associated with type-strict slice - 'values'. When Scan is writing to the 'pointers' it
-	// also writing to the 'values' under the hood. When all pointers/values have been scanned, put them into 'rowsStore'.
-	// Finally we get queryResult iterable store with data and information about stored rows, columns and columns names.
-	var rowsStore = make([][]sql.NullString, 0, 10)
-
-	for rows.Next() {
-		pointers := make([]interface{}, ncols)
-		values := make([]sql.NullString, ncols)
+	// also writing to the 'values' under the hood. Both slices are allocated once and reused for all rows.
+	pointers := make([]interface{}, len(colnames))
+	values := make([]sql.NullString, len(colnames))
 
-		for i := range pointers {
-			pointers[i] = &values[i]
-		}
+	for i := range pointers {
+		pointers[i] = &values[i]
+	}
 
-		err = rows.Scan(pointers...)
+	for rows.Next() {
+		err := rows.Scan(pointers...)
 		if err != nil {
 			log.Warnf("skip collecting stats: %s", err)
 			continue
 		}
-		rowsStore = append(rowsStore, values)
-		nrows++
-	}
 
-	rows.Close()
+		if err := fn(colnames, values); err != nil {
+			return nil, err
+		}
+	}
 
-	return &model.PGResult{
-		Nrows:    nrows,
-		Ncols:    ncols,
-		Colnames: colnames,
-		Rows:     rowsStore,
-	}, nil
+	return colnames, rows.Err()
 }
 
 // Close method closes database connections gracefully.
@@ -137,6 +353,10 @@ func (db *DB) close() {
 	if err != nil {
 		log.Warnf("failed to close database connection: %s; ignore", err)
 	}
+
+	// Return the slot once, repeated closing doesn't affect connections limit.
+	releaseConnSlot(db.slots)
+	db.slots = nil
 }
 
 // isDataTypeSupported tests passed type OID is supported.
@@ -1,13 +1,18 @@
 package store
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgscv/internal/log"
 	"github.com/lesovsky/pgscv/internal/model"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -58,6 +63,84 @@ func TestNewWithConfig(t *testing.T) {
 	}
 }
 
+func TestSetSessionTimeouts(t *testing.T) {
+	SetSessionTimeouts(30*time.Second, 5*time.Second, 0)
+	assert.Equal(t, map[string]string{"statement_timeout": "30000", "lock_timeout": "5000"}, sessionTimeouts)
+
+	// Timeouts are applied to Postgres sessions, but not to Pgbouncer admin console.
+	db := NewTest(t)
+	var v string
+	assert.NoError(t, db.Conn().QueryRow(context.Background(), "SHOW statement_timeout").Scan(&v))
+	assert.Equal(t, "30s", v)
+	assert.NoError(t, db.Conn().QueryRow(context.Background(), "SHOW application_name").Scan(&v))
+	assert.Equal(t, "pgscv", v)
+	db.Close()
+
+	SetSessionTimeouts(0, 0, 0)
+	assert.Empty(t, sessionTimeouts)
+}
+
+func TestSetMaxConnections(t *testing.T) {
+	SetMaxConnections(1)
+	defer SetMaxConnections(0)
+
+	slots, err := acquireConnSlot(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, slots)
+
+	// The second slot is available only after the first one is released.
+	acquired := make(chan struct{})
+	go func() {
+		s, err := acquireConnSlot(context.Background())
+		assert.NoError(t, err)
+		releaseConnSlot(s)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("slot acquired over the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseConnSlot(slots)
+	<-acquired
+
+	// Waiting for the slot is stopped when context is done.
+	slots, err = acquireConnSlot(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = acquireConnSlot(ctx)
+	assert.Error(t, err)
+	releaseConnSlot(slots)
+
+	// No limit.
+	SetMaxConnections(0)
+	slots, err = acquireConnSlot(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, slots)
+}
+
+func TestNewWithConfig_maxConnections(t *testing.T) {
+	SetMaxConnections(1)
+	defer SetMaxConnections(0)
+
+	timeout := connSlotTimeout
+	connSlotTimeout = 50 * time.Millisecond
+	defer func() { connSlotTimeout = timeout }()
+
+	// All slots are occupied, new connection is not waited forever.
+	slots, err := acquireConnSlot(context.Background())
+	assert.NoError(t, err)
+	defer releaseConnSlot(slots)
+
+	_, err = New(TestPostgresConnStr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "connection slot")
+}
+
 func TestDB_Query(t *testing.T) {
 	db := NewTest(t)
 
@@ -112,6 +195,53 @@ func TestDB_Query(t *testing.T) {
 	}
 }
 
+func TestDB_QueryFunc(t *testing.T) {
+	db := NewTest(t)
+
+	var got []string
+	err := db.QueryFunc("SELECT 'example'||i AS example, i+1 AS one FROM generate_series(1,3) as gs(i)", func(colnames []pgproto3.FieldDescription, values []sql.NullString) error {
+		assert.Len(t, colnames, 2)
+		got = append(got, values[0].String+"/"+values[1].String)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"example1/2", "example2/3", "example3/4"}, got)
+
+	// Error returned by function stops processing.
+	var n int
+	err = db.QueryFunc("SELECT i FROM generate_series(1,3) as gs(i)", func(_ []pgproto3.FieldDescription, _ []sql.NullString) error {
+		n++
+		return fmt.Errorf("stop")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Error(t, db.QueryFunc("invalid", func(_ []pgproto3.FieldDescription, _ []sql.NullString) error { return nil }))
+
+	db.Close()
+}
+
+func TestDB_QueryBatch(t *testing.T) {
+	db := NewTest(t)
+
+	res, err := db.QueryBatch(
+		"SELECT 'example' AS example",
+		"SELECT i FROM generate_series(1,3) as gs(i)",
+		"SELECT 1 WHERE false",
+	)
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, "example", res[0].Rows[0][0].String)
+	assert.Equal(t, 3, res[1].Nrows)
+	assert.Equal(t, 0, res[2].Nrows)
+	assert.Equal(t, 1, res[2].Ncols)
+
+	_, err = db.QueryBatch("SELECT 1", "invalid")
+	assert.Error(t, err)
+
+	db.Close()
+}
+
 func TestDB_Close(t *testing.T) {
 	db := NewTest(t)
 	assert.NotNil(t, db)
@@ -152,3 +282,28 @@ func Test_isDataTypeSupported(t *testing.T) {
 		assert.Equal(t, tc.want, isDataTypeSupported(tc.t))
 	}
 }
+
+func Test_auditLogger(t *testing.T) {
+	var buf bytes.Buffer
+	orig := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = orig }()
+
+	l := auditLogger{database: "testdb"}
+
+	// Records not related to statements are not logged.
+	l.Log(context.Background(), pgx.LogLevelInfo, "closed connection", nil)
+	assert.Equal(t, 0, buf.Len())
+
+	l.Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]interface{}{"sql": "SELECT 1", "time": time.Millisecond, "rowCount": 1})
+	assert.Contains(t, buf.String(), `"database":"testdb"`)
+	assert.Contains(t, buf.String(), `"sql":"SELECT 1"`)
+	assert.Contains(t, buf.String(), `"duration":"1ms"`)
+	assert.Contains(t, buf.String(), `"rows":"1"`)
+	assert.Contains(t, buf.String(), `"level":"info"`)
+
+	buf.Reset()
+	l.Log(context.Background(), pgx.LogLevelError, "Query", map[string]interface{}{"sql": "SELECT invalid", "err": "syntax error"})
+	assert.Contains(t, buf.String(), `"level":"error"`)
+	assert.Contains(t, buf.String(), "audit: Query failed: syntax error")
+}